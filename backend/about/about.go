@@ -1,85 +1,114 @@
 package about
 
 import (
-	"context"  // Context is used for database operations
+	"errors"   // For matching store errors
 	"fmt"      // For writing error or information messages to the terminal
 	"net/http" // For HTTP status codes
-	"strconv"  // To convert string expressions to integer
+	"portfolio/problem"
+	"portfolio/validation" // Request validation
+	"strconv"              // To convert string expressions to integer
 
-	"github.com/gin-gonic/gin"        // To use the Gin framework
-	"github.com/jackc/pgx/v5/pgxpool" // To communicate with PostgreSQL database using pgxpool library
+	"github.com/gin-gonic/gin" // To use the Gin framework
 )
 
 // About struct represents a row in the "about" table in the database
 type About struct {
-	ID      int    `json:"id"`      // Displayed as "id" field in JSON output
-	Content string `json:"content"` // Displayed as "content" field in JSON output
+	ID      int    `json:"id"`                                   // Displayed as "id" field in JSON output
+	Content string `json:"content" binding:"notblank,max=20000"` // Displayed as "content" field in JSON output
 }
 
-var Pool *pgxpool.Pool // Variable to hold the database pool globally
+// Handler serves the about endpoints using a Store
+type Handler struct {
+	store Store
+}
 
-// SetDB function sets the externally received database pool to be used in this package
-func SetDB(pool *pgxpool.Pool) {
-	Pool = pool // Incoming pool is assigned to the global variable
+// NewHandler creates about handlers backed by the given store
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
 }
 
 // GetAbouts function retrieves all "about" records from the database and returns them as JSON
-func GetAbouts(c *gin.Context) {
-	rows, err := Pool.Query(context.Background(), "SELECT id, content FROM about") // SQL query is executed
+func (h *Handler) GetAbouts(c *gin.Context) {
+	abouts, err := h.store.List(c.Request.Context()) // Records are fetched from the store
 	if err != nil {
-		fmt.Println(err)                                                                      // If there is an error, print to terminal
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Data could not be retrieved"}) // Return HTTP 500 error as JSON
+		fmt.Println(err)                                                    // If there is an error, print to terminal
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // Return HTTP 500 error as JSON
 		return
 	}
-	defer rows.Close() // Release resources after the query is completed
-
-	var abouts []About // Empty slice to store incoming data
-	for rows.Next() {  // Loop for each row
-		var a About                                          // Temporary About object
-		if err := rows.Scan(&a.ID, &a.Content); err != nil { // Row data is assigned to struct
-			fmt.Println(err)                                                                // Print error if exists
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Row could not be read"}) // Return error as JSON
-			return
-		}
-		abouts = append(abouts, a) // Add struct to slice
-	}
 
 	c.JSON(http.StatusOK, abouts) // Return all records as JSON
 }
 
 // DeleteAbout function deletes the about record with the specified ID
-func DeleteAbout(c *gin.Context) {
+func (h *Handler) DeleteAbout(c *gin.Context) {
 	idStr := c.Param("id")         // Get ID from URL parameter (/api/about/:id format)
 	id, err := strconv.Atoi(idStr) // Convert string ID to integer
 	if err != nil {
-		fmt.Println(err)                                            // Print error
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"}) // Return 400 if invalid ID
+		fmt.Println(err)                                     // Print error
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // Return 400 if invalid ID
 		return
 	}
 
-	_, err = Pool.Exec(context.Background(), "DELETE FROM about WHERE id=$1", id) // Execute delete query
+	err = h.store.Delete(c.Request.Context(), id) // Execute delete
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("About record not found")) // Return 404 if nothing matched
+		return
+	}
 	if err != nil {
-		fmt.Println(err)                                                                  // Print error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete operation failed"}) // Return 500 if failed
+		fmt.Println(err)                                                // Print error
+		problem.Respond(c, problem.Internal("Delete operation failed")) // Return 500 if failed
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "About record deleted."}) // Return success message as JSON
 }
 
-// UpdateAbout function updates the content of the about record with the specified ID
-func UpdateAbout(c *gin.Context) {
+// GetAbout function returns the about record with the specified ID
+func (h *Handler) GetAbout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id")) // Get ID from URL parameter (/api/about/:id format)
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // Return 400 if invalid ID
+		return
+	}
+
+	a, err := h.store.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("About record not found")) // Return 404 if no record
+		return
+	}
+	if err != nil {
+		fmt.Println(err)                                                    // Print error
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // Return 500 if failed
+		return
+	}
+
+	c.JSON(http.StatusOK, a) // Return the record as JSON
+}
+
+// UpdateAbout function updates the content of the about record with the specified ID, taken
+// from the URL on PUT /about/:id and from the body on PUT /about
+func (h *Handler) UpdateAbout(c *gin.Context) {
 	var a About
-	if err := c.ShouldBindJSON(&a); err != nil { // Bind JSON data to struct
-		fmt.Println("JSON parsing error:", err)                       // Print error if exists
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"}) // Return 400 if invalid data
+	if !validation.Bind(c, &a) { // Bind and validate JSON data
 		return
 	}
+	if idStr := c.Param("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr) // The URL decides which record is updated
+		if err != nil {
+			problem.Respond(c, problem.BadRequest("Invalid ID")) // Return 400 if invalid ID
+			return
+		}
+		a.ID = id
+	}
 
-	_, err := Pool.Exec(context.Background(), "UPDATE about SET content=$1 WHERE id=$2", a.Content, a.ID) // Execute update query
+	err := h.store.Update(c.Request.Context(), a) // Execute update
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("About record not found")) // Return 404 if nothing matched
+		return
+	}
 	if err != nil {
-		fmt.Println("Database update error:", err)                              // Print error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"}) // Return 500 if failed
+		fmt.Println("Database update error:", err)            // Print error
+		problem.Respond(c, problem.Internal("Update failed")) // Return 500 if failed
 		return
 	}
 
@@ -87,20 +116,18 @@ func UpdateAbout(c *gin.Context) {
 }
 
 // CreateAbout function adds a new about record
-func CreateAbout(c *gin.Context) {
+func (h *Handler) CreateAbout(c *gin.Context) {
 	var a About
-	if err := c.ShouldBindJSON(&a); err != nil { // Bind JSON data to struct
-		fmt.Println("JSON parsing error:", err)                       // Print error if exists
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"}) // Return 400 if invalid data
+	if !validation.Bind(c, &a) { // Bind and validate JSON data
 		return
 	}
 
-	_, err := Pool.Exec(context.Background(), "INSERT INTO about (content) VALUES ($1)", a.Content) // Execute insert query
-	if err != nil {
-		fmt.Println("Database insert error:", err)                                          // Print error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Record could not be added"}) // Return 500 if failed
+	if err := h.store.Create(c.Request.Context(), &a); err != nil { // Execute insert
+		fmt.Println("Database insert error:", err)                        // Print error
+		problem.Respond(c, problem.Internal("Record could not be added")) // Return 500 if failed
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "New About record added successfully"}) // Return success message with 201
+	c.Header("Location", fmt.Sprintf("/api/about/%d", a.ID)) // Where the new record can be fetched
+	c.JSON(http.StatusCreated, a)                            // Return the new record with 201
 }
@@ -0,0 +1,84 @@
+package about
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore implements Store in process memory, for local development and tests
+type MemoryStore struct {
+	mu     sync.RWMutex
+	abouts []About
+	nextID int
+}
+
+// NewMemoryStore creates an empty in-memory about store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// List returns all about records
+func (s *MemoryStore) List(ctx context.Context) ([]About, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]About(nil), s.abouts...), nil
+}
+
+// Get returns a single about record by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (About, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.abouts[i], nil
+	}
+	return About{}, ErrNotFound
+}
+
+// Create inserts a new about record and stores the generated ID in a
+func (s *MemoryStore) Create(ctx context.Context, a *About) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.ID = s.nextID
+	s.nextID++
+	s.abouts = append(s.abouts, *a)
+	return nil
+}
+
+// Update saves the content of the record with a.ID
+func (s *MemoryStore) Update(ctx context.Context, a About) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(a.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.abouts[i] = a
+	return nil
+}
+
+// Delete removes an about record by ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.abouts = append(s.abouts[:i], s.abouts[i+1:]...)
+	return nil
+}
+
+// index returns the slice position of the record with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, a := range s.abouts {
+		if a.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,64 @@
+package about
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates an about store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// List returns all about records
+func (s *SQLStore) List(ctx context.Context) ([]About, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT id, content FROM about")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var abouts []About
+	for rows.Next() {
+		var a About
+		if err := rows.Scan(&a.ID, &a.Content); err != nil {
+			return nil, err
+		}
+		abouts = append(abouts, a)
+	}
+	return abouts, rows.Err()
+}
+
+// Get returns a single about record by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (About, error) {
+	var a About
+	err := s.conn.QueryRowContext(ctx, "SELECT id, content FROM about WHERE id=$1", id).Scan(&a.ID, &a.Content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return About{}, ErrNotFound
+	}
+	return a, err
+}
+
+// Create inserts a new about record and stores the generated ID in a
+func (s *SQLStore) Create(ctx context.Context, a *About) error {
+	return s.conn.QueryRowContext(ctx, "INSERT INTO about (content) VALUES ($1) RETURNING id", a.Content).Scan(&a.ID)
+}
+
+// Update saves the content of the record with a.ID
+func (s *SQLStore) Update(ctx context.Context, a About) error {
+	result, err := s.conn.ExecContext(ctx, "UPDATE about SET content=$1 WHERE id=$2", a.Content, a.ID)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Delete removes an about record by ID
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM about WHERE id=$1", id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
@@ -0,0 +1,18 @@
+package about
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when no about record matches the given ID
+var ErrNotFound = errors.New("about record not found")
+
+// Store is the persistence interface the about handlers depend on
+type Store interface {
+	List(ctx context.Context) ([]About, error)      // All about records
+	Get(ctx context.Context, id int) (About, error) // Single record by ID
+	Create(ctx context.Context, a *About) error     // Inserts a and sets its ID
+	Update(ctx context.Context, a About) error      // Updates the record with a.ID, or ErrNotFound
+	Delete(ctx context.Context, id int) error       // Removes the record by ID, or ErrNotFound
+}
@@ -1,28 +1,32 @@
 package auth
 
 import (
-	"os"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func jwtSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev-jwt-secret-change-me"
-	}
-	return []byte(secret)
-}
-
 // Claims struct - information stored in the JWT token
 type Claims struct {
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`               // Session the token was issued for, checked on every request
+	Purpose   string `json:"purpose,omitempty"` // Set on restricted tokens such as the 2FA challenge
 	jwt.RegisteredClaims
 }
 
+// ChallengePurpose marks the token returned after the password step of a two-factor login
+const ChallengePurpose = "2fa"
+
+// challengeTTL is how long the user has to enter their second factor
+const challengeTTL = 5 * time.Minute
+
+// ErrWrongPurpose is returned when a restricted token is used where another kind is expected
+var ErrWrongPurpose = errors.New("token cannot be used for this request")
+
 // HashPassword hashes the password
 func HashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -37,35 +41,86 @@ func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-// GenerateToken creates a JWT token
-func GenerateToken(userID int, username string) (string, error) {
-	// Token will be valid for 24 hours
-	expirationTime := time.Now().Add(24 * time.Hour)
+// dummyHash is compared against when a username does not exist, see CheckPasswordDummy
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// CheckPasswordDummy spends as long as CheckPassword without a real hash, so response
+// times do not reveal whether a username exists
+func CheckPasswordDummy(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// GenerateToken creates a short-lived JWT access token for a session
+func GenerateToken(userID int, username string, sessionID string) (string, error) {
+	// Access tokens are short-lived; refresh tokens keep the user logged in
+	expirationTime := time.Now().Add(AccessTokenTTL())
 
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret())
+	return signToken(claims)
 }
 
 // ValidateToken validates the JWT token
 func ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret(), nil
-	})
+	// The key is chosen by the token's "kid" header; only configured algorithms are accepted
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey, jwt.WithValidMethods(validMethods()))
 
 	if err != nil || !token.Valid {
 		return nil, err
 	}
 
+	// Challenge and other restricted tokens never grant API access
+	if claims.Purpose != "" {
+		return nil, ErrWrongPurpose
+	}
+
+	return claims, nil
+}
+
+// GenerateChallengeToken creates the short-lived token that proves the password step of a
+// two-factor login; it can only be exchanged for real tokens together with a valid code
+func GenerateChallengeToken(userID int, username string) (string, error) {
+	claims := &Claims{
+		UserID:   userID,
+		Username: username,
+		Purpose:  ChallengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(challengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signToken(claims)
+}
+
+// ValidateChallengeToken validates a token created by GenerateChallengeToken
+func ValidateChallengeToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey, jwt.WithValidMethods(validMethods()))
+	if err != nil || !token.Valid {
+		return nil, err
+	}
+
+	if claims.Purpose != ChallengePurpose {
+		return nil, ErrWrongPurpose
+	}
+
 	return claims, nil
 }
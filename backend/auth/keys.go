@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keySet holds the key used to sign new tokens and every key still accepted for verification
+type keySet struct {
+	signingKID    string
+	signingMethod jwt.SigningMethod
+	signingKey    crypto.PrivateKey
+	publicKeys    map[string]crypto.PublicKey // kid -> key, published in the JWKS
+	secret        []byte                      // Legacy HS256 secret from JWT_SECRET, if set
+}
+
+// keys is loaded once at startup by LoadKeys
+var keys *keySet
+
+// LoadKeys loads the JWT keys.
+//
+// JWT_KEYS_DIR points to a directory of PEM files (RSA or Ed25519, private or public);
+// each file name without its extension is the key ID ("kid"). JWT_SIGNING_KEY_ID picks
+// the private key that signs new tokens and may be omitted when there is only one.
+// All keys in the directory verify tokens, so a key can be rotated by adding the new key,
+// switching JWT_SIGNING_KEY_ID and deleting the old file once its tokens have expired.
+//
+// Without a key directory, JWT_SECRET enables HS256 signing. If neither is set, release
+// mode refuses to start and other modes use a temporary key that changes on restart.
+func LoadKeys(release bool) error {
+	ks := &keySet{publicKeys: map[string]crypto.PublicKey{}}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		ks.secret = []byte(secret)
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		if err := ks.loadDir(dir, os.Getenv("JWT_SIGNING_KEY_ID")); err != nil {
+			return err
+		}
+	} else if ks.secret != nil {
+		ks.signingMethod = jwt.SigningMethodHS256
+		log.Println("Signing tokens with HS256 from JWT_SECRET; set JWT_KEYS_DIR to use RS256/EdDSA keys and publish a JWKS")
+	} else if release {
+		return fmt.Errorf("no JWT signing key configured: set JWT_KEYS_DIR (or JWT_SECRET) in release mode")
+	} else {
+		// Development only: a fresh key per process, so tokens do not survive a restart
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		ks.signingKID = "dev-ephemeral"
+		ks.signingMethod = jwt.SigningMethodEdDSA
+		ks.signingKey = private
+		ks.publicKeys[ks.signingKID] = public
+		log.Println("WARNING: no JWT key configured, using a temporary development key")
+	}
+
+	keys = ks
+	return nil
+}
+
+// loadDir reads every PEM file in dir and selects the signing key
+func (ks *keySet) loadDir(dir string, signingKID string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no .pem files found in JWT_KEYS_DIR %s", dir)
+	}
+
+	privateKeys := map[string]crypto.PrivateKey{}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		private, public, err := parsePEMKey(content)
+		if err != nil {
+			return fmt.Errorf("key %s: %v", file, err)
+		}
+
+		ks.publicKeys[kid] = public
+		if private != nil {
+			privateKeys[kid] = private
+		}
+	}
+
+	// Without an explicit choice, a single private key is unambiguous
+	if signingKID == "" {
+		if len(privateKeys) != 1 {
+			return fmt.Errorf("JWT_SIGNING_KEY_ID is required when JWT_KEYS_DIR has %d private keys", len(privateKeys))
+		}
+		for kid := range privateKeys {
+			signingKID = kid
+		}
+	}
+
+	private, ok := privateKeys[signingKID]
+	if !ok {
+		return fmt.Errorf("signing key %q is not a private key in JWT_KEYS_DIR", signingKID)
+	}
+
+	ks.signingKID = signingKID
+	ks.signingKey = private
+	ks.signingMethod = methodForKey(ks.publicKeys[signingKID])
+
+	log.Printf("Signing tokens with %s key %q, %d verification key(s) loaded",
+		ks.signingMethod.Alg(), signingKID, len(ks.publicKeys))
+	return nil
+}
+
+// parsePEMKey decodes an RSA or Ed25519 key; private is nil for public key files
+func parsePEMKey(content []byte) (crypto.PrivateKey, crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, nil, fmt.Errorf("not a PEM file")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, &k.PublicKey, nil
+	case ed25519.PrivateKey:
+		return k, k.Public(), nil
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return nil, k, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %T (use RSA or Ed25519)", key)
+	}
+}
+
+// methodForKey returns the JWT algorithm used with a public key
+func methodForKey(key crypto.PublicKey) jwt.SigningMethod {
+	if _, ok := key.(*rsa.PublicKey); ok {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// signToken signs a token with the active key and sets its "kid" header
+func signToken(claims jwt.Claims) (string, error) {
+	if keys == nil {
+		return "", fmt.Errorf("JWT keys are not loaded")
+	}
+
+	token := jwt.NewWithClaims(keys.signingMethod, claims)
+	if keys.signingMethod == jwt.SigningMethodHS256 {
+		return token.SignedString(keys.secret)
+	}
+
+	token.Header["kid"] = keys.signingKID
+	return token.SignedString(keys.signingKey)
+}
+
+// verificationKey picks the key for a token from its "kid" header and algorithm
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if keys == nil {
+		return nil, fmt.Errorf("JWT keys are not loaded")
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if keys.secret == nil {
+			return nil, fmt.Errorf("HS256 tokens are not accepted")
+		}
+		return keys.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keys.publicKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+
+	// The algorithm must match the key type, never what the token claims alone
+	if methodForKey(key).Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("algorithm %s does not match key %q", token.Method.Alg(), kid)
+	}
+	return key, nil
+}
+
+// validMethods lists the algorithms accepted by ValidateToken
+func validMethods() []string {
+	methods := []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+	if keys != nil && keys.secret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	return methods
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // Ed25519 curve name
+	X   string `json:"x,omitempty"`   // Ed25519 public key
+}
+
+// PublicJWKs returns the verification keys, sorted by key ID
+func PublicJWKs() []JWK {
+	jwks := []JWK{}
+	if keys == nil {
+		return jwks
+	}
+
+	for kid, key := range keys.publicKeys {
+		jwk := JWK{Kid: kid, Use: "sig", Alg: methodForKey(key).Alg()}
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		}
+		jwks = append(jwks, jwk)
+	}
+
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+// JWKSHandler serves the public verification keys at /.well-known/jwks.json
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": PublicJWKs()})
+}
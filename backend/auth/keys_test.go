@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey stores key as <kid>.pem in dir, as a private key or, for public keys, a public one
+func writeKey(t *testing.T, dir, kid string, key any) {
+	t.Helper()
+	var block *pem.Block
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+// loadKeyDir loads the keys in dir the way the server does in release mode, restoring the
+// previous keys when the test ends
+func loadKeyDir(t *testing.T, dir, signingKID string) error {
+	t.Helper()
+	previous := keys
+	t.Cleanup(func() { keys = previous })
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_SIGNING_KEY_ID", signingKID)
+	t.Setenv("JWT_SECRET", "")
+	return LoadKeys(true)
+}
+
+// sign creates an access token with any method, key and kid, bypassing the loaded keys
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, &Claims{
+		UserID:    1,
+		Username:  "admin",
+		SessionID: "s1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// header returns the decoded header of a token
+func header(t *testing.T, token string) map[string]any {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Header
+}
+
+func TestSigningKeySelection(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "ed", newEd25519Key(t))
+	writeKey(t, dir, "rsa", newRSAKey(t))
+	writeKey(t, dir, "public", newEd25519Key(t).Public())
+
+	for _, tt := range []struct {
+		kid string
+		alg string
+	}{{"ed", "EdDSA"}, {"rsa", "RS256"}} {
+		if err := loadKeyDir(t, dir, tt.kid); err != nil {
+			t.Fatal(err)
+		}
+		token, err := GenerateToken(1, "admin", "s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := header(t, token); h["kid"] != tt.kid || h["alg"] != tt.alg {
+			t.Errorf("signing with %s: header %v, want kid %s and alg %s", tt.kid, h, tt.kid, tt.alg)
+		}
+		if _, err := ValidateToken(token); err != nil {
+			t.Errorf("signing with %s: %v", tt.kid, err)
+		}
+	}
+
+	// Two private keys need a choice, and only a private key can sign
+	for _, kid := range []string{"", "public", "missing"} {
+		if err := loadKeyDir(t, dir, kid); err == nil {
+			t.Errorf("JWT_SIGNING_KEY_ID %q loaded, want an error", kid)
+		}
+	}
+
+	// A single private key is chosen without JWT_SIGNING_KEY_ID
+	single := t.TempDir()
+	writeKey(t, single, "only", newEd25519Key(t))
+	writeKey(t, single, "public", newEd25519Key(t).Public())
+	if err := loadKeyDir(t, single, ""); err != nil {
+		t.Fatal(err)
+	}
+	if keys.signingKID != "only" {
+		t.Errorf("signing key = %q, want only", keys.signingKID)
+	}
+}
+
+func TestValidateTokenPinsAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	edKey := newEd25519Key(t)
+	rsaKey := newRSAKey(t)
+	writeKey(t, dir, "ed", edKey)
+	writeKey(t, dir, "rsa", rsaKey)
+	if err := loadKeyDir(t, dir, "ed"); err != nil {
+		t.Fatal(err)
+	}
+
+	rsaDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"EdDSA with its key", sign(t, jwt.SigningMethodEdDSA, edKey, "ed"), true},
+		{"RS256 with its key", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa"), true},
+		{"HS256 without JWT_SECRET", sign(t, jwt.SigningMethodHS256, []byte("secret"), ""), false},
+		{"HS256 keyed with a public key", sign(t, jwt.SigningMethodHS256, rsaDER, "rsa"), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "ed"), false},
+		{"unknown kid", sign(t, jwt.SigningMethodEdDSA, edKey, "other"), false},
+		{"no kid", sign(t, jwt.SigningMethodEdDSA, edKey, ""), false},
+		{"EdDSA claiming the RSA kid", sign(t, jwt.SigningMethodEdDSA, edKey, "rsa"), false},
+		{"RS256 claiming the Ed25519 kid", sign(t, jwt.SigningMethodRS256, rsaKey, "ed"), false},
+		{"known kid, foreign key", sign(t, jwt.SigningMethodEdDSA, newEd25519Key(t), "ed"), false},
+	}
+	for _, tt := range tests {
+		claims, err := ValidateToken(tt.token)
+		if tt.valid && (err != nil || claims.UserID != 1) {
+			t.Errorf("%s: got %+v, %v; want valid", tt.name, claims, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: accepted, want rejected", tt.name)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2026-01", newEd25519Key(t))
+	if err := loadKeyDir(t, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	old, err := GenerateToken(1, "admin", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new signing key; tokens of the old one keep working while its file is there
+	writeKey(t, dir, "2026-02", newRSAKey(t))
+	if err := loadKeyDir(t, dir, "2026-02"); err != nil {
+		t.Fatal(err)
+	}
+	current, err := GenerateToken(1, "admin", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := header(t, current)["kid"]; kid != "2026-02" {
+		t.Errorf("new tokens are signed by %v, want 2026-02", kid)
+	}
+	for name, token := range map[string]string{"old": old, "current": current} {
+		if _, err := ValidateToken(token); err != nil {
+			t.Errorf("%s token during rotation: %v", name, err)
+		}
+	}
+
+	// Retiring the old key ends its tokens
+	if err := os.Remove(filepath.Join(dir, "2026-01.pem")); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadKeyDir(t, dir, "2026-02"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(old); err == nil {
+		t.Error("token of the retired key accepted")
+	}
+	if _, err := ValidateToken(current); err != nil {
+		t.Errorf("current token after retiring the old key: %v", err)
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	edKey := newEd25519Key(t)
+	rsaKey := newRSAKey(t)
+	writeKey(t, dir, "b-ed", edKey)
+	writeKey(t, dir, "a-rsa", rsaKey)
+	if err := loadKeyDir(t, dir, "b-ed"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	JWKSHandler(c)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") == "" {
+		t.Fatalf("got %d with Cache-Control %q, want 200 and caching", w.Code, w.Header().Get("Cache-Control"))
+	}
+
+	var body struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Keys) != 2 {
+		t.Fatalf("keys = %v, want 2 sorted by kid", body.Keys)
+	}
+	for _, jwk := range body.Keys {
+		if _, ok := jwk["d"]; ok {
+			t.Errorf("key %s publishes private material", jwk["kid"])
+		}
+	}
+
+	rsaJWK, edJWK := body.Keys[0], body.Keys[1]
+	if rsaJWK["kid"] != "a-rsa" || rsaJWK["kty"] != "RSA" || rsaJWK["alg"] != "RS256" || rsaJWK["use"] != "sig" || rsaJWK["e"] != "AQAB" {
+		t.Errorf("RSA key = %v", rsaJWK)
+	}
+	if n, err := base64.RawURLEncoding.DecodeString(rsaJWK["n"]); err != nil || string(n) != string(rsaKey.N.Bytes()) {
+		t.Errorf("RSA modulus does not match the key: %v", err)
+	}
+	if edJWK["kid"] != "b-ed" || edJWK["kty"] != "OKP" || edJWK["crv"] != "Ed25519" || edJWK["alg"] != "EdDSA" {
+		t.Errorf("Ed25519 key = %v", edJWK)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(edJWK["x"])
+	if err != nil || !ed25519.PublicKey(x).Equal(edKey.Public()) {
+		t.Errorf("Ed25519 x does not match the key: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"time"
+)
+
+// Session and refresh token errors
+var (
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected")
+)
+
+// Session is one login of a user. Every refresh token issued for that login
+// belongs to the same session, so revoking it ends the login everywhere.
+type Session struct {
+	ID        string     `json:"id"`
+	UserID    int        `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RefreshToken is the stored form of an opaque refresh token; only its hash is kept
+type RefreshToken struct {
+	Hash      string
+	SessionID string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time // Set once the token has been exchanged for a new one
+}
+
+// SessionStore persists sessions and their refresh tokens
+type SessionStore interface {
+	CreateSession(ctx context.Context, s Session) error
+	GetSession(ctx context.Context, id string) (Session, error)
+	RevokeSession(ctx context.Context, id string, at time.Time) error
+	RevokeUserSessions(ctx context.Context, userID int, at time.Time) error
+	SaveRefreshToken(ctx context.Context, t RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+	// MarkRefreshTokenUsed sets UsedAt if it is still empty and reports whether it did,
+	// so two concurrent refreshes with the same token cannot both succeed
+	MarkRefreshTokenUsed(ctx context.Context, hash string, at time.Time) (bool, error)
+}
+
+// durationFromEnv reads a Go duration such as "15m" from the environment
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
+
+// AccessTokenTTL is how long an access token stays valid (ACCESS_TOKEN_TTL, default 15m)
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// RefreshTokenTTL is how long a refresh token stays valid (REFRESH_TOKEN_TTL, default 30 days)
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// RandomToken returns a URL-safe random string built from n random bytes
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest under which an opaque token is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// StartSession creates a new session for the user and returns its first refresh token
+func StartSession(ctx context.Context, store SessionStore, userID int) (Session, string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return Session{}, "", err
+	}
+
+	session := Session{
+		ID:        hex.EncodeToString(idBytes),
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.CreateSession(ctx, session); err != nil {
+		return Session{}, "", err
+	}
+
+	refreshToken, err := issueRefreshToken(ctx, store, session.ID)
+	if err != nil {
+		return Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same session.
+// Presenting a token that was already exchanged revokes the whole session,
+// because it means the token was copied by someone else.
+func RotateRefreshToken(ctx context.Context, store SessionStore, token string) (Session, string, error) {
+	hash := HashToken(token)
+	now := time.Now().UTC()
+
+	stored, err := store.GetRefreshToken(ctx, hash)
+	if errors.Is(err, ErrRefreshTokenNotFound) {
+		return Session{}, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	session, err := store.GetSession(ctx, stored.SessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return Session{}, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return Session{}, "", err
+	}
+	if session.RevokedAt != nil {
+		return Session{}, "", ErrInvalidRefreshToken
+	}
+
+	// An already used token means two parties hold the same token family
+	if stored.UsedAt != nil {
+		if err := store.RevokeSession(ctx, session.ID, now); err != nil {
+			return Session{}, "", err
+		}
+		return Session{}, "", ErrRefreshTokenReused
+	}
+
+	if now.After(stored.ExpiresAt) {
+		return Session{}, "", ErrInvalidRefreshToken
+	}
+
+	marked, err := store.MarkRefreshTokenUsed(ctx, hash, now)
+	if err != nil {
+		return Session{}, "", err
+	}
+	if !marked {
+		// Lost a race against another refresh with the same token
+		if err := store.RevokeSession(ctx, session.ID, now); err != nil {
+			return Session{}, "", err
+		}
+		return Session{}, "", ErrRefreshTokenReused
+	}
+
+	refreshToken, err := issueRefreshToken(ctx, store, session.ID)
+	if err != nil {
+		return Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// RevokeRefreshToken ends the session a refresh token belongs to (logout).
+// Unknown tokens are ignored so logging out twice is harmless.
+func RevokeRefreshToken(ctx context.Context, store SessionStore, token string) error {
+	stored, err := store.GetRefreshToken(ctx, HashToken(token))
+	if errors.Is(err, ErrRefreshTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return store.RevokeSession(ctx, stored.SessionID, time.Now().UTC())
+}
+
+// issueRefreshToken creates and stores a new refresh token for a session
+func issueRefreshToken(ctx context.Context, store SessionStore, sessionID string) (string, error) {
+	token, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	err = store.SaveRefreshToken(ctx, RefreshToken{
+		Hash:      HashToken(token),
+		SessionID: sessionID,
+		ExpiresAt: now.Add(RefreshTokenTTL()),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemorySessionStore implements SessionStore in process memory
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	tokens   map[string]RefreshToken // Keyed by token hash
+}
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: map[string]Session{},
+		tokens:   map[string]RefreshToken{},
+	}
+}
+
+// CreateSession inserts a new session
+func (s *MemorySessionStore) CreateSession(ctx context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// GetSession returns a session by ID
+func (s *MemorySessionStore) GetSession(ctx context.Context, id string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// RevokeSession marks a session as revoked
+func (s *MemorySessionStore) RevokeSession(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok && session.RevokedAt == nil {
+		session.RevokedAt = &at
+		s.sessions[id] = session
+	}
+	return nil
+}
+
+// RevokeUserSessions marks every open session of a user as revoked
+func (s *MemorySessionStore) RevokeUserSessions(ctx context.Context, userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			s.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+// SaveRefreshToken inserts a new refresh token hash
+func (s *MemorySessionStore) SaveRefreshToken(ctx context.Context, t RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[t.Hash] = t
+	return nil
+}
+
+// GetRefreshToken returns a refresh token by its hash
+func (s *MemorySessionStore) GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[hash]
+	if !ok {
+		return RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	return t, nil
+}
+
+// MarkRefreshTokenUsed sets UsedAt only if the token has not been used yet
+func (s *MemorySessionStore) MarkRefreshTokenUsed(ctx context.Context, hash string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[hash]
+	if !ok || t.UsedAt != nil {
+		return false, nil
+	}
+	t.UsedAt = &at
+	s.tokens[hash] = t
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"time"
+)
+
+// SQLSessionStore implements SessionStore on PostgreSQL or SQLite
+type SQLSessionStore struct {
+	conn *db.DB
+}
+
+// NewSQLSessionStore creates a session store backed by an SQL database
+func NewSQLSessionStore(conn *db.DB) *SQLSessionStore {
+	return &SQLSessionStore{conn: conn}
+}
+
+// CreateSession inserts a new session
+func (s *SQLSessionStore) CreateSession(ctx context.Context, session Session) error {
+	_, err := s.conn.ExecContext(ctx,
+		"INSERT INTO sessions (id, user_id, created_at) VALUES ($1, $2, $3)",
+		session.ID, session.UserID, session.CreatedAt)
+	return err
+}
+
+// GetSession returns a session by ID
+func (s *SQLSessionStore) GetSession(ctx context.Context, id string) (Session, error) {
+	var session Session
+	var revokedAt sql.NullTime
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT id, user_id, created_at, revoked_at FROM sessions WHERE id=$1", id).
+		Scan(&session.ID, &session.UserID, &session.CreatedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrSessionNotFound
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, err
+}
+
+// RevokeSession marks a session as revoked
+func (s *SQLSessionStore) RevokeSession(ctx context.Context, id string, at time.Time) error {
+	_, err := s.conn.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=$1 WHERE id=$2 AND revoked_at IS NULL", at, id)
+	return err
+}
+
+// RevokeUserSessions marks every open session of a user as revoked
+func (s *SQLSessionStore) RevokeUserSessions(ctx context.Context, userID int, at time.Time) error {
+	_, err := s.conn.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=$1 WHERE user_id=$2 AND revoked_at IS NULL", at, userID)
+	return err
+}
+
+// SaveRefreshToken inserts a new refresh token hash
+func (s *SQLSessionStore) SaveRefreshToken(ctx context.Context, t RefreshToken) error {
+	_, err := s.conn.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, session_id, expires_at, created_at) VALUES ($1, $2, $3, $4)",
+		t.Hash, t.SessionID, t.ExpiresAt, t.CreatedAt)
+	return err
+}
+
+// GetRefreshToken returns a refresh token by its hash
+func (s *SQLSessionStore) GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	var t RefreshToken
+	var usedAt sql.NullTime
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT token_hash, session_id, expires_at, created_at, used_at FROM refresh_tokens WHERE token_hash=$1", hash).
+		Scan(&t.Hash, &t.SessionID, &t.ExpiresAt, &t.CreatedAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	return t, err
+}
+
+// MarkRefreshTokenUsed sets used_at only if the token has not been used yet
+func (s *SQLSessionStore) MarkRefreshTokenUsed(ctx context.Context, hash string, at time.Time) (bool, error) {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at=$1 WHERE token_hash=$2 AND used_at IS NULL", at, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore()
+	session, first, err := StartSession(ctx, store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, second, err := RotateRefreshToken(ctx, store, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != session.ID || second == "" || second == first {
+		t.Fatalf("rotation gave session %s and token %q, want %s and a new token", got.ID, second, session.ID)
+	}
+
+	// The new token works once in turn
+	if _, _, err := RotateRefreshToken(ctx, store, second); err != nil {
+		t.Errorf("rotating the new token: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore()
+	session, first, err := StartSession(ctx, store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := RotateRefreshToken(ctx, store, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Presenting the used token again revokes the session, so the latest token stops working too
+	if _, _, err := RotateRefreshToken(ctx, store, first); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reusing a token = %v, want ErrRefreshTokenReused", err)
+	}
+	if s, err := store.GetSession(ctx, session.ID); err != nil || s.RevokedAt == nil {
+		t.Errorf("session after reuse = %+v, %v; want revoked", s, err)
+	}
+	if _, _, err := RotateRefreshToken(ctx, store, second); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("latest token after reuse = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRotateRefreshTokenRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore()
+
+	if _, _, err := RotateRefreshToken(ctx, store, "unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	session, _, err := StartSession(ctx, store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	expired := RefreshToken{Hash: HashToken("expired"), SessionID: session.ID, ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)}
+	if err := store.SaveRefreshToken(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := RotateRefreshToken(ctx, store, "expired"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired token = %v, want ErrInvalidRefreshToken", err)
+	}
+	if s, _ := store.GetSession(ctx, session.ID); s.RevokedAt != nil {
+		t.Error("an expired token revoked its session")
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySessionStore()
+	session, token, err := StartSession(ctx, store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RevokeRefreshToken(ctx, store, token); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := store.GetSession(ctx, session.ID); s.RevokedAt == nil {
+		t.Error("logout did not revoke the session")
+	}
+	if _, _, err := RotateRefreshToken(ctx, store, token); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("token after logout = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	// Logging out twice, or with an unknown token, is harmless
+	for _, token := range []string{token, "unknown"} {
+		if err := RevokeRefreshToken(ctx, store, token); err != nil {
+			t.Errorf("RevokeRefreshToken(%q) = %v, want nil", token, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package autoreply
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	netmail "net/mail"
+	"os"
+	"portfolio/mail"
+	"portfolio/validation"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// sampleSubmission is used to check the configured texts before they are saved
+var sampleSubmission = mail.ContactMailData{
+	Name:    "Jane Doe",
+	Email:   "jane@example.com",
+	Phone:   "+1 555 0100",
+	Message: "Hello!",
+}
+
+// Interval is the minimum time between two auto-replies to the same address
+// (AUTO_REPLY_INTERVAL, default 24h), so the form cannot be used to flood someone's inbox
+func Interval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("AUTO_REPLY_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// Responder sends auto-replies for contact form submissions
+type Responder struct {
+	store    Store
+	mailer   *mail.Mailer
+	interval time.Duration
+}
+
+// NewResponder creates a responder that sends at most one auto-reply per address per interval
+func NewResponder(store Store, mailer *mail.Mailer, interval time.Duration) *Responder {
+	return &Responder{store: store, mailer: mailer, interval: interval}
+}
+
+// Send acknowledges a saved submission. Nothing is sent when auto-replies are disabled, the
+// address is not a plain email address, or it already got an auto-reply within the interval.
+// Call it only once the submission is stored: the address is reserved right before the
+// message is queued, so a submission that could not be saved does not use up its auto-reply.
+func (r *Responder) Send(ctx context.Context, data mail.ContactMailData) error {
+	settings, err := r.store.GetSettings(ctx)
+	if err != nil || !settings.Enabled {
+		return err
+	}
+
+	to, ok := plainAddress(data.Email)
+	if !ok {
+		return nil
+	}
+
+	subject, body, err := render(settings, data)
+	if err != nil {
+		return err
+	}
+	msg, err := r.mailer.AutoReplyMail(to, mail.AutoReplyMailData{
+		Subject: subject,
+		Body:    body,
+		Message: data.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	allowed, err := r.store.Reserve(ctx, strings.ToLower(to), now, now.Add(-r.interval))
+	if err != nil || !allowed {
+		return err
+	}
+	return r.mailer.SendAutoReplyMail(ctx, msg)
+}
+
+// plainAddress accepts a single bare address such as "jane@example.com", without a display name
+func plainAddress(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+	addr, err := netmail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", false
+	}
+	return addr.Address, true
+}
+
+// render fills the configured subject and body with the submission
+func render(settings Settings, data mail.ContactMailData) (string, string, error) {
+	subject, err := execute("subject", settings.Subject, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := execute("body", settings.Body, data)
+	if err != nil {
+		return "", "", err
+	}
+	// A subject is a single header line
+	return strings.Join(strings.Fields(subject), " "), body, nil
+}
+
+// execute parses and runs one configured text
+func execute(name, text string, data mail.ContactMailData) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// validate checks that the texts render with sample data before they are saved;
+// required fields and lengths are checked by the binding tags
+func validate(settings Settings) validation.Errors {
+	var errs validation.Errors
+	for _, field := range []struct{ name, text string }{{"subject", settings.Subject}, {"body", settings.Body}} {
+		if _, err := execute(field.name, field.text, sampleSubmission); err != nil {
+			errs = append(errs, validation.FieldError{Field: field.name, Reason: "is not a valid template: " + strings.TrimPrefix(err.Error(), field.name+": ")})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,55 @@
+package autoreply
+
+import (
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"portfolio/validation"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the auto-reply settings of the admin API
+type Handler struct {
+	store Store
+}
+
+// NewHandler creates auto-reply handlers backed by the given store
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// GetSettings returns the auto-reply settings
+func (h *Handler) GetSettings(c *gin.Context) {
+	settings, err := h.store.GetSettings(c.Request.Context())
+	if err != nil {
+		fmt.Println("Auto-reply settings error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings replaces the auto-reply settings after checking that the texts render
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	var settings Settings
+	if !validation.Bind(c, &settings) {
+		return
+	}
+	if errs := validate(settings); len(errs) > 0 {
+		validation.Fail(c, errs...)
+		return
+	}
+
+	now := time.Now().UTC()
+	settings.UpdatedAt = &now
+	if err := h.store.SaveSettings(c.Request.Context(), settings); err != nil {
+		fmt.Println("Auto-reply settings update error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
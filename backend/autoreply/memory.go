@@ -0,0 +1,51 @@
+package autoreply
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory
+type MemoryStore struct {
+	mu       sync.Mutex
+	settings *Settings            // Nil until saved
+	lastSent map[string]time.Time // Address -> time of its last auto-reply
+}
+
+// NewMemoryStore creates an in-memory auto-reply store with the default settings
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{lastSent: map[string]time.Time{}}
+}
+
+// GetSettings returns the saved settings, or DefaultSettings when there are none
+func (s *MemoryStore) GetSettings(ctx context.Context) (Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.settings == nil {
+		return DefaultSettings, nil
+	}
+	return *s.settings, nil
+}
+
+// SaveSettings replaces the settings
+func (s *MemoryStore) SaveSettings(ctx context.Context, settings Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings = &settings
+	return nil
+}
+
+// Reserve records an auto-reply to email unless the address already got one after since
+func (s *MemoryStore) Reserve(ctx context.Context, email string, now, since time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.lastSent[email]; ok && !last.Before(since) {
+		return false, nil
+	}
+	s.lastSent[email] = now
+	return true, nil
+}
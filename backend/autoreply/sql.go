@@ -0,0 +1,54 @@
+package autoreply
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates an auto-reply store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// GetSettings returns the saved settings, or DefaultSettings when there are none
+func (s *SQLStore) GetSettings(ctx context.Context) (Settings, error) {
+	var settings Settings
+	var updatedAt time.Time
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT enabled, subject, body, updated_at FROM auto_reply_settings WHERE id=1").
+		Scan(&settings.Enabled, &settings.Subject, &settings.Body, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultSettings, nil
+	}
+	settings.UpdatedAt = &updatedAt
+	return settings, err
+}
+
+// SaveSettings stores the settings in their single row
+func (s *SQLStore) SaveSettings(ctx context.Context, settings Settings) error {
+	_, err := s.conn.ExecContext(ctx, `INSERT INTO auto_reply_settings (id, enabled, subject, body, updated_at) VALUES (1, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET enabled = excluded.enabled, subject = excluded.subject, body = excluded.body, updated_at = excluded.updated_at`,
+		settings.Enabled, settings.Subject, settings.Body, settings.UpdatedAt)
+	return err
+}
+
+// Reserve claims the address in a single statement, so two submissions at the same time
+// cannot both get an auto-reply. The row only changes when the last one was before since.
+func (s *SQLStore) Reserve(ctx context.Context, email string, now, since time.Time) (bool, error) {
+	var reserved string
+	err := s.conn.QueryRowContext(ctx, `INSERT INTO auto_reply_log (email, last_sent_at) VALUES ($1, $2)
+		ON CONFLICT (email) DO UPDATE SET last_sent_at = excluded.last_sent_at WHERE auto_reply_log.last_sent_at < $3
+		RETURNING email`, email, now, since).Scan(&reserved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
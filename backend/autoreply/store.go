@@ -0,0 +1,33 @@
+package autoreply
+
+import (
+	"context"
+	"time"
+)
+
+// Settings configure the acknowledgement sent to visitors who use the contact form.
+// Subject and Body are text/template strings with the submission as data: {{.Name}},
+// {{.Email}}, {{.Phone}} and {{.Message}}.
+type Settings struct {
+	Enabled   bool       `json:"enabled"`
+	Subject   string     `json:"subject" binding:"notblank,max=200"`
+	Body      string     `json:"body" binding:"notblank,max=5000"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // Nil until the settings are first saved
+}
+
+// DefaultSettings are used until an admin saves their own; auto-replies start disabled
+var DefaultSettings = Settings{
+	Enabled: false,
+	Subject: "Thanks for your message, {{.Name}}",
+	Body: "Hi {{.Name}},\n\nThanks for getting in touch. I have received your message " +
+		"and will reply as soon as I can.\n\nBest regards",
+}
+
+// Store persists the settings and when each address last got an auto-reply
+type Store interface {
+	GetSettings(ctx context.Context) (Settings, error) // DefaultSettings when never saved
+	SaveSettings(ctx context.Context, s Settings) error
+	// Reserve records an auto-reply to email at now, unless the address already got one after
+	// since, and reports whether the auto-reply may be sent
+	Reserve(ctx context.Context, email string, now, since time.Time) (bool, error)
+}
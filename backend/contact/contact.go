@@ -1,132 +1,238 @@
 package contact
 
 import (
-	"context"        // For context in database operations
-	"fmt"            // For printing and formatting to console
-	"net/http"       // For HTTP status codes
-	"portfolio/mail" // Mail package import
-	"strconv"        // For string-integer conversion
-	"time"           // For time.Time type
-
-	"github.com/gin-gonic/gin"        // Gin framework usage
-	"github.com/jackc/pgx/v5/pgxpool" // PostgreSQL connection pool library
+	"errors"              // For matching sentinel errors
+	"fmt"                 // For printing and formatting to console
+	"net/http"            // For HTTP status codes
+	"portfolio/autoreply" // Visitor acknowledgements
+	"portfolio/listing"   // Pagination and sorting
+	"portfolio/mail"      // Mail package import
+	"portfolio/problem"
+	"portfolio/spam"       // Bot and abuse checks
+	"portfolio/user"       // Assignees are admin users
+	"portfolio/validation" // Request validation
+	"strconv"              // For string-integer conversion
+	"strings"              // For joining spam reasons
+	"time"                 // For time.Time type
+
+	"github.com/gin-gonic/gin" // Gin framework usage
 )
 
 // Contact struct represents the contact table
 type Contact struct {
-	ID        int       `json:"id"`         // ID field, sent as "id" in JSON
-	Name      string    `json:"name"`       // Name field, sent as "name" in JSON
-	Email     string    `json:"email"`      // Email field, sent as "email" in JSON
-	Phone     string    `json:"phone"`      // Phone number, sent as "phone" in JSON
-	Message   string    `json:"message"`    // Message content, sent as "message" in JSON
-	CreatedAt time.Time `json:"created_at"` // Created timestamp, sent as "created_at" in JSON
+	ID          int       `json:"id"`                                     // ID field, sent as "id" in JSON
+	Name        string    `json:"name" binding:"notblank,max=100"`        // Name field, sent as "name" in JSON
+	Email       string    `json:"email" binding:"required,email,max=254"` // Email field, sent as "email" in JSON
+	Phone       string    `json:"phone" binding:"max=40"`                 // Phone number, sent as "phone" in JSON
+	Message     string    `json:"message" binding:"notblank,max=5000"`    // Message content, sent as "message" in JSON
+	CreatedAt   time.Time `json:"created_at"`                             // Created timestamp, sent as "created_at" in JSON
+	Status      string    `json:"status"`                                 // Workflow status, see StatusNew and the others
+	AssignedTo  *int      `json:"assigned_to"`                            // Admin user handling the message, or null
+	IP          string    `json:"ip,omitempty"`                           // Address the form was sent from, for the rate limit
+	SpamReasons []string  `json:"spam_reasons,omitempty"`                 // Why the message was flagged, see the spam package
 }
 
-var Pool *pgxpool.Pool // Global database pool is stored
+// ContactRequest is the body of POST /api/contact
+type ContactRequest struct {
+	Name      string `json:"name" binding:"notblank,max=100"`
+	Email     string `json:"email" binding:"required,email,max=254"`
+	Phone     string `json:"phone" binding:"max=40"`
+	Message   string `json:"message" binding:"notblank,max=5000"`
+	Website   string `json:"website"`                      // Honeypot: hidden from people by the form, filled in by bots
+	FormToken string `json:"form_token" binding:"max=200"` // From GET /api/contact/token
+	Captcha   string `json:"captcha" binding:"max=4096"`   // CAPTCHA response, when one is configured
+}
+
+// maxContactBodyBytes caps the size of a contact form post
+const maxContactBodyBytes = 64 << 10
+
+// Handler serves the contact endpoints using a Store
+type Handler struct {
+	store     Store
+	users     user.Store // To check assignees
+	mailer    *mail.Mailer
+	autoReply *autoreply.Responder
+	spam      *spam.Checker
+}
 
-func SetDB(pool *pgxpool.Pool) {
-	Pool = pool // The incoming pool is assigned to the global Pool variable
+// NewHandler creates contact handlers backed by the given stores, mailer, auto-responder and spam checker
+func NewHandler(store Store, users user.Store, mailer *mail.Mailer, autoReply *autoreply.Responder, checker *spam.Checker) *Handler {
+	return &Handler{store: store, users: users, mailer: mailer, autoReply: autoReply, spam: checker}
 }
 
-func DeleteContact(c *gin.Context) {
+func (h *Handler) DeleteContact(c *gin.Context) {
 	idStr := c.Param("id")         // Get ID from URL parameter (/api/contact/:id)
 	id, err := strconv.Atoi(idStr) // Convert string ID to integer
 	if err != nil {
-		fmt.Println("ID conversion error:", err)                    // Print error to console
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"}) // Return 400 Bad Request
+		fmt.Println("ID conversion error:", err)             // Print error to console
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // Return 400 Bad Request
 		return
 	}
 
-	_, err = Pool.Exec(context.Background(), "DELETE FROM contact WHERE id=$1", id) // Execute delete query
+	err = h.store.Delete(c.Request.Context(), id) // Execute delete
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Contact not found")) // Return 404 Not Found
+		return
+	}
 	if err != nil {
-		fmt.Println("Delete error:", err)                                                 // Print error to console
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete operation failed"}) // Return 500 Internal Server Error
+		fmt.Println("Delete error:", err)                               // Print error to console
+		problem.Respond(c, problem.Internal("Delete operation failed")) // Return 500 Internal Server Error
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Contact ID %d deleted successfully", id)}) // Return success message as JSON
 }
 
-func GetContacts(c *gin.Context) {
-	// SELECT query to fetch created_at field as well
-	rows, err := Pool.Query(context.Background(), "SELECT id, name, email, phone, message, created_at FROM contact ORDER BY created_at DESC")
+func (h *Handler) GetContacts(c *gin.Context) {
+	filter, err := parseFilter(c) // ?status=, ?from=, ?to=, ?assigned_to=, ?q=, page and sort
 	if err != nil {
-		fmt.Println("Data fetch error:", err)                                                 // Print error to console
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Data could not be retrieved"}) // Return 500
+		problem.Respond(c, problem.BadRequest(err.Error())) // Return 400
 		return
 	}
-	defer rows.Close() // Close resource at the end of the function
 
-	var contacts []Contact // List of Contact structs
-	for rows.Next() {      // Loop row by row
-		var cct Contact
-		// Scan including created_at field as time.Time
-		if err := rows.Scan(&cct.ID, &cct.Name, &cct.Email, &cct.Phone, &cct.Message, &cct.CreatedAt); err != nil {
-			fmt.Println("Error reading row:", err)                                          // Print error to console
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Row could not be read"}) // Return 500
-			return
-		}
-		contacts = append(contacts, cct) // Add to list
+	contacts, total, err := h.store.List(c.Request.Context(), filter) // Newest messages first by default
+	if err != nil {
+		fmt.Println("Data fetch error:", err)                               // Print error to console
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // Return 500
+		return
 	}
 
-	fmt.Printf("Found %d contacts in database\n", len(contacts)) // Debug log
-	c.JSON(http.StatusOK, contacts)                              // Return all records as JSON
+	listing.SetHeaders(c, filter.Params, total) // X-Total-Count and Link
+	c.JSON(http.StatusOK, contacts)             // Return the page as JSON
 }
 
-func UpdateContact(c *gin.Context) {
+// UpdateContact edits a message, taken from the URL on PUT /contact/:id and from the body on PUT /contact
+func (h *Handler) UpdateContact(c *gin.Context) {
 	var contact Contact
-	if err := c.ShouldBindJSON(&contact); err != nil { // Bind JSON data to struct
-		fmt.Println("JSON parsing error:", err)                       // Print error to console
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"}) // Return 400
+	if !validation.Bind(c, &contact) { // Bind and validate JSON data
 		return
 	}
+	if idStr := c.Param("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr) // The URL decides which message is updated
+		if err != nil {
+			problem.Respond(c, problem.BadRequest("Invalid ID")) // Return 400 Bad Request
+			return
+		}
+		contact.ID = id
+	}
 
-	_, err := Pool.Exec(context.Background(),
-		"UPDATE contact SET name=$1, email=$2, phone=$3, message=$4 WHERE id=$5",
-		contact.Name, contact.Email, contact.Phone, contact.Message, contact.ID) // Execute update query
+	err := h.store.Update(c.Request.Context(), contact) // Execute update
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Contact not found")) // Return 404 Not Found
+		return
+	}
 	if err != nil {
-		fmt.Println("Database update error:", err)                              // Print error to console
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"}) // Return 500
+		fmt.Println("Database update error:", err)            // Print error to console
+		problem.Respond(c, problem.Internal("Update failed")) // Return 500
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Contact ID %d updated successfully", contact.ID)}) // Return success message
 }
 
-// CreateContact - UPDATED with mail integration
-func CreateContact(c *gin.Context) {
-	var contact Contact
-	if err := c.ShouldBindJSON(&contact); err != nil { // Bind JSON data to struct
-		fmt.Println("JSON parsing error:", err)                       // Print error to console
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"}) // Return 400
+// CreateContact saves a contact form submission and queues the notification email with it.
+// Submissions that fail the spam checks are stored with the spam status and not mailed; the
+// response is the same, so bots learn nothing from it.
+func (h *Handler) CreateContact(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxContactBodyBytes)
+
+	var req ContactRequest
+	if !validation.Bind(c, &req) { // Bind and validate JSON data
 		return
 	}
+	ctx := c.Request.Context()
 
-	// Insert into database first (created_at will be set automatically by database)
-	_, err := Pool.Exec(context.Background(),
-		"INSERT INTO contact (name, email, phone, message) VALUES ($1, $2, $3, $4)",
-		contact.Name, contact.Email, contact.Phone, contact.Message) // Execute insert query
-	if err != nil {
-		fmt.Println("Database insert error:", err)                                          // Print error to console
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Record could not be added"}) // Return 500
+	if !h.allowSubmission(c) {
 		return
 	}
 
-	// Send email notification
-	mailData := mail.ContactMailData{
-		Name:    contact.Name,
-		Email:   contact.Email,
-		Phone:   contact.Phone,
-		Message: contact.Message,
+	submission := spam.Submission{
+		IP:        c.ClientIP(),
+		Honeypot:  req.Website,
+		FormToken: req.FormToken,
+		Captcha:   req.Captcha,
+		Name:      req.Name,
+		Message:   req.Message,
+	}
+	if err := h.spam.VerifyCaptcha(ctx, submission); err != nil {
+		if !errors.Is(err, spam.ErrCaptchaFailed) {
+			fmt.Println("CAPTCHA verification error:", err)
+		}
+		problem.Respond(c, problem.BadRequest("Please complete the CAPTCHA"))
+		return
 	}
 
-	// Send mail (error handling but don't block the response)
-	if mailErr := mail.SendContactMail(mailData); mailErr != nil {
-		fmt.Printf("Mail sending failed: %v\n", mailErr)
-		// Even if mail fails, contact was saved, return success response
+	contact := Contact{
+		Name:    req.Name,
+		Email:   req.Email,
+		Phone:   req.Phone,
+		Message: req.Message,
+		Status:  StatusNew,
+		IP:      submission.IP,
+	}
+
+	var notify []mail.Message
+	if contact.SpamReasons = h.spam.Check(submission); len(contact.SpamReasons) > 0 {
+		// Kept for review in the admin panel, but nobody is emailed
+		contact.Status = StatusSpam
+		fmt.Printf("Contact form submission from %s flagged as spam: %s\n", contact.IP, strings.Join(contact.SpamReasons, ", "))
 	} else {
-		fmt.Println("Contact mail sent successfully!")
+		notify = h.notifications(contact)
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Contact record added successfully and email sent"}) // Return success message with 201
+	// The record and its notification are saved together, or not at all
+	if err := h.store.Create(ctx, &contact, notify...); err != nil { // Execute insert
+		fmt.Println("Database insert error:", err)                        // Print error to console
+		problem.Respond(c, problem.Internal("Record could not be added")) // Return 500
+		return
+	}
+
+	// Acknowledged only once saved, so a failed insert does not use up the visitor's auto-reply
+	if contact.Status != StatusSpam {
+		if err := h.autoReply.Send(ctx, mailData(contact)); err != nil {
+			fmt.Println("Auto-reply error:", err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Contact record added successfully"}) // Return success message with 201
+}
+
+// allowSubmission answers 429 and returns false while the client has sent too many messages
+func (h *Handler) allowSubmission(c *gin.Context) bool {
+	wait, err := h.spam.Allow(c.Request.Context(), c.ClientIP())
+	if err != nil {
+		fmt.Println("Rate limit error:", err)
+		problem.Respond(c, problem.Internal("Record could not be added"))
+		return false
+	}
+	if wait == 0 {
+		return true
+	}
+
+	problem.Respond(c, problem.TooManyRequests("Too many messages, please try again later", wait))
+	return false
+}
+
+// notifications returns the emails queued with a genuine submission: the notification for
+// TO_EMAIL. The acknowledgement for the visitor is sent by the auto-responder once the
+// submission is saved.
+func (h *Handler) notifications(contact Contact) []mail.Message {
+	// Delivered by the outbox worker so the visitor never waits for it
+	notification, err := h.mailer.ContactMail(mailData(contact))
+	if err != nil {
+		// The message is still saved and visible in the admin panel
+		fmt.Println("Contact mail template error:", err)
+		return nil
+	}
+	return []mail.Message{notification}
+}
+
+// mailData is the submission as the contact mail templates see it
+func mailData(contact Contact) mail.ContactMailData {
+	return mail.ContactMailData{
+		Name:    contact.Name,
+		Email:   contact.Email,
+		Phone:   contact.Phone,
+		Message: contact.Message,
+	}
 }
@@ -0,0 +1,362 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"portfolio/autoreply"
+	"portfolio/internal/testutil"
+	"portfolio/mail"
+	"portfolio/outbox"
+	"portfolio/spam"
+	"portfolio/user"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// testEnv is a contact handler on memory stores, routed like routes.SetupRoutes
+type testEnv struct {
+	router  *gin.Engine
+	store   *MemoryStore
+	outbox  *outbox.MemoryStore
+	checker *spam.Checker
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	queue := outbox.NewMemoryStore()
+	store := NewMemoryStore(queue)
+	checker, err := spam.NewChecker(spam.Config{
+		TokenMaxAge:  time.Hour,
+		TokenSecret:  "test",
+		RequireToken: true,
+		RateLimit:    5,
+		RateWindow:   time.Hour,
+		MaxLinks:     2,
+	}, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer := testutil.Mailer(t, outbox.NewQueue(queue))
+	h := NewHandler(store, user.NewMemoryStore(), mailer,
+		autoreply.NewResponder(autoreply.NewMemoryStore(), mailer, time.Hour), checker)
+
+	r := gin.New()
+	r.GET("/api/contact/token", spam.NewHandler(checker).GetFormToken)
+	r.POST("/api/contact", h.CreateContact)
+	admin := r.Group("/api/admin")
+	admin.GET("/contact", h.GetContacts)
+	admin.GET("/contact/:id", h.GetContact)
+	admin.PUT("/contact/:id/status", h.SetStatus)
+	admin.POST("/contact/:id/replies", h.CreateReply)
+	admin.DELETE("/contact/:id/notes/:noteId", h.DeleteNote)
+	admin.DELETE("/contact/:id", h.DeleteContact)
+	return &testEnv{router: r, store: store, outbox: queue, checker: checker}
+}
+
+// do sends a JSON request
+func (env *testEnv) do(method, path string, body any) *httptest.ResponseRecorder {
+	return testutil.Do(env.router, method, path, body)
+}
+
+// formToken fetches a form token the way the contact form does
+func (env *testEnv) formToken(t *testing.T) string {
+	t.Helper()
+	w := env.do(http.MethodGet, "/api/contact/token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("token: got %d: %s", w.Code, w.Body)
+	}
+	var resp struct{ Token string }
+	testutil.Decode(t, w, &resp)
+	return resp.Token
+}
+
+func TestCreateContact(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/api/contact", ContactRequest{
+		Name:      "Ann",
+		Email:     "ann@example.com",
+		Message:   "Hello, I have a question about your work",
+		FormToken: env.formToken(t),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+
+	w = env.do(http.MethodGet, "/api/admin/contact", nil)
+	var contacts []Contact
+	testutil.Decode(t, w, &contacts)
+	if len(contacts) != 1 || contacts[0].Name != "Ann" || contacts[0].Status != StatusNew {
+		t.Fatalf("contacts = %+v, want one new message from Ann", contacts)
+	}
+
+	entries, err := env.outbox.List(context.Background(), outbox.StatusPending, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Recipients != "to@example.com" {
+		t.Errorf("outbox = %+v, want the notification to to@example.com", entries)
+	}
+}
+
+func TestCreateContactRejectsInvalidInput(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/api/contact", map[string]string{"name": "Ann", "email": "not-an-email", "message": "Hi"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got %d, want 422: %s", w.Code, w.Body)
+	}
+	if contacts, total, _ := env.store.List(context.Background(), Filter{}); total != 0 {
+		t.Errorf("stored %+v, want nothing", contacts)
+	}
+}
+
+func TestCreateContactFlagsSpam(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Bots get the same answer, the message is kept for review and nobody is mailed
+	w := env.do(http.MethodPost, "/api/contact", ContactRequest{
+		Name:    "Bot",
+		Email:   "bot@example.com",
+		Message: "Cheap links http://a.example http://b.example http://c.example",
+		Website: "http://spam.example",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+
+	contacts, _, err := env.store.List(context.Background(), Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{spam.ReasonHoneypot, spam.ReasonMissingToken, spam.ReasonTooManyLinks}
+	if len(contacts) != 1 || contacts[0].Status != StatusSpam || !slices.Equal(contacts[0].SpamReasons, want) {
+		t.Fatalf("contacts = %+v, want one spam message flagged for %v", contacts, want)
+	}
+	if entries, _ := env.outbox.List(context.Background(), outbox.StatusPending, 10); len(entries) != 0 {
+		t.Errorf("outbox = %+v, want nothing for spam", entries)
+	}
+}
+
+func TestFormTokenFromAnotherIP(t *testing.T) {
+	env := newTestEnv(t)
+	token := env.formToken(t)
+
+	// A token harvested by one client and replayed from another address is flagged
+	w := testutil.Do(env.router, http.MethodPost, "/api/contact",
+		ContactRequest{Name: "Bot", Email: "bot@example.com", Message: "Hello", FormToken: token},
+		"X-Forwarded-For", "203.0.113.9")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+	// The address that loaded the form can still use it
+	if w := env.do(http.MethodPost, "/api/contact", ContactRequest{Name: "Ann", Email: "ann@example.com", Message: "Hello", FormToken: token}); w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+
+	contacts, _, err := env.store.List(context.Background(), Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 2 || !slices.Equal(contacts[0].SpamReasons, []string{spam.ReasonInvalidToken}) || contacts[1].Status != StatusNew {
+		t.Errorf("contacts = %+v, want the replayed token flagged and the original one accepted", contacts)
+	}
+}
+
+func TestCreateContactRateLimit(t *testing.T) {
+	env := newTestEnv(t)
+	req := ContactRequest{Name: "Ann", Email: "ann@example.com", Message: "Hello, I have a question"}
+
+	// The limit counts every stored message, spam included
+	for i := 0; i < 5; i++ {
+		if w := env.do(http.MethodPost, "/api/contact", req); w.Code != http.StatusCreated {
+			t.Fatalf("message %d: got %d, want 201: %s", i+1, w.Code, w.Body)
+		}
+	}
+	w := env.do(http.MethodPost, "/api/contact", req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("message 6: got %d, want 429: %s", w.Code, w.Body)
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 3599 || retry > 3600 {
+		t.Errorf("Retry-After = %q, want about an hour", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestGetContact(t *testing.T) {
+	env := newTestEnv(t)
+	w := env.do(http.MethodPost, "/api/contact", ContactRequest{Name: "Ann", Email: "ann@example.com", Message: "Hello", FormToken: env.formToken(t)})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d: %s", w.Code, w.Body)
+	}
+
+	w = env.do(http.MethodGet, "/api/admin/contact/1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200: %s", w.Code, w.Body)
+	}
+	var got Contact
+	testutil.Decode(t, w, &got)
+	if got.ID != 1 || got.Name != "Ann" || got.Email != "ann@example.com" {
+		t.Errorf("got %+v, want the message from Ann", got)
+	}
+}
+
+func TestMissingContact(t *testing.T) {
+	env := newTestEnv(t)
+	w := env.do(http.MethodPost, "/api/contact", ContactRequest{Name: "Ann", Email: "ann@example.com", Message: "Hello", FormToken: env.formToken(t)})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		method, path string
+		body         any
+		want         int
+	}{
+		{http.MethodGet, "/api/admin/contact/99", nil, http.StatusNotFound},
+		{http.MethodGet, "/api/admin/contact/abc", nil, http.StatusBadRequest},
+		{http.MethodPut, "/api/admin/contact/99/status", StatusRequest{Status: StatusArchived}, http.StatusNotFound},
+		{http.MethodPost, "/api/admin/contact/99/replies", ReplyRequest{Body: "Thanks"}, http.StatusNotFound},
+		{http.MethodDelete, "/api/admin/contact/1/notes/99", nil, http.StatusNotFound},
+		{http.MethodDelete, "/api/admin/contact/99", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := env.do(tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+	}
+
+	if w := env.do(http.MethodDelete, "/api/admin/contact/1", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := env.do(http.MethodGet, "/api/admin/contact/1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: got %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestSetStatusTransitions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	w := env.do(http.MethodPost, "/api/contact", ContactRequest{Name: "Ann", Email: "ann@example.com", Message: "Hello", FormToken: env.formToken(t)})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d: %s", w.Code, w.Body)
+	}
+
+	if w := env.do(http.MethodPut, "/api/admin/contact/1/status", StatusRequest{Status: StatusArchived}); w.Code != http.StatusOK {
+		t.Fatalf("new to archived: got %d, want 200: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPut, "/api/admin/contact/1/status", StatusRequest{Status: StatusNew})
+	if w.Code != http.StatusConflict {
+		t.Errorf("archived to new: got %d, want 409: %s", w.Code, w.Body)
+	}
+	if got, err := env.store.Get(ctx, 1); err != nil || got.Status != StatusArchived {
+		t.Errorf("after the rejected change: status %q, %v; want archived", got.Status, err)
+	}
+
+	// Every pair of statuses: a change is applied exactly when the workflow allows it
+	statuses := []string{StatusNew, StatusRead, StatusReplied, StatusArchived, StatusSpam}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if err := env.store.SetStatus(ctx, 1, from); err != nil {
+				t.Fatal(err)
+			}
+			allowed := from == to || slices.Contains(transitions[from], to)
+			want, status := http.StatusConflict, from
+			if allowed {
+				want, status = http.StatusOK, to
+			}
+
+			if w := env.do(http.MethodPut, "/api/admin/contact/1/status", StatusRequest{Status: to}); w.Code != want {
+				t.Errorf("%s to %s: got %d, want %d: %s", from, to, w.Code, want, w.Body)
+			}
+			if got, err := env.store.Get(ctx, 1); err != nil || got.Status != status {
+				t.Errorf("%s to %s: status %q, %v; want %s", from, to, got.Status, err, status)
+			}
+		}
+	}
+
+	if w := env.do(http.MethodPut, "/api/admin/contact/1/status", StatusRequest{Status: "deleted"}); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown status: got %d, want 422: %s", w.Code, w.Body)
+	}
+}
+
+// failingStore fails Create while fail is set
+type failingStore struct {
+	*MemoryStore
+	fail bool
+}
+
+func (s *failingStore) Create(ctx context.Context, c *Contact, notify ...mail.Message) error {
+	if s.fail {
+		return errors.New("insert failed")
+	}
+	return s.MemoryStore.Create(ctx, c, notify...)
+}
+
+func TestAutoReplyOnlyForSavedContacts(t *testing.T) {
+	ctx := context.Background()
+	queue := outbox.NewMemoryStore()
+	store := &failingStore{MemoryStore: NewMemoryStore(queue), fail: true}
+	checker, err := spam.NewChecker(spam.Config{RateLimit: 10, RateWindow: time.Hour, MaxLinks: 2}, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies := autoreply.NewMemoryStore()
+	settings := autoreply.DefaultSettings
+	settings.Enabled = true
+	if err := replies.SaveSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	mailer := testutil.Mailer(t, outbox.NewQueue(queue))
+	h := NewHandler(store, user.NewMemoryStore(), mailer, autoreply.NewResponder(replies, mailer, time.Hour), checker)
+	r := gin.New()
+	r.POST("/api/contact", h.CreateContact)
+
+	req := ContactRequest{Name: "Ann", Email: "ann@example.com", Message: "Hello, I have a question"}
+	autoReplies := func() int {
+		entries, err := queue.List(ctx, outbox.StatusPending, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, e := range entries {
+			if e.Recipients == "ann@example.com" {
+				n++
+			}
+		}
+		return n
+	}
+
+	if w := testutil.Do(r, http.MethodPost, "/api/contact", req); w.Code != http.StatusInternalServerError {
+		t.Fatalf("failed insert: got %d, want 500: %s", w.Code, w.Body)
+	}
+	if n := autoReplies(); n != 0 {
+		t.Fatalf("%d auto-replies queued for a message that was not saved, want 0", n)
+	}
+
+	// The failed attempt did not use up the address's auto-reply
+	store.fail = false
+	if w := testutil.Do(r, http.MethodPost, "/api/contact", req); w.Code != http.StatusCreated {
+		t.Fatalf("retry: got %d, want 201: %s", w.Code, w.Body)
+	}
+	if n := autoReplies(); n != 1 {
+		t.Errorf("%d auto-replies queued after the retry, want 1", n)
+	}
+
+	// Within the interval the address gets no second one
+	if w := testutil.Do(r, http.MethodPost, "/api/contact", req); w.Code != http.StatusCreated {
+		t.Fatalf("second message: got %d, want 201: %s", w.Code, w.Body)
+	}
+	if n := autoReplies(); n != 1 {
+		t.Errorf("%d auto-replies queued after a second message, want 1", n)
+	}
+}
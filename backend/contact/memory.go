@@ -0,0 +1,297 @@
+package contact
+
+import (
+	"context"
+	"portfolio/listing"
+	"portfolio/mail"
+	"portfolio/outbox"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory, for local development and tests
+type MemoryStore struct {
+	mu       sync.RWMutex
+	contacts []Contact // Kept in insertion order, which is also receive order
+	nextID   int
+	replies  []Reply
+	replyID  int
+	notes    []Note
+	noteID   int
+	outbox   outbox.Store // Where Create and AddReply queue emails
+}
+
+// NewMemoryStore creates an empty in-memory contact store that queues notifications in queue
+func NewMemoryStore(queue outbox.Store) *MemoryStore {
+	return &MemoryStore{nextID: 1, replyID: 1, noteID: 1, outbox: queue}
+}
+
+// compareContacts are the fields a contact list can be sorted by
+var compareContacts = map[string]func(a, b Contact) int{
+	"created_at": func(a, b Contact) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"name":       func(a, b Contact) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"email":      func(a, b Contact) int { return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email)) },
+	"status":     func(a, b Contact) int { return strings.Compare(a.Status, b.Status) },
+}
+
+// List returns the page of contact messages matching filter and the number of matches
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Contact, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	contacts := []Contact{}
+	for _, c := range s.contacts {
+		if matches(c, filter) {
+			contacts = append(contacts, c)
+		}
+	}
+	listing.Sort(contacts, filter.Params, compareContacts, func(c Contact) int { return c.ID })
+	return listing.Window(contacts, filter.Params), len(contacts), nil
+}
+
+// matches reports whether c passes every condition of filter
+func matches(c Contact, filter Filter) bool {
+	search := strings.ToLower(filter.Search)
+	switch {
+	case filter.Status != "" && c.Status != filter.Status:
+		return false
+	case filter.From != nil && c.CreatedAt.Before(*filter.From):
+		return false
+	case filter.To != nil && !c.CreatedAt.Before(*filter.To):
+		return false
+	case filter.AssignedTo != nil && (c.AssignedTo == nil || *c.AssignedTo != *filter.AssignedTo):
+		return false
+	case filter.Unassigned && c.AssignedTo != nil:
+		return false
+	case search != "" && !strings.Contains(strings.ToLower(c.Name+"\n"+c.Email+"\n"+c.Message), search):
+		return false
+	}
+	return true
+}
+
+// Get returns a single contact message by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.contacts[i], nil
+	}
+	return Contact{}, ErrNotFound
+}
+
+// Create inserts a new message, stores the generated ID and receive time in c and queues notify
+func (s *MemoryStore) Create(ctx context.Context, c *Contact, notify ...mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The memory outbox cannot fail, so queueing first keeps the two in step
+	for _, msg := range notify {
+		entry := outbox.NewEntry(msg)
+		if err := s.outbox.Enqueue(ctx, &entry); err != nil {
+			return err
+		}
+	}
+
+	c.ID = s.nextID
+	c.CreatedAt = time.Now().UTC()
+	if c.Status == "" {
+		c.Status = StatusNew
+	}
+	c.AssignedTo = nil
+	s.nextID++
+	s.contacts = append(s.contacts, *c)
+	return nil
+}
+
+// Update saves the editable fields of the message with c.ID
+func (s *MemoryStore) Update(ctx context.Context, c Contact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(c.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	// The receive time and the workflow fields are not changed by an edit
+	c.CreatedAt = s.contacts[i].CreatedAt
+	c.Status = s.contacts[i].Status
+	c.AssignedTo = s.contacts[i].AssignedTo
+	c.IP = s.contacts[i].IP
+	c.SpamReasons = s.contacts[i].SpamReasons
+	s.contacts[i] = c
+	return nil
+}
+
+// Delete removes a contact message by ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.contacts = append(s.contacts[:i], s.contacts[i+1:]...)
+
+	kept := s.replies[:0]
+	for _, r := range s.replies {
+		if r.ContactID != id {
+			kept = append(kept, r)
+		}
+	}
+	s.replies = kept
+
+	keptNotes := s.notes[:0]
+	for _, n := range s.notes {
+		if n.ContactID != id {
+			keptNotes = append(keptNotes, n)
+		}
+	}
+	s.notes = keptNotes
+	return nil
+}
+
+// ListReplies returns the replies to a message, oldest first
+func (s *MemoryStore) ListReplies(ctx context.Context, contactID int) ([]Reply, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	replies := []Reply{}
+	for _, r := range s.replies {
+		if r.ContactID == contactID {
+			replies = append(replies, r)
+		}
+	}
+	return replies, nil
+}
+
+// AddReply inserts a reply and queues its email
+func (s *MemoryStore) AddReply(ctx context.Context, r *Reply, msg mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(r.ContactID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	entry := outbox.NewEntry(msg)
+	if err := s.outbox.Enqueue(ctx, &entry); err != nil {
+		return err
+	}
+
+	r.ID = s.replyID
+	r.CreatedAt = time.Now().UTC()
+	s.replyID++
+	s.replies = append(s.replies, *r)
+	if status := s.contacts[i].Status; status == StatusNew || status == StatusRead {
+		s.contacts[i].Status = StatusReplied
+	}
+	return nil
+}
+
+// SetStatus changes the workflow status of a message
+func (s *MemoryStore) SetStatus(ctx context.Context, id int, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.contacts[i].Status = status
+	return nil
+}
+
+// Assign sets or clears the user handling a message
+func (s *MemoryStore) Assign(ctx context.Context, id int, userID *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.contacts[i].AssignedTo = userID
+	return nil
+}
+
+// CountByStatus returns the number of messages in each status that has any
+func (s *MemoryStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, c := range s.contacts {
+		counts[c.Status]++
+	}
+	return counts, nil
+}
+
+// ListNotes returns the internal notes on a message, oldest first
+func (s *MemoryStore) ListNotes(ctx context.Context, contactID int) ([]Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := []Note{}
+	for _, n := range s.notes {
+		if n.ContactID == contactID {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+// AddNote inserts an internal note
+func (s *MemoryStore) AddNote(ctx context.Context, n *Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index(n.ContactID) < 0 {
+		return ErrNotFound
+	}
+	n.ID = s.noteID
+	n.CreatedAt = time.Now().UTC()
+	s.noteID++
+	s.notes = append(s.notes, *n)
+	return nil
+}
+
+// DeleteNote removes a note if it belongs to the given message
+func (s *MemoryStore) DeleteNote(ctx context.Context, contactID, noteID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, n := range s.notes {
+		if n.ID == noteID && n.ContactID == contactID {
+			s.notes = append(s.notes[:i], s.notes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// index returns the slice position of the message with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, c := range s.contacts {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// RecentSubmissions returns the receive times of the latest messages sent from ip, newest first
+func (s *MemoryStore) RecentSubmissions(ctx context.Context, ip string, limit int) ([]time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var times []time.Time
+	for i := len(s.contacts) - 1; i >= 0 && len(times) < limit; i-- {
+		if s.contacts[i].IP == ip {
+			times = append(times, s.contacts[i].CreatedAt)
+		}
+	}
+	return times, nil
+}
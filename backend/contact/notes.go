@@ -0,0 +1,79 @@
+package contact
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/middleware"
+	"portfolio/problem"
+	"portfolio/validation"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Note is an internal comment on a contact message; it is never sent to the visitor
+type Note struct {
+	ID         int       `json:"id"`
+	ContactID  int       `json:"contact_id"`
+	AuthorID   *int      `json:"author_id,omitempty"` // Nil once the author's account is deleted
+	AuthorName string    `json:"author_name"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NoteRequest is the body of POST /api/admin/contact/:id/notes
+type NoteRequest struct {
+	Body string `json:"body" binding:"notblank,max=5000"`
+}
+
+// CreateNote adds an internal note to a message
+func (h *Handler) CreateNote(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	var req NoteRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	authorID, authorName, _ := middleware.GetCurrentUser(c)
+	note := Note{ContactID: contact.ID, AuthorID: &authorID, AuthorName: authorName, Body: req.Body}
+	if err := h.store.AddNote(c.Request.Context(), &note); err != nil {
+		fmt.Println("Note insert error:", err)
+		problem.Respond(c, problem.Internal("Note could not be added"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// DeleteNote removes an internal note from a message
+func (h *Handler) DeleteNote(c *gin.Context) {
+	contactID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+	noteID, err := strconv.Atoi(c.Param("noteId"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid note ID"))
+		return
+	}
+
+	err = h.store.DeleteNote(c.Request.Context(), contactID, noteID)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Note not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Note delete error:", err)
+		problem.Respond(c, problem.Internal("Delete operation failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Note ID %d deleted successfully", noteID)})
+}
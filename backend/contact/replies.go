@@ -0,0 +1,164 @@
+package contact
+
+import (
+	"fmt"
+	"net/http"
+	netmail "net/mail"
+	"portfolio/mail"
+	"portfolio/middleware"
+	"portfolio/problem"
+	"portfolio/validation"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultReplySubject starts a thread when the admin does not choose a subject
+const defaultReplySubject = "Re: Your message on the portfolio"
+
+// Reply is an email sent to the visitor in answer to a contact message
+type Reply struct {
+	ID         int       `json:"id"`
+	ContactID  int       `json:"contact_id"`
+	AuthorID   *int      `json:"author_id,omitempty"` // Nil once the author's account is deleted
+	AuthorName string    `json:"author_name"`
+	To         string    `json:"to"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body"`
+	MessageID  string    `json:"message_id"`
+	InReplyTo  string    `json:"in_reply_to,omitempty"` // Message-ID of the previous reply in the thread
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Thread is a contact message together with every reply sent to it and its internal notes, oldest first
+type Thread struct {
+	Contact
+	Replies []Reply `json:"replies"`
+	Notes   []Note  `json:"notes"`
+}
+
+// ReplyRequest is the body of POST /api/admin/contact/:id/replies
+type ReplyRequest struct {
+	Subject string `json:"subject" binding:"max=200"` // Optional; follow-ups reuse the subject of the thread
+	Body    string `json:"body" binding:"notblank,max=20000"`
+}
+
+// GetContact returns a single contact message with its reply thread and notes.
+// Opening a new message marks it read.
+func (h *Handler) GetContact(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if contact.Status == StatusNew {
+		if err := h.store.SetStatus(ctx, contact.ID, StatusRead); err != nil {
+			fmt.Println("Status update error:", err)
+		} else {
+			contact.Status = StatusRead
+		}
+	}
+
+	replies, err := h.store.ListReplies(ctx, contact.ID)
+	if err != nil {
+		fmt.Println("Reply list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+	notes, err := h.store.ListNotes(ctx, contact.ID)
+	if err != nil {
+		fmt.Println("Note list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, Thread{Contact: contact, Replies: replies, Notes: notes})
+}
+
+// CreateReply emails an answer to the visitor and stores it in the thread of the contact message.
+// Each reply carries In-Reply-To and References for the earlier replies, so mail clients show
+// the conversation as one thread; answers from the visitor go to TO_EMAIL.
+func (h *Handler) CreateReply(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	var req ReplyRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	to, err := netmail.ParseAddress(contact.Email)
+	if err != nil {
+		problem.Respond(c, problem.Unprocessable("The contact has no valid email address"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	replies, err := h.store.ListReplies(ctx, contact.ID)
+	if err != nil {
+		fmt.Println("Reply list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	authorID, authorName, _ := middleware.GetCurrentUser(c)
+	reply := Reply{
+		ContactID:  contact.ID,
+		AuthorID:   &authorID,
+		AuthorName: authorName,
+		To:         to.Address,
+		Subject:    strings.TrimSpace(req.Subject),
+		Body:       req.Body,
+		MessageID:  mail.NewMessageID(h.mailer.FromEmail()),
+	}
+
+	headers := map[string]string{"Message-ID": reply.MessageID}
+	if len(replies) > 0 {
+		references := make([]string, 0, len(replies))
+		for _, r := range replies {
+			references = append(references, r.MessageID)
+		}
+		reply.InReplyTo = replies[len(replies)-1].MessageID
+		headers["In-Reply-To"] = reply.InReplyTo
+		headers["References"] = strings.Join(references, " ")
+		if reply.Subject == "" {
+			reply.Subject = replySubject(replies[0].Subject)
+		}
+	} else if reply.Subject == "" {
+		reply.Subject = defaultReplySubject
+	}
+
+	msg, err := h.mailer.ContactReplyMail(reply.To, mail.ContactReplyMailData{
+		Subject:    reply.Subject,
+		Body:       reply.Body,
+		Name:       contact.Name,
+		Message:    contact.Message,
+		ReceivedAt: contact.CreatedAt.Format("Mon, 2 Jan 2006 15:04 MST"),
+	}, headers)
+	if err != nil {
+		fmt.Println("Reply template error:", err)
+		problem.Respond(c, problem.Internal("Reply could not be sent"))
+		return
+	}
+	reply.Subject = msg.Subject
+
+	if err := h.store.AddReply(ctx, &reply, msg); err != nil {
+		fmt.Println("Reply insert error:", err)
+		problem.Respond(c, problem.Internal("Reply could not be sent"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// replySubject prefixes a subject with "Re: " unless it already has it
+func replySubject(subject string) string {
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
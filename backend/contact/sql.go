@@ -0,0 +1,324 @@
+package contact
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"portfolio/db"
+	"portfolio/mail"
+	"portfolio/outbox"
+	"strings"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a contact store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+const contactColumns = "id, COALESCE(name, ''), email, COALESCE(phone, ''), COALESCE(message, ''), created_at, status, assigned_to, COALESCE(ip, ''), COALESCE(spam_reasons, '')"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanContact reads one contact row selected with contactColumns
+func scanContact(row rowScanner) (Contact, error) {
+	var cct Contact
+	var assignedTo sql.NullInt64
+	var spamReasons string
+	err := row.Scan(&cct.ID, &cct.Name, &cct.Email, &cct.Phone, &cct.Message, &cct.CreatedAt, &cct.Status, &assignedTo,
+		&cct.IP, &spamReasons)
+	if assignedTo.Valid {
+		id := int(assignedTo.Int64)
+		cct.AssignedTo = &id
+	}
+	if spamReasons != "" {
+		cct.SpamReasons = strings.Split(spamReasons, ",")
+	}
+	return cct, err
+}
+
+// sortColumns are the fields a contact list can be sorted by
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"name":       "LOWER(name)",
+	"email":      "LOWER(email)",
+	"status":     "status",
+}
+
+// List returns the page of contact messages matching filter and the number of matches
+func (s *SQLStore) List(ctx context.Context, filter Filter) ([]Contact, int, error) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Status != "" {
+		add("status=$%d", filter.Status)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	if filter.AssignedTo != nil {
+		add("assigned_to=$%d", *filter.AssignedTo)
+	}
+	if filter.Unassigned {
+		conditions = append(conditions, "assigned_to IS NULL")
+	}
+	if filter.Search != "" {
+		add(`(LOWER(COALESCE(name, '')) LIKE $%[1]d ESCAPE '\' OR LOWER(email) LIKE $%[1]d ESCAPE '\'
+			OR LOWER(COALESCE(message, '')) LIKE $%[1]d ESCAPE '\')`, db.ContainsPattern(strings.ToLower(filter.Search)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	var total int
+	if err := s.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM contact"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.conn.QueryContext(ctx, "SELECT "+contactColumns+" FROM contact"+where+filter.Clause(sortColumns), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	contacts := []Contact{}
+	for rows.Next() {
+		cct, err := scanContact(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		contacts = append(contacts, cct)
+	}
+	return contacts, total, rows.Err()
+}
+
+// Get returns a single contact message by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (Contact, error) {
+	cct, err := scanContact(s.conn.QueryRowContext(ctx, "SELECT "+contactColumns+" FROM contact WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Contact{}, ErrNotFound
+	}
+	return cct, err
+}
+
+// Create inserts a new message and queues notify in one transaction, storing the generated ID
+// and receive time in c
+func (s *SQLStore) Create(ctx context.Context, c *Contact, notify ...mail.Message) error {
+	// The timestamp is set here rather than by a column default so both dialects store the same format
+	c.CreatedAt = time.Now().UTC()
+	if c.Status == "" {
+		c.Status = StatusNew
+	}
+	c.AssignedTo = nil
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO contact (name, email, phone, message, created_at, status, ip, spam_reasons)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		c.Name, c.Email, c.Phone, c.Message, c.CreatedAt, c.Status, nullString(c.IP),
+		nullString(strings.Join(c.SpamReasons, ","))).Scan(&c.ID)
+	if err != nil {
+		return err
+	}
+	for _, msg := range notify {
+		entry := outbox.NewEntry(msg)
+		if err := outbox.EnqueueTx(ctx, tx, &entry); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Update saves the editable fields of the message with c.ID
+func (s *SQLStore) Update(ctx context.Context, c Contact) error {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE contact SET name=$1, email=$2, phone=$3, message=$4 WHERE id=$5",
+		c.Name, c.Email, c.Phone, c.Message, c.ID)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Delete removes a contact message by ID
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM contact WHERE id=$1", id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+const replyColumns = "id, contact_id, author_id, author_name, to_email, subject, body, message_id, COALESCE(in_reply_to, ''), created_at"
+
+// ListReplies returns the replies to a message, oldest first
+func (s *SQLStore) ListReplies(ctx context.Context, contactID int) ([]Reply, error) {
+	rows, err := s.conn.QueryContext(ctx,
+		"SELECT "+replyColumns+" FROM contact_replies WHERE contact_id=$1 ORDER BY id", contactID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replies := []Reply{}
+	for rows.Next() {
+		var r Reply
+		var authorID sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.ContactID, &authorID, &r.AuthorName, &r.To, &r.Subject, &r.Body,
+			&r.MessageID, &r.InReplyTo, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		if authorID.Valid {
+			id := int(authorID.Int64)
+			r.AuthorID = &id
+		}
+		replies = append(replies, r)
+	}
+	return replies, rows.Err()
+}
+
+// AddReply inserts a reply and queues its email in one transaction
+func (s *SQLStore) AddReply(ctx context.Context, r *Reply, msg mail.Message) error {
+	r.CreatedAt = time.Now().UTC()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO contact_replies
+		(contact_id, author_id, author_name, to_email, subject, body, message_id, in_reply_to, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		r.ContactID, r.AuthorID, r.AuthorName, r.To, r.Subject, r.Body, r.MessageID, nullString(r.InReplyTo), r.CreatedAt).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE contact SET status=$1 WHERE id=$2 AND status IN ($3, $4)",
+		StatusReplied, r.ContactID, StatusNew, StatusRead); err != nil {
+		return err
+	}
+
+	entry := outbox.NewEntry(msg)
+	if err := outbox.EnqueueTx(ctx, tx, &entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetStatus changes the workflow status of a message
+func (s *SQLStore) SetStatus(ctx context.Context, id int, status string) error {
+	return s.updateContact(ctx, "UPDATE contact SET status=$1 WHERE id=$2", status, id)
+}
+
+// Assign sets or clears the user handling a message
+func (s *SQLStore) Assign(ctx context.Context, id int, userID *int) error {
+	return s.updateContact(ctx, "UPDATE contact SET assigned_to=$1 WHERE id=$2", userID, id)
+}
+
+// updateContact runs an update of one message, returning ErrNotFound when it matched none
+func (s *SQLStore) updateContact(ctx context.Context, query string, args ...any) error {
+	result, err := s.conn.ExecContext(ctx, query, args...)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// CountByStatus returns the number of messages in each status that has any
+func (s *SQLStore) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT status, COUNT(*) FROM contact GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// ListNotes returns the internal notes on a message, oldest first
+func (s *SQLStore) ListNotes(ctx context.Context, contactID int) ([]Note, error) {
+	rows, err := s.conn.QueryContext(ctx,
+		"SELECT id, contact_id, author_id, author_name, body, created_at FROM contact_notes WHERE contact_id=$1 ORDER BY id",
+		contactID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []Note{}
+	for rows.Next() {
+		var n Note
+		var authorID sql.NullInt64
+		if err := rows.Scan(&n.ID, &n.ContactID, &authorID, &n.AuthorName, &n.Body, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		if authorID.Valid {
+			id := int(authorID.Int64)
+			n.AuthorID = &id
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// AddNote inserts an internal note
+func (s *SQLStore) AddNote(ctx context.Context, n *Note) error {
+	n.CreatedAt = time.Now().UTC()
+	return s.conn.QueryRowContext(ctx,
+		"INSERT INTO contact_notes (contact_id, author_id, author_name, body, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		n.ContactID, n.AuthorID, n.AuthorName, n.Body, n.CreatedAt).Scan(&n.ID)
+}
+
+// DeleteNote removes a note if it belongs to the given message
+func (s *SQLStore) DeleteNote(ctx context.Context, contactID, noteID int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM contact_notes WHERE id=$1 AND contact_id=$2", noteID, contactID)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// RecentSubmissions returns the receive times of the latest messages sent from ip, newest first
+func (s *SQLStore) RecentSubmissions(ctx context.Context, ip string, limit int) ([]time.Time, error) {
+	rows, err := s.conn.QueryContext(ctx,
+		"SELECT created_at FROM contact WHERE ip=$1 ORDER BY created_at DESC LIMIT $2", ip, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
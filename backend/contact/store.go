@@ -0,0 +1,53 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"portfolio/listing"
+	"portfolio/mail"
+	"time"
+)
+
+// ErrNotFound is returned when no contact message or note matches the given ID
+var ErrNotFound = errors.New("contact not found")
+
+// Filter selects contact messages in List; zero fields match everything
+type Filter struct {
+	Status     string     // Only messages with this status
+	From       *time.Time // Received at or after From
+	To         *time.Time // Received before To
+	AssignedTo *int       // Only messages assigned to this user
+	Unassigned bool       // Only messages assigned to nobody
+	Search     string     // Text in the name, email or message, ignoring case
+
+	listing.Params // Page and order; the zero value returns every match by ID
+}
+
+// Store is the persistence interface the contact handlers depend on
+type Store interface {
+	List(ctx context.Context, filter Filter) ([]Contact, int, error) // The page of matching messages and the number of matches
+	Get(ctx context.Context, id int) (Contact, error)                // Single message by ID
+	// Create inserts c and sets its ID and CreatedAt; an empty status becomes StatusNew. The notify
+	// emails are queued in the mail outbox atomically with the message, so a saved enquiry always
+	// gets its notification.
+	Create(ctx context.Context, c *Contact, notify ...mail.Message) error
+	Update(ctx context.Context, c Contact) error // Updates the message with c.ID, or ErrNotFound
+	Delete(ctx context.Context, id int) error    // Removes the message and its replies by ID, or ErrNotFound
+
+	ListReplies(ctx context.Context, contactID int) ([]Reply, error) // Replies to a message, oldest first
+	// AddReply inserts r, setting its ID and CreatedAt, queues msg in the same transaction
+	// and moves a new or read message to the replied status; archived and spam messages keep theirs
+	AddReply(ctx context.Context, r *Reply, msg mail.Message) error
+
+	SetStatus(ctx context.Context, id int, status string) error // ErrNotFound for an unknown message
+	Assign(ctx context.Context, id int, userID *int) error      // Nil removes the assignee
+	CountByStatus(ctx context.Context) (map[string]int, error)  // Number of messages in each status
+
+	ListNotes(ctx context.Context, contactID int) ([]Note, error) // Internal notes on a message, oldest first
+	AddNote(ctx context.Context, n *Note) error                   // Inserts n and sets its ID and CreatedAt
+	DeleteNote(ctx context.Context, contactID, noteID int) error  // ErrNotFound unless the note belongs to the message
+
+	// RecentSubmissions returns the receive times of the latest limit messages sent from ip,
+	// newest first, for the rate limit of the contact form
+	RecentSubmissions(ctx context.Context, ip string, limit int) ([]time.Time, error)
+}
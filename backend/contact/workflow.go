@@ -0,0 +1,202 @@
+package contact
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/listing"
+	"portfolio/middleware"
+	"portfolio/problem"
+	"portfolio/user"
+	"portfolio/validation"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Statuses of a contact message
+const (
+	StatusNew      = "new"      // Not opened yet; counted as unread
+	StatusRead     = "read"     // Opened in the admin panel
+	StatusReplied  = "replied"  // Answered with a reply from the admin API
+	StatusArchived = "archived" // Handled, hidden from the inbox
+	StatusSpam     = "spam"
+)
+
+// transitions lists the statuses each status may be changed to by hand. Opening a new
+// message marks it read and sending a reply marks a new or read message replied automatically.
+var transitions = map[string][]string{
+	StatusNew:      {StatusRead, StatusReplied, StatusArchived, StatusSpam},
+	StatusRead:     {StatusNew, StatusReplied, StatusArchived, StatusSpam},
+	StatusReplied:  {StatusArchived, StatusSpam},
+	StatusArchived: {StatusRead},
+	StatusSpam:     {StatusRead},
+}
+
+// validStatus reports whether s is a known status
+func validStatus(s string) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// StatusRequest is the body of PUT /api/admin/contact/:id/status
+type StatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=new read replied archived spam"`
+}
+
+// AssignRequest is the body of PUT /api/admin/contact/:id/assignee; a null user_id unassigns
+type AssignRequest struct {
+	UserID *int `json:"user_id"`
+}
+
+// parseFilter reads the list filters: ?status=, ?from= and ?to= (RFC 3339 or YYYY-MM-DD, to is
+// exclusive), ?assigned_to= (a user ID, "me" or "none") and ?q=, and the page and sort
+// (created_at, name, email or status; newest first by default)
+func parseFilter(c *gin.Context) (Filter, error) {
+	var filter Filter
+
+	params, err := listing.Parse(c, "-created_at", "created_at", "name", "email", "status")
+	if err != nil {
+		return filter, err
+	}
+	filter.Params = params
+	filter.Search = strings.TrimSpace(c.Query("q"))
+
+	if status := c.Query("status"); status != "" {
+		if !validStatus(status) {
+			return filter, fmt.Errorf("invalid status %q", status)
+		}
+		filter.Status = status
+	}
+
+	if filter.From, filter.To, err = listing.TimeRange(c); err != nil {
+		return filter, err
+	}
+
+	switch assignee := c.Query("assigned_to"); assignee {
+	case "":
+	case "none":
+		filter.Unassigned = true
+	case "me":
+		userID, _, _ := middleware.GetCurrentUser(c)
+		filter.AssignedTo = &userID
+	default:
+		userID, err := strconv.Atoi(assignee)
+		if err != nil {
+			return filter, fmt.Errorf("invalid assigned_to %q", assignee)
+		}
+		filter.AssignedTo = &userID
+	}
+	return filter, nil
+}
+
+// loadContact loads the message named by the :id parameter, writing the error response
+// and returning false when it cannot
+func (h *Handler) loadContact(c *gin.Context) (Contact, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return Contact{}, false
+	}
+
+	contact, err := h.store.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Contact not found"))
+		return Contact{}, false
+	}
+	if err != nil {
+		fmt.Println("Data fetch error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return Contact{}, false
+	}
+	return contact, true
+}
+
+// SetStatus moves a message to another status of the workflow
+func (h *Handler) SetStatus(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	var req StatusRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	if req.Status != contact.Status && !slices.Contains(transitions[contact.Status], req.Status) {
+		problem.Respond(c, problem.Conflict(fmt.Sprintf("A %s message cannot be marked %s", contact.Status, req.Status)))
+		return
+	}
+
+	if err := h.store.SetStatus(c.Request.Context(), contact.ID, req.Status); err != nil {
+		fmt.Println("Status update error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	contact.Status = req.Status
+	c.JSON(http.StatusOK, contact)
+}
+
+// Assign gives a message to an admin user, or to nobody
+func (h *Handler) Assign(c *gin.Context) {
+	contact, ok := h.loadContact(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	if req.UserID != nil {
+		_, err := h.users.Get(c.Request.Context(), *req.UserID)
+		if errors.Is(err, user.ErrNotFound) {
+			validation.Fail(c, validation.FieldError{Field: "user_id", Reason: "is not an existing user"})
+			return
+		}
+		if err != nil {
+			fmt.Println("User fetch error:", err)
+			problem.Respond(c, problem.Internal("Data could not be retrieved"))
+			return
+		}
+	}
+
+	if err := h.store.Assign(c.Request.Context(), contact.ID, req.UserID); err != nil {
+		fmt.Println("Assign error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	contact.AssignedTo = req.UserID
+	c.JSON(http.StatusOK, contact)
+}
+
+// GetCounts returns the number of messages in each status, with new messages as "unread"
+// and all others as "read", for the dashboard badge
+func (h *Handler) GetCounts(c *gin.Context) {
+	counts, err := h.store.CountByStatus(c.Request.Context())
+	if err != nil {
+		fmt.Println("Count error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	byStatus := map[string]int{}
+	total := 0
+	for status := range transitions {
+		byStatus[status] = counts[status]
+		total += counts[status]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unread":    byStatus[StatusNew],
+		"read":      total - byStatus[StatusNew],
+		"total":     total,
+		"by_status": byStatus,
+	})
+}
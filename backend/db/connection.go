@@ -2,16 +2,62 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log"
 	"os"
+	"strings"
 
-	"github.com/jackc/pgx/v5/pgxpool" // Use connection pool
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver for database/sql
+	"modernc.org/sqlite"               // Embedded SQLite driver, no cgo required
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
-var Pool *pgxpool.Pool // Use pool instead of single connection
+// Dialect identifies the SQL database behind a connection
+type Dialect string
 
-// ConnectDB function establishes the connection with pool
-func ConnectDB() {
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Storage backends that can be selected with DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// DB is a connection pool together with the dialect its queries must use
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Driver returns the storage backend chosen by DB_DRIVER (postgres by default)
+func Driver() string {
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "":
+		return DriverPostgres
+	case DriverPostgres, DriverSQLite, DriverMemory:
+		return driver
+	default:
+		log.Fatalf("Unknown DB_DRIVER %q (expected postgres, sqlite or memory)", driver)
+		return ""
+	}
+}
+
+// ConnectDB function opens the SQL database selected by DB_DRIVER
+func ConnectDB() *DB {
+	if Driver() == DriverSQLite {
+		return connectSQLite()
+	}
+	return connectPostgres()
+}
+
+// connectPostgres connects to the PostgreSQL server in DATABASE_URL
+func connectPostgres() *DB {
 	connStr := os.Getenv("DATABASE_URL")
 	if connStr == "" {
 		log.Fatal("DATABASE_URL environment variable is required")
@@ -19,17 +65,80 @@ func ConnectDB() {
 
 	log.Println("Attempting to connect to database...")
 
-	var err error
-	Pool, err = pgxpool.New(context.Background(), connStr)
+	pool, err := sql.Open("pgx", connStr)
 	if err != nil {
 		log.Fatalf("Error connecting to the database: %v", err)
 	}
 
 	log.Println("Pool created, testing connection...")
 
-	if err := Pool.Ping(context.Background()); err != nil {
+	if err := pool.PingContext(context.Background()); err != nil {
 		log.Fatalf("Error pinging database: %v", err)
 	}
 
 	log.Println("Database connected successfully with connection pool!")
+	return &DB{DB: pool, Dialect: Postgres}
+}
+
+// connectSQLite opens (or creates) the SQLite file in SQLITE_PATH
+func connectSQLite() *DB {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "portfolio.db"
+	}
+
+	log.Printf("Opening SQLite database %s...", path)
+
+	// Foreign keys are off by default in SQLite; the busy timeout avoids "database is locked" errors
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		log.Fatalf("Error opening SQLite database: %v", err)
+	}
+
+	// SQLite allows a single writer, so one connection keeps writes serialized
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.PingContext(context.Background()); err != nil {
+		log.Fatalf("Error opening SQLite database: %v", err)
+	}
+
+	log.Println("SQLite database ready!")
+	return &DB{DB: conn, Dialect: SQLite}
+}
+
+// RequireRows passes through the result of an UPDATE or DELETE by ID, turning a statement
+// that matched no row into notFound
+func RequireRows(result sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// IsUniqueViolation reports whether err was caused by a UNIQUE or PRIMARY KEY constraint,
+// on PostgreSQL (SQLSTATE 23505) or SQLite
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// likeEscaper escapes the LIKE wildcards, for patterns used with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern returns a LIKE pattern matching values that contain s, for use with ESCAPE '\'
+func ContainsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
 }
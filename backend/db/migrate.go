@@ -0,0 +1,298 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration files are embedded into the binary so the schema always ships with the code.
+// Each dialect has its own directory; file names follow the
+// "<version>_<name>.up.sql" / "<version>_<name>.down.sql" format and
+// versions are kept in step between the dialects.
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the advisory lock key that keeps two instances from migrating at once
+const migrationLockID = 7419253001
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState describes a migration and whether it has been applied
+type MigrationState struct {
+	Migration
+	Applied bool
+}
+
+// LoadMigrations reads the embedded migration files of a dialect, sorted by version
+func LoadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := "migrations/" + string(dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		// "0002_project_media_fields.up.sql" -> version 2, name "project_media_fields"
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionStr, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %q", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %v", fileName, err)
+		}
+
+		content, err := migrationFiles.ReadFile(dir + "/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d used by both %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in version order
+func MigrateUp(ctx context.Context, database *DB) error {
+	return withMigrationLock(ctx, database, func(conn *sql.Conn) error {
+		migrations, applied, err := migrationPlan(ctx, database.Dialect, conn)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, m := range migrations {
+			if applied[m.Version] {
+				continue
+			}
+
+			log.Printf("Applying migration %04d_%s...", m.Version, m.Name)
+			err := runInTx(ctx, conn, m.Up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %v", m.Version, m.Name, err)
+			}
+			count++
+		}
+
+		if count == 0 {
+			log.Println("Database schema is up to date")
+		} else {
+			log.Printf("Applied %d migration(s)", count)
+		}
+		return nil
+	})
+}
+
+// MigrateDown rolls back the given number of most recently applied migrations
+func MigrateDown(ctx context.Context, database *DB, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	return withMigrationLock(ctx, database, func(conn *sql.Conn) error {
+		migrations, applied, err := migrationPlan(ctx, database.Dialect, conn)
+		if err != nil {
+			return err
+		}
+
+		// Walk backwards from the newest migration
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if !applied[m.Version] {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %04d_%s has no down file", m.Version, m.Name)
+			}
+
+			log.Printf("Reverting migration %04d_%s...", m.Version, m.Name)
+			err := runInTx(ctx, conn, m.Down,
+				"DELETE FROM schema_migrations WHERE version=$1", m.Version)
+			if err != nil {
+				return fmt.Errorf("revert of %04d_%s failed: %v", m.Version, m.Name, err)
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// MigrationStatus lists every known migration and whether it is applied
+func MigrationStatus(ctx context.Context, database *DB) ([]MigrationState, error) {
+	var states []MigrationState
+	err := withMigrationLock(ctx, database, func(conn *sql.Conn) error {
+		migrations, applied, err := migrationPlan(ctx, database.Dialect, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			states = append(states, MigrationState{Migration: m, Applied: applied[m.Version]})
+		}
+		return nil
+	})
+	return states, err
+}
+
+// RunMigrateCommand handles "migrate up", "migrate down [steps]" and "migrate status"
+func RunMigrateCommand(ctx context.Context, database *DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		return MigrateUp(ctx, database)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		return MigrateDown(ctx, database, steps)
+	case "status":
+		states, err := MigrationStatus(ctx, database)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			status := "pending"
+			if s.Applied {
+				status = "applied"
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, status)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// withMigrationLock runs fn on a single connection holding the migration lock
+func withMigrationLock(ctx context.Context, database *DB, fn func(conn *sql.Conn) error) error {
+	// Advisory locks belong to a session, so lock and unlock on the same connection
+	conn, err := database.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// SQLite is a single local file opened by one process, so only PostgreSQL needs the lock
+	if database.Dialect == Postgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("could not acquire migration lock: %v", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+	}
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema_migrations table: %v", err)
+	}
+
+	return fn(conn)
+}
+
+// migrationPlan returns all embedded migrations and the set of applied versions
+func migrationPlan(ctx context.Context, dialect Dialect, conn *sql.Conn) ([]Migration, map[int]bool, error) {
+	migrations, err := LoadMigrations(dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, nil, err
+		}
+		applied[version] = true
+	}
+
+	return migrations, applied, rows.Err()
+}
+
+// runInTx executes a migration script and its bookkeeping statement in one transaction
+func runInTx(ctx context.Context, conn *sql.Conn, script string, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Scripts may contain several statements; without arguments both drivers run them all
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS contact;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS about;
+DROP TABLE IF EXISTS home;
+DROP TABLE IF EXISTS users;
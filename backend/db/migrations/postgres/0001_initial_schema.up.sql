@@ -0,0 +1,40 @@
+-- USERS table - Admin accounts
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(100) NOT NULL UNIQUE,
+    password VARCHAR(255) NOT NULL,
+    email VARCHAR(255) UNIQUE
+);
+
+-- HOME table - Main page content
+CREATE TABLE IF NOT EXISTS home (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT
+);
+
+-- ABOUT table - About me section
+CREATE TABLE IF NOT EXISTS about (
+    id SERIAL PRIMARY KEY,
+    content TEXT NOT NULL
+);
+
+-- PROJECTS table - Portfolio projects
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    message TEXT
+);
+
+-- CONTACT table - Contact form submissions
+CREATE TABLE IF NOT EXISTS contact (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255),
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(50),
+    message TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_name ON projects(name);
+CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
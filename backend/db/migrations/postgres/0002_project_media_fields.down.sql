@@ -0,0 +1,4 @@
+ALTER TABLE projects DROP COLUMN IF EXISTS demo_url;
+ALTER TABLE projects DROP COLUMN IF EXISTS github_url;
+ALTER TABLE projects DROP COLUMN IF EXISTS technologies;
+ALTER TABLE projects DROP COLUMN IF EXISTS image_url;
@@ -0,0 +1,5 @@
+-- Image, tech stack and link fields shown on the project cards
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS image_url TEXT;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS technologies TEXT;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS github_url TEXT;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS demo_url TEXT;
@@ -0,0 +1,6 @@
+-- Received time and read flag for contact submissions
+ALTER TABLE contact ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE contact ADD COLUMN IF NOT EXISTS is_read BOOLEAN DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_contact_created_at ON contact(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_contact_is_read ON contact(is_read);
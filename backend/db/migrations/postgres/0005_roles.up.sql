@@ -0,0 +1,46 @@
+-- ROLES table - Named sets of permissions
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT
+);
+
+-- ROLE_PERMISSIONS table - Permissions granted by each role, e.g. "projects:write"
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- USER_ROLES table - Roles assigned to each user
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+-- Built-in roles
+INSERT INTO roles (name, description) VALUES ('superadmin', 'Full access including user and role management');
+INSERT INTO roles (name, description) VALUES ('admin', 'Manages all content and contact messages');
+INSERT INTO roles (name, description) VALUES ('editor', 'Edits home, about and project content');
+
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'home:write' FROM roles WHERE name='superadmin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'about:write' FROM roles WHERE name='superadmin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'projects:write' FROM roles WHERE name='superadmin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'contacts:read' FROM roles WHERE name='superadmin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'contacts:write' FROM roles WHERE name='superadmin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'users:manage' FROM roles WHERE name='superadmin';
+
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'home:write' FROM roles WHERE name='admin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'about:write' FROM roles WHERE name='admin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'projects:write' FROM roles WHERE name='admin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'contacts:read' FROM roles WHERE name='admin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'contacts:write' FROM roles WHERE name='admin';
+
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'home:write' FROM roles WHERE name='editor';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'about:write' FROM roles WHERE name='editor';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'projects:write' FROM roles WHERE name='editor';
+
+-- Keep existing access: "admin" was the super admin, every other user could edit all content
+INSERT INTO user_roles (user_id, role_id) SELECT u.id, r.id FROM users u, roles r WHERE u.username='admin' AND r.name='superadmin';
+INSERT INTO user_roles (user_id, role_id) SELECT u.id, r.id FROM users u, roles r WHERE u.username<>'admin' AND r.name='admin';
@@ -0,0 +1,20 @@
+-- MAIL_OUTBOX table - Emails waiting for delivery by the background worker, with their retry state
+CREATE TABLE IF NOT EXISTS mail_outbox (
+    id SERIAL PRIMARY KEY,
+    payload TEXT NOT NULL,
+    recipients TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    provider_id TEXT,
+    created_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_due ON mail_outbox(status, next_attempt_at);
+
+-- Admins and super admins can see and requeue failed emails
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'mail:manage' FROM roles WHERE name='superadmin';
+INSERT INTO role_permissions (role_id, permission) SELECT id, 'mail:manage' FROM roles WHERE name='admin';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS auto_reply_log;
+DROP TABLE IF EXISTS auto_reply_settings;
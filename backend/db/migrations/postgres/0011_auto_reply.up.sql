@@ -0,0 +1,14 @@
+-- AUTO_REPLY_SETTINGS table - Single row with the acknowledgement sent to contact form visitors
+CREATE TABLE IF NOT EXISTS auto_reply_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    enabled BOOLEAN NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- AUTO_REPLY_LOG table - When each address last got an auto-reply, for the per-recipient limit
+CREATE TABLE IF NOT EXISTS auto_reply_log (
+    email VARCHAR(255) PRIMARY KEY,
+    last_sent_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,15 @@
+-- CONTACT_REPLIES table - Emails sent to visitors in answer to their contact messages
+CREATE TABLE IF NOT EXISTS contact_replies (
+    id SERIAL PRIMARY KEY,
+    contact_id INTEGER NOT NULL REFERENCES contact(id) ON DELETE CASCADE,
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    author_name VARCHAR(255) NOT NULL,
+    to_email VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    message_id VARCHAR(255) NOT NULL,
+    in_reply_to VARCHAR(255),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_contact_replies_contact ON contact_replies(contact_id);
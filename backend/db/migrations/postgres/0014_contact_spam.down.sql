@@ -0,0 +1,5 @@
+-- CONTACT table - Remove the spam filter columns
+DROP INDEX IF EXISTS idx_contact_ip_created_at;
+
+ALTER TABLE contact DROP COLUMN spam_reasons;
+ALTER TABLE contact DROP COLUMN ip;
@@ -0,0 +1,7 @@
+-- PROJECTS table - Remove the timestamps and the full-text index
+DROP INDEX IF EXISTS idx_projects_search;
+DROP INDEX IF EXISTS idx_projects_created_at;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE projects DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE projects DROP COLUMN IF EXISTS created_at;
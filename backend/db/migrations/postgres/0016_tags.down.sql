@@ -0,0 +1,5 @@
+-- TAGS tables - Remove the tag taxonomy; projects keep their technologies text
+DROP TABLE IF EXISTS project_tag_imports;
+DROP TABLE IF EXISTS project_tags;
+DROP TABLE IF EXISTS tag_aliases;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,8 @@
+-- PROJECTS table - Publishing status and schedule. Existing projects were public, so they start
+-- published. A draft with publish_at goes live at that time, and a published project with
+-- unpublish_at is archived at that time.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published';
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITHOUT TIME ZONE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMP WITHOUT TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_projects_status ON projects(status);
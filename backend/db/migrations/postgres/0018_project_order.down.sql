@@ -0,0 +1,5 @@
+-- PROJECTS table - Remove the manual order and the featured flag
+DROP INDEX IF EXISTS idx_projects_position;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS featured;
+ALTER TABLE projects DROP COLUMN IF EXISTS position;
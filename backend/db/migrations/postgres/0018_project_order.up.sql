@@ -0,0 +1,10 @@
+-- PROJECTS table - Manual order and the featured flag. Lower positions come first; existing
+-- projects keep the newest-first order they were shown in.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS featured BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE projects SET position = ranked.n
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY id DESC) AS n FROM projects) ranked
+WHERE projects.id = ranked.id;
+
+CREATE INDEX IF NOT EXISTS idx_projects_position ON projects(position);
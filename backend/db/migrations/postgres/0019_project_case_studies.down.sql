@@ -0,0 +1,11 @@
+-- PROJECTS table - Remove the slugs, their redirects and the case-study content
+DROP TABLE IF EXISTS project_slug_redirects;
+DROP INDEX IF EXISTS idx_projects_slug;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS metrics;
+ALTER TABLE projects DROP COLUMN IF EXISTS gallery;
+ALTER TABLE projects DROP COLUMN IF EXISTS client;
+ALTER TABLE projects DROP COLUMN IF EXISTS duration;
+ALTER TABLE projects DROP COLUMN IF EXISTS role;
+ALTER TABLE projects DROP COLUMN IF EXISTS body;
+ALTER TABLE projects DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,4 @@
+ALTER TABLE projects DROP COLUMN demo_url;
+ALTER TABLE projects DROP COLUMN github_url;
+ALTER TABLE projects DROP COLUMN technologies;
+ALTER TABLE projects DROP COLUMN image_url;
@@ -0,0 +1,5 @@
+-- Image, tech stack and link fields shown on the project cards
+ALTER TABLE projects ADD COLUMN image_url TEXT;
+ALTER TABLE projects ADD COLUMN technologies TEXT;
+ALTER TABLE projects ADD COLUMN github_url TEXT;
+ALTER TABLE projects ADD COLUMN demo_url TEXT;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_contact_is_read;
+DROP INDEX IF EXISTS idx_contact_created_at;
+
+ALTER TABLE contact DROP COLUMN is_read;
+ALTER TABLE contact DROP COLUMN created_at;
@@ -0,0 +1,7 @@
+-- Received time and read flag for contact submissions.
+-- SQLite cannot add a column with a CURRENT_TIMESTAMP default, so created_at is always set on insert.
+ALTER TABLE contact ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE contact ADD COLUMN is_read BOOLEAN DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_contact_created_at ON contact(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_contact_is_read ON contact(is_read);
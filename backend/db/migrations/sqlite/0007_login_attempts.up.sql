@@ -0,0 +1,20 @@
+-- LOGIN_FAILURES table - Recent failed logins per account ("user:<name>") and per IP ("ip:<addr>")
+CREATE TABLE IF NOT EXISTS login_failures (
+    subject VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL
+);
+
+-- LOGIN_ATTEMPTS table - Log of every login attempt for review by the super admin
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    user_agent TEXT,
+    success BOOLEAN NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON login_attempts(username);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip);
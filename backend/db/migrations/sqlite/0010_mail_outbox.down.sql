@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission='mail:manage';
+DROP TABLE IF EXISTS mail_outbox;
@@ -0,0 +1,11 @@
+-- CONTACT table - Restore the is_read flag from the workflow status
+DROP TABLE IF EXISTS contact_notes;
+
+ALTER TABLE contact ADD COLUMN is_read BOOLEAN DEFAULT false;
+UPDATE contact SET is_read = (status <> 'new');
+CREATE INDEX IF NOT EXISTS idx_contact_is_read ON contact(is_read);
+
+DROP INDEX IF EXISTS idx_contact_assigned_to;
+DROP INDEX IF EXISTS idx_contact_status;
+ALTER TABLE contact DROP COLUMN assigned_to;
+ALTER TABLE contact DROP COLUMN status;
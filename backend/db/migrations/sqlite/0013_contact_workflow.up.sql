@@ -0,0 +1,23 @@
+-- CONTACT table - Workflow status and assignee; the status replaces the is_read flag
+ALTER TABLE contact ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'new';
+ALTER TABLE contact ADD COLUMN assigned_to INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE contact SET status = CASE WHEN is_read THEN 'read' ELSE 'new' END;
+
+DROP INDEX IF EXISTS idx_contact_is_read;
+ALTER TABLE contact DROP COLUMN is_read;
+
+CREATE INDEX IF NOT EXISTS idx_contact_status ON contact(status);
+CREATE INDEX IF NOT EXISTS idx_contact_assigned_to ON contact(assigned_to);
+
+-- CONTACT_NOTES table - Internal notes on contact messages, never sent to the visitor
+CREATE TABLE IF NOT EXISTS contact_notes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    contact_id INTEGER NOT NULL REFERENCES contact(id) ON DELETE CASCADE,
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    author_name VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_contact_notes_contact ON contact_notes(contact_id);
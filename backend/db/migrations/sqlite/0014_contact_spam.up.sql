@@ -0,0 +1,5 @@
+-- CONTACT table - Sender IP for the per-IP rate limit and the reasons a message was flagged as spam
+ALTER TABLE contact ADD COLUMN ip VARCHAR(64);
+ALTER TABLE contact ADD COLUMN spam_reasons TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_contact_ip_created_at ON contact(ip, created_at DESC);
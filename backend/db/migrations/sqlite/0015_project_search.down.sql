@@ -0,0 +1,5 @@
+-- PROJECTS table - Remove the timestamps
+DROP INDEX IF EXISTS idx_projects_created_at;
+
+ALTER TABLE projects DROP COLUMN updated_at;
+ALTER TABLE projects DROP COLUMN created_at;
@@ -0,0 +1,9 @@
+-- PROJECTS table - Creation and edit times. SQLite searches with LIKE, so there is no full-text index.
+-- SQLite cannot add a column with a CURRENT_TIMESTAMP default, so existing rows are filled in here
+-- and new rows always get both times on insert.
+ALTER TABLE projects ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE projects ADD COLUMN updated_at TIMESTAMP;
+
+UPDATE projects SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_projects_created_at ON projects(created_at DESC);
@@ -0,0 +1,47 @@
+-- TAGS table - Canonical technology tags. match_key is the name folded by the backend
+-- (lower case, letters and digits only), so "Node.js" and "node js" are the same tag.
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    match_key VARCHAR(100) NOT NULL UNIQUE,
+    category VARCHAR(20) NOT NULL DEFAULT 'other',
+    created_at TIMESTAMP NOT NULL
+);
+
+-- TAG_ALIASES table - Other spellings that resolve to a tag, e.g. "golang" for Go
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    match_key VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag_id);
+
+-- PROJECT_TAGS table - Tags of each project, in the order they are listed
+CREATE TABLE IF NOT EXISTS project_tags (
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_tags_tag ON project_tags(tag_id);
+
+-- PROJECT_TAG_IMPORTS table - The comma-separated technologies of existing projects, one row
+-- per name. The backend turns them into tags on startup and deletes the rows.
+CREATE TABLE IF NOT EXISTS project_tag_imports (
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL
+);
+
+-- SQLite has no string_to_array, so the list is split with a recursive query
+INSERT INTO project_tag_imports (project_id, position, name)
+WITH RECURSIVE split(project_id, position, name, rest) AS (
+    SELECT id, 0, '', technologies || ',' FROM projects WHERE TRIM(COALESCE(technologies, '')) <> ''
+    UNION ALL
+    SELECT project_id, position + 1, TRIM(SUBSTR(rest, 1, INSTR(rest, ',') - 1)), SUBSTR(rest, INSTR(rest, ',') + 1)
+    FROM split WHERE rest <> ''
+)
+SELECT project_id, position, name FROM split WHERE name <> '';
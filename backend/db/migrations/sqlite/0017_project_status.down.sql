@@ -0,0 +1,6 @@
+-- PROJECTS table - Remove the publishing status and schedule
+DROP INDEX IF EXISTS idx_projects_status;
+
+ALTER TABLE projects DROP COLUMN unpublish_at;
+ALTER TABLE projects DROP COLUMN publish_at;
+ALTER TABLE projects DROP COLUMN status;
@@ -0,0 +1,8 @@
+-- PROJECTS table - Manual order and the featured flag. Lower positions come first; existing
+-- projects keep the newest-first order they were shown in.
+ALTER TABLE projects ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN featured BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE projects SET position = (SELECT COUNT(*) FROM projects newer WHERE newer.id >= projects.id);
+
+CREATE INDEX IF NOT EXISTS idx_projects_position ON projects(position);
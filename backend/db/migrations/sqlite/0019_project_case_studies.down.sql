@@ -0,0 +1,11 @@
+-- PROJECTS table - Remove the slugs, their redirects and the case-study content
+DROP TABLE IF EXISTS project_slug_redirects;
+DROP INDEX IF EXISTS idx_projects_slug;
+
+ALTER TABLE projects DROP COLUMN metrics;
+ALTER TABLE projects DROP COLUMN gallery;
+ALTER TABLE projects DROP COLUMN client;
+ALTER TABLE projects DROP COLUMN duration;
+ALTER TABLE projects DROP COLUMN role;
+ALTER TABLE projects DROP COLUMN body;
+ALTER TABLE projects DROP COLUMN slug;
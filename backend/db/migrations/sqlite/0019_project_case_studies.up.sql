@@ -0,0 +1,20 @@
+-- PROJECTS table - URL slugs and case-study content. The gallery and outcome metrics are JSON
+-- arrays. Existing projects get slugs generated from their names by the backend on startup.
+ALTER TABLE projects ADD COLUMN slug VARCHAR(100);
+ALTER TABLE projects ADD COLUMN body TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN role VARCHAR(200) NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN duration VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN client VARCHAR(200) NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN gallery TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE projects ADD COLUMN metrics TEXT NOT NULL DEFAULT '[]';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_slug ON projects(slug);
+
+-- PROJECT_SLUG_REDIRECTS table - Earlier slugs of projects, so old links keep working
+CREATE TABLE IF NOT EXISTS project_slug_redirects (
+    slug VARCHAR(100) PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_slug_redirects_project ON project_slug_redirects(project_id);
@@ -1,42 +1,49 @@
 package home
 
 import (
-	"context"  // Context for database operations
+	"errors"   // For matching store errors
 	"fmt"      // For formatted printing
 	"net/http" // For HTTP status codes
-	"strconv"  // For string to int conversion
+	"portfolio/problem"
+	"portfolio/validation" // Request validation
+	"strconv"              // For string to int conversion
 
-	"github.com/gin-gonic/gin"        // Gin framework
-	"github.com/jackc/pgx/v5/pgxpool" // pgxpool library for PostgreSQL connection pool
+	"github.com/gin-gonic/gin" // Gin framework
 )
 
 // Home struct represents the data in the home table
 type Home struct {
-	ID          int    `json:"id"`          // Appears as "id" in JSON output
-	Title       string `json:"title"`       // Title field
-	Description string `json:"description"` // Description field
+	ID          int    `json:"id"`                               // Appears as "id" in JSON output
+	Title       string `json:"title" binding:"notblank,max=200"` // Title field
+	Description string `json:"description" binding:"max=2000"`   // Description field
 }
 
-// Pool is a global variable for the database connection pool
-var Pool *pgxpool.Pool
+// Handler serves the home endpoints using a Store
+type Handler struct {
+	store Store
+}
 
-// SetDB function receives the database pool from main.go
-func SetDB(pool *pgxpool.Pool) {
-	Pool = pool
+// NewHandler creates home handlers backed by the given store
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
 }
 
 // DeleteHome deletes a home record by a specific ID
-func DeleteHome(c *gin.Context) {
+func (h *Handler) DeleteHome(c *gin.Context) {
 	idStr := c.Param("id")         // Get id from URL parameter (/api/home/:id format)
 	id, err := strconv.Atoi(idStr) // Convert string to integer
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"}) // If invalid ID, return 400
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // If invalid ID, return 400
 		return
 	}
 
-	_, err = Pool.Exec(context.Background(), "DELETE FROM home WHERE id=$1", id) // Delete query from database
+	err = h.store.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Home record not found")) // Nothing to delete, return 404
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete operation failed"}) // If delete fails, return 500
+		problem.Respond(c, problem.Internal("Delete operation failed")) // If delete fails, return 500
 		return
 	}
 
@@ -44,57 +51,77 @@ func DeleteHome(c *gin.Context) {
 }
 
 // GetHomes returns all records from the home table when an HTTP GET request is received
-func GetHomes(c *gin.Context) {
-	rows, err := Pool.Query(context.Background(), "SELECT id, title, description FROM home") // Select all home records
+func (h *Handler) GetHomes(c *gin.Context) {
+	homes, err := h.store.List(c.Request.Context()) // Select all home records
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Data could not be retrieved"}) // If data cannot be fetched, return 500
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // If data cannot be fetched, return 500
 		return
 	}
-	defer rows.Close() // Close the connection when done
 
-	var homes []Home  // Create empty slice
-	for rows.Next() { // Loop through rows
-		var h Home
-		if err := rows.Scan(&h.ID, &h.Title, &h.Description); err != nil { // Get data from row
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Row could not be read"}) // If error, return 500
-			return
-		}
-		homes = append(homes, h) // Add to slice
+	c.JSON(http.StatusOK, homes) // Return all records as JSON
+}
+
+// GetHome returns a single home record by ID
+func (h *Handler) GetHome(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id")) // Get id from URL parameter (/api/home/:id format)
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // If invalid ID, return 400
+		return
 	}
 
-	c.JSON(http.StatusOK, homes) // Return all records as JSON
+	rec, err := h.store.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Home record not found")) // If no record, return 404
+		return
+	}
+	if err != nil {
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // If data cannot be fetched, return 500
+		return
+	}
+
+	c.JSON(http.StatusOK, rec) // Return the record as JSON
 }
 
-// UpdateHome updates a home record
-func UpdateHome(c *gin.Context) {
-	var h Home
-	if err := c.ShouldBindJSON(&h); err != nil { // Bind JSON data to struct (decode)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"}) // If JSON invalid, return 400
+// UpdateHome updates a home record, taken from the URL on PUT /home/:id and from the body on PUT /home
+func (h *Handler) UpdateHome(c *gin.Context) {
+	var rec Home
+	if !validation.Bind(c, &rec) { // Bind and validate JSON data (decode)
 		return
 	}
+	if idStr := c.Param("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr) // The URL decides which record is updated
+		if err != nil {
+			problem.Respond(c, problem.BadRequest("Invalid ID")) // If invalid ID, return 400
+			return
+		}
+		rec.ID = id
+	}
 
-	_, err := Pool.Exec(context.Background(), "UPDATE home SET title=$1, description=$2 WHERE id=$3", h.Title, h.Description, h.ID) // Update query
+	err := h.store.Update(c.Request.Context(), rec)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Home record not found")) // If no record, return 404
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"}) // If fails, return 500
+		problem.Respond(c, problem.Internal("Update failed")) // If fails, return 500
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Home ID %d updated successfully", h.ID)}) // Return success message
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Home ID %d updated successfully", rec.ID)}) // Return success message
 }
 
 // CreateHome function adds a new home record
-func CreateHome(c *gin.Context) {
-	var h Home
-	if err := c.ShouldBindJSON(&h); err != nil { // Bind JSON data to struct
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"}) // If invalid data, return 400
+func (h *Handler) CreateHome(c *gin.Context) {
+	var rec Home
+	if !validation.Bind(c, &rec) { // Bind and validate JSON data
 		return
 	}
 
-	_, err := Pool.Exec(context.Background(), "INSERT INTO home (title, description) VALUES ($1, $2)", h.Title, h.Description) // Insert new record
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Record could not be added"}) // If cannot be added, return 500
+	if err := h.store.Create(c.Request.Context(), &rec); err != nil { // Insert new record
+		problem.Respond(c, problem.Internal("Record could not be added")) // If cannot be added, return 500
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Home record added successfully"}) // Return success message (201)
+	c.Header("Location", fmt.Sprintf("/api/home/%d", rec.ID)) // Where the new record can be fetched
+	c.JSON(http.StatusCreated, rec)                           // Return the new record (201)
 }
@@ -0,0 +1,89 @@
+package home
+
+import (
+	"net/http"
+	"portfolio/internal/testutil"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// newTestRouter returns a home handler on a memory store, routed like routes.SetupRoutes
+func newTestRouter() *gin.Engine {
+	h := NewHandler(NewMemoryStore())
+
+	r := gin.New()
+	r.GET("/api/home", h.GetHomes)
+	r.GET("/api/home/:id", h.GetHome)
+	admin := r.Group("/api/admin")
+	admin.POST("/home", h.CreateHome)
+	admin.PUT("/home/:id", h.UpdateHome)
+	admin.PUT("/home", h.UpdateHome)
+	admin.DELETE("/home/:id", h.DeleteHome)
+	return r
+}
+
+func TestCreateHome(t *testing.T) {
+	r := newTestRouter()
+
+	w := testutil.Do(r, http.MethodPost, "/api/admin/home", Home{Title: "Hello", Description: "Welcome"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+	var created Home
+	testutil.Decode(t, w, &created)
+	if created.ID == 0 || created.Title != "Hello" {
+		t.Errorf("created %+v, want the new record with its ID", created)
+	}
+
+	location := w.Header().Get("Location")
+	w = testutil.Do(r, http.MethodGet, location, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get %q: got %d, want 200: %s", location, w.Code, w.Body)
+	}
+	var got Home
+	testutil.Decode(t, w, &got)
+	if got != created {
+		t.Errorf("got %+v, want %+v", got, created)
+	}
+}
+
+func TestUpdateHome(t *testing.T) {
+	r := newTestRouter()
+	testutil.Do(r, http.MethodPost, "/api/admin/home", Home{Title: "Hello"})
+
+	// The URL decides which record is updated, the body ID is ignored
+	if w := testutil.Do(r, http.MethodPut, "/api/admin/home/1", Home{ID: 2, Title: "Hi"}); w.Code != http.StatusOK {
+		t.Fatalf("update: got %d, want 200: %s", w.Code, w.Body)
+	}
+	var got Home
+	testutil.Decode(t, testutil.Do(r, http.MethodGet, "/api/home/1", nil), &got)
+	if got.Title != "Hi" {
+		t.Errorf("title = %q, want Hi", got.Title)
+	}
+}
+
+func TestMissingHome(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		method, path string
+		body         any
+		want         int
+	}{
+		{http.MethodGet, "/api/home/99", nil, http.StatusNotFound},
+		{http.MethodGet, "/api/home/abc", nil, http.StatusBadRequest},
+		{http.MethodPut, "/api/admin/home/99", Home{Title: "Hi"}, http.StatusNotFound},
+		{http.MethodPut, "/api/admin/home", Home{ID: 99, Title: "Hi"}, http.StatusNotFound},
+		{http.MethodDelete, "/api/admin/home/99", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := testutil.Do(r, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package home
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore implements Store in process memory, for local development and tests
+type MemoryStore struct {
+	mu     sync.RWMutex
+	homes  []Home
+	nextID int
+}
+
+// NewMemoryStore creates an empty in-memory home store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// List returns all home records
+func (s *MemoryStore) List(ctx context.Context) ([]Home, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Home(nil), s.homes...), nil
+}
+
+// Get returns a single home record by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Home, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.homes[i], nil
+	}
+	return Home{}, ErrNotFound
+}
+
+// Create inserts a new home record and stores the generated ID in h
+func (s *MemoryStore) Create(ctx context.Context, h *Home) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h.ID = s.nextID
+	s.nextID++
+	s.homes = append(s.homes, *h)
+	return nil
+}
+
+// Update saves the title and description of the record with h.ID
+func (s *MemoryStore) Update(ctx context.Context, h Home) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(h.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.homes[i] = h
+	return nil
+}
+
+// Delete removes a home record by ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.homes = append(s.homes[:i], s.homes[i+1:]...)
+	return nil
+}
+
+// index returns the slice position of the record with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, h := range s.homes {
+		if h.ID == id {
+			return i
+		}
+	}
+	return -1
+}
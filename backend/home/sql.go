@@ -0,0 +1,66 @@
+package home
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a home store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// List returns all home records
+func (s *SQLStore) List(ctx context.Context) ([]Home, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT id, title, COALESCE(description, '') FROM home")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var homes []Home
+	for rows.Next() {
+		var h Home
+		if err := rows.Scan(&h.ID, &h.Title, &h.Description); err != nil {
+			return nil, err
+		}
+		homes = append(homes, h)
+	}
+	return homes, rows.Err()
+}
+
+// Get returns a single home record by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (Home, error) {
+	var h Home
+	err := s.conn.QueryRowContext(ctx, "SELECT id, title, COALESCE(description, '') FROM home WHERE id=$1", id).
+		Scan(&h.ID, &h.Title, &h.Description)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Home{}, ErrNotFound
+	}
+	return h, err
+}
+
+// Create inserts a new home record and stores the generated ID in h
+func (s *SQLStore) Create(ctx context.Context, h *Home) error {
+	return s.conn.QueryRowContext(ctx, "INSERT INTO home (title, description) VALUES ($1, $2) RETURNING id",
+		h.Title, h.Description).Scan(&h.ID)
+}
+
+// Update saves the title and description of the record with h.ID
+func (s *SQLStore) Update(ctx context.Context, h Home) error {
+	result, err := s.conn.ExecContext(ctx, "UPDATE home SET title=$1, description=$2 WHERE id=$3", h.Title, h.Description, h.ID)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Delete removes a home record by ID
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM home WHERE id=$1", id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
@@ -0,0 +1,18 @@
+package home
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when no home record matches the given ID
+var ErrNotFound = errors.New("home record not found")
+
+// Store is the persistence interface the home handlers depend on
+type Store interface {
+	List(ctx context.Context) ([]Home, error)      // All home records
+	Get(ctx context.Context, id int) (Home, error) // Single record by ID
+	Create(ctx context.Context, h *Home) error     // Inserts h and sets its ID
+	Update(ctx context.Context, h Home) error      // Updates the record with h.ID, or ErrNotFound
+	Delete(ctx context.Context, id int) error      // Removes the record by ID, or ErrNotFound
+}
@@ -0,0 +1,57 @@
+// Package testutil holds the fixture shared by the handler tests: Gin in test mode, JSON
+// requests against a router and decoding of JSON responses.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"portfolio/mail"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Main runs the tests of a package with Gin in test mode; call it from TestMain
+func Main(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// Do sends a request with body encoded as JSON to h and returns the response. header holds
+// extra header name/value pairs, such as "Authorization", "Bearer ...".
+func Do(h http.Handler, method, path string, body any, header ...string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// Decode unmarshals the JSON body of w into v, failing the test if it is not valid JSON
+func Decode(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body, err)
+	}
+}
+
+// Mailer returns a mailer with the built-in templates that sends through sender, from
+// from@example.com and with notifications to to@example.com
+func Mailer(t *testing.T, sender mail.Sender) *mail.Mailer {
+	t.Helper()
+	templates, err := mail.LoadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mail.NewMailer(sender, templates, "from@example.com", "to@example.com")
+}
@@ -0,0 +1,305 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"portfolio/auth"
+	"portfolio/mail"
+	"portfolio/middleware"
+	"portfolio/problem"
+	"portfolio/rbac"
+	"portfolio/user"
+	"portfolio/validation"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInviteRequest struct for inviting an email address
+type CreateInviteRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+	Role  string `json:"role" binding:"notblank,max=50"` // Name of the role the new user gets
+}
+
+// AcceptInviteRequest struct for accepting an invite
+type AcceptInviteRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" binding:"notblank,max=50"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// Handler serves the invite endpoints using a Store
+type Handler struct {
+	store  Store
+	users  user.Store
+	roles  rbac.Store
+	mailer *mail.Mailer
+}
+
+// NewHandler creates invite handlers backed by the given stores and mailer
+func NewHandler(store Store, users user.Store, roles rbac.Store, mailer *mail.Mailer) *Handler {
+	return &Handler{store: store, users: users, roles: roles, mailer: mailer}
+}
+
+// inviteTTL is how long an invite link stays valid (INVITE_TTL, default 72h)
+func inviteTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("INVITE_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return 72 * time.Hour
+}
+
+// CreateInvite invites an email address and sends the invite link
+func (h *Handler) CreateInvite(c *gin.Context) {
+	inviterID, inviterName, _ := middleware.GetCurrentUser(c)
+
+	var req CreateInviteRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+
+	ctx := c.Request.Context()
+	if ok, err := h.roleExists(c, req.Role); err != nil {
+		fmt.Println("Invite role lookup error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be created"))
+		return
+	} else if !ok {
+		problem.Respond(c, problem.BadRequest(fmt.Sprintf("Unknown role %q", req.Role)))
+		return
+	}
+
+	if _, err := h.users.GetByEmail(ctx, email); err == nil {
+		problem.Respond(c, problem.Conflict("A user with this email already exists"))
+		return
+	}
+	if _, err := h.store.FindOpenByEmail(ctx, email); err == nil {
+		problem.Respond(c, problem.Conflict("This email already has an open invite, resend it instead"))
+		return
+	}
+
+	token, err := auth.RandomToken(32)
+	if err != nil {
+		fmt.Println("Invite token error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be created"))
+		return
+	}
+
+	now := time.Now().UTC()
+	inv := Invite{
+		Email:     email,
+		Role:      req.Role,
+		TokenHash: auth.HashToken(token),
+		InvitedBy: &inviterID,
+		ExpiresAt: now.Add(inviteTTL()),
+		CreatedAt: now,
+	}
+	if err := h.store.Create(ctx, &inv); err != nil {
+		fmt.Println("Invite create error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be created"))
+		return
+	}
+
+	inv.Status = inv.StatusAt(now)
+	mailSent := h.sendInvite(c, inv, inviterName, token, inviteTTL())
+	c.JSON(http.StatusCreated, gin.H{"invite": inv, "mail_sent": mailSent})
+}
+
+// GetInvites returns the invites that are still open, including expired ones
+func (h *Handler) GetInvites(c *gin.Context) {
+	invites, err := h.store.ListOpen(c.Request.Context())
+	if err != nil {
+		fmt.Println("Invite list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	now := time.Now().UTC()
+	for i := range invites {
+		invites[i].Status = invites[i].StatusAt(now)
+	}
+	c.JSON(http.StatusOK, invites)
+}
+
+// ResendInvite sends a new invite link with a fresh expiry; the previous link stops working
+func (h *Handler) ResendInvite(c *gin.Context) {
+	_, inviterName, _ := middleware.GetCurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	inv, err := h.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Invite not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Invite resend error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be resent"))
+		return
+	}
+
+	token, err := auth.RandomToken(32)
+	if err != nil {
+		fmt.Println("Invite token error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be resent"))
+		return
+	}
+
+	now := time.Now().UTC()
+	inv.TokenHash = auth.HashToken(token)
+	inv.ExpiresAt = now.Add(inviteTTL())
+	if err := h.store.RenewToken(ctx, id, inv.TokenHash, inv.ExpiresAt); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			problem.Respond(c, problem.Conflict("Invite was already accepted or revoked"))
+			return
+		}
+		fmt.Println("Invite resend error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be resent"))
+		return
+	}
+
+	inv.Status = inv.StatusAt(now)
+	mailSent := h.sendInvite(c, inv, inviterName, token, inviteTTL())
+	c.JSON(http.StatusOK, gin.H{"invite": inv, "mail_sent": mailSent})
+}
+
+// RevokeInvite cancels an open invite so its link stops working
+func (h *Handler) RevokeInvite(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	if err := h.store.Revoke(c.Request.Context(), id, time.Now().UTC()); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			problem.Respond(c, problem.NotFound("Open invite not found"))
+			return
+		}
+		fmt.Println("Invite revoke error:", err)
+		problem.Respond(c, problem.Internal("Invite could not be revoked"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Invite ID %d revoked", id)})
+}
+
+// GetInviteByToken shows the email and role of a valid invite link, so the accept
+// page can display them before the invitee picks a username and password
+func (h *Handler) GetInviteByToken(c *gin.Context) {
+	inv, ok := h.validInvite(c, c.Param("token"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"email": inv.Email, "role": inv.Role, "expires_at": inv.ExpiresAt})
+}
+
+// AcceptInvite creates the invitee's account with the invited role and sends the welcome mail
+func (h *Handler) AcceptInvite(c *gin.Context) {
+	var req AcceptInviteRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+	username := strings.TrimSpace(req.Username)
+
+	inv, ok := h.validInvite(c, req.Token)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.users.GetByUsername(ctx, username); err == nil {
+		problem.Respond(c, problem.Conflict("This username is already taken"))
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		problem.Respond(c, problem.Internal("Could not hash password"))
+		return
+	}
+
+	u := user.User{Username: username, Password: hashedPassword, Email: inv.Email}
+	if err := h.users.Create(ctx, &u); err != nil {
+		fmt.Println("Invite accept error:", err)
+		problem.Respond(c, problem.Conflict("User could not be created, the username or email may already be taken"))
+		return
+	}
+
+	// Claim the invite; if another request got there first, undo the new account
+	accepted, err := h.store.MarkAccepted(ctx, inv.ID, time.Now().UTC())
+	if err != nil || !accepted {
+		if err != nil {
+			fmt.Println("Invite accept error:", err)
+		}
+		// Not tied to the request, so the account is still removed if the client has gone
+		if err := h.users.Delete(context.Background(), u.ID); err != nil {
+			fmt.Println("Invite accept rollback error:", err)
+		}
+		problem.Respond(c, problem.BadRequest("Invalid or expired invite"))
+		return
+	}
+
+	if err := h.roles.SetUserRoles(ctx, u.ID, []string{inv.Role}); err != nil {
+		// The role may have been deleted since the invite; the account exists without it
+		fmt.Println("Invite role assignment error:", err)
+	}
+
+	go func() {
+		if err := h.mailer.SendWelcomeMail(context.Background(), u.Email, u.Username); err != nil {
+			fmt.Printf("Welcome mail failed: %v\n", err)
+		}
+	}()
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Account created successfully, you can now log in", "username": u.Username})
+}
+
+// validInvite looks up an open, unexpired invite by its link token; otherwise it answers 400
+func (h *Handler) validInvite(c *gin.Context, token string) (Invite, bool) {
+	inv, err := h.store.GetByTokenHash(c.Request.Context(), auth.HashToken(token))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		fmt.Println("Invite lookup error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return Invite{}, false
+	}
+	if err != nil || inv.StatusAt(time.Now().UTC()) != StatusPending {
+		problem.Respond(c, problem.BadRequest("Invalid or expired invite"))
+		return Invite{}, false
+	}
+	return inv, true
+}
+
+// roleExists reports whether a role with the given name exists
+func (h *Handler) roleExists(c *gin.Context, name string) (bool, error) {
+	roles, err := h.roles.ListRoles(c.Request.Context())
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sendInvite emails the invite link and reports whether it was sent
+func (h *Handler) sendInvite(c *gin.Context, inv Invite, inviterName, token string, validFor time.Duration) bool {
+	link := mail.AppURL("/admin/accept-invite", token)
+	if err := h.mailer.SendInviteMail(c.Request.Context(), inv.Email, inviterName, inv.Role, link, validFor); err != nil {
+		fmt.Printf("Invite mail failed: %v\n", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package invite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"portfolio/auth"
+	"portfolio/internal/testutil"
+	"portfolio/mail"
+	"portfolio/rbac"
+	"portfolio/user"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// testEnv is an invite handler on memory stores, routed like routes.SetupRoutes
+type testEnv struct {
+	router *gin.Engine
+	store  *MemoryStore
+	users  *user.MemoryStore
+	roles  *rbac.MemoryStore
+	mail   *mail.MemorySender
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		router: gin.New(),
+		store:  NewMemoryStore(),
+		users:  user.NewMemoryStore(),
+		roles:  rbac.NewMemoryStore(),
+		mail:   mail.NewMemorySender(),
+	}
+	h := NewHandler(env.store, env.users, env.roles, testutil.Mailer(t, env.mail))
+
+	api := env.router.Group("/api")
+	api.GET("/invites/:token", h.GetInviteByToken)
+	api.POST("/invites/accept", h.AcceptInvite)
+	admin := api.Group("/superadmin", func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Set("username", "admin")
+	})
+	admin.GET("/invites", h.GetInvites)
+	admin.POST("/invites", h.CreateInvite)
+	admin.POST("/invites/:id/resend", h.ResendInvite)
+	admin.DELETE("/invites/:id", h.RevokeInvite)
+	return env
+}
+
+// do sends a JSON request
+func (env *testEnv) do(method, path string, body any) *httptest.ResponseRecorder {
+	return testutil.Do(env.router, method, path, body)
+}
+
+var inviteLinkPattern = regexp.MustCompile(`/admin/accept-invite\?token=([^\s"<&]+)`)
+
+// invite creates an invite and returns it with the token from its mail
+func (env *testEnv) invite(t *testing.T, email, role string) (Invite, string) {
+	t.Helper()
+	env.mail.Reset()
+	w := env.do(http.MethodPost, "/api/superadmin/invites", CreateInviteRequest{Email: email, Role: role})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("invite %s: got %d, want 201: %s", email, w.Code, w.Body)
+	}
+	var resp struct {
+		Invite   Invite `json:"invite"`
+		MailSent bool   `json:"mail_sent"`
+	}
+	testutil.Decode(t, w, &resp)
+	if !resp.MailSent {
+		t.Fatal("invite mail was not sent")
+	}
+	return resp.Invite, env.lastLink(t)
+}
+
+// lastLink returns the token of the invite link in the last mail
+func (env *testEnv) lastLink(t *testing.T) string {
+	t.Helper()
+	messages := env.mail.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no mail was sent")
+	}
+	m := inviteLinkPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	if m == nil {
+		t.Fatalf("no invite link in %q", messages[len(messages)-1].Text)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAcceptInvite(t *testing.T) {
+	env := newTestEnv(t)
+	inv, token := env.invite(t, "ann@example.com", "editor")
+	if inv.Status != StatusPending || inv.InvitedBy == nil || *inv.InvitedBy != 1 {
+		t.Errorf("invite = %+v, want pending and invited by user 1", inv)
+	}
+	if to := env.mail.Messages()[0].To; len(to) != 1 || to[0] != "ann@example.com" {
+		t.Errorf("invite mail sent to %v, want ann@example.com", to)
+	}
+
+	w := env.do(http.MethodGet, "/api/invites/"+url.PathEscape(token), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get by token: got %d, want 200: %s", w.Code, w.Body)
+	}
+
+	w = env.do(http.MethodPost, "/api/invites/accept", AcceptInviteRequest{Token: token, Username: " ann ", Password: "password1"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("accept: got %d, want 201: %s", w.Code, w.Body)
+	}
+
+	ctx := context.Background()
+	u, err := env.users.GetByUsername(ctx, "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Email != "ann@example.com" || auth.CheckPassword(u.Password, "password1") != nil {
+		t.Errorf("user = %+v, want ann@example.com with the chosen password", u)
+	}
+	roles, err := env.roles.UserRoles(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0].Name != "editor" {
+		t.Errorf("roles = %+v, want editor", roles)
+	}
+
+	// The link creates one account only
+	w = env.do(http.MethodPost, "/api/invites/accept", AcceptInviteRequest{Token: token, Username: "ann2", Password: "password1"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("second accept: got %d, want 400: %s", w.Code, w.Body)
+	}
+	var open []Invite
+	testutil.Decode(t, env.do(http.MethodGet, "/api/superadmin/invites", nil), &open)
+	if len(open) != 0 {
+		t.Errorf("open invites = %+v, want none", open)
+	}
+}
+
+func TestCreateInviteRejectsConflicts(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.users.Create(context.Background(), &user.User{Username: "bob", Email: "bob@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	env.invite(t, "ann@example.com", "editor")
+
+	tests := []struct {
+		name string
+		req  CreateInviteRequest
+		want int
+	}{
+		{"unknown role", CreateInviteRequest{Email: "cy@example.com", Role: "owner"}, http.StatusBadRequest},
+		{"existing user", CreateInviteRequest{Email: "bob@example.com", Role: "editor"}, http.StatusConflict},
+		{"open invite", CreateInviteRequest{Email: "ANN@example.com", Role: "admin"}, http.StatusConflict},
+		{"invalid email", CreateInviteRequest{Email: "ann", Role: "editor"}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := env.do(http.MethodPost, "/api/superadmin/invites", tt.req)
+			if w.Code != tt.want {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestAcceptInviteRejectsClosedInvites(t *testing.T) {
+	env := newTestEnv(t)
+
+	revoked, revokedToken := env.invite(t, "revoked@example.com", "editor")
+	if w := env.do(http.MethodDelete, "/api/superadmin/invites/"+strconv.Itoa(revoked.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke: got %d: %s", w.Code, w.Body)
+	}
+
+	// Resending replaces the link
+	resent, oldToken := env.invite(t, "resent@example.com", "editor")
+	if w := env.do(http.MethodPost, "/api/superadmin/invites/"+strconv.Itoa(resent.ID)+"/resend", nil); w.Code != http.StatusOK {
+		t.Fatalf("resend: got %d: %s", w.Code, w.Body)
+	}
+	newToken := env.lastLink(t)
+
+	now := time.Now().UTC()
+	expired := Invite{Email: "expired@example.com", Role: "editor", TokenHash: auth.HashToken("expired"), ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)}
+	if err := env.store.Create(context.Background(), &expired); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"revoked": revokedToken, "replaced": oldToken, "expired": "expired", "unknown": "unknown"} {
+		w := env.do(http.MethodPost, "/api/invites/accept", AcceptInviteRequest{Token: token, Username: name, Password: "password1"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s invite: got %d, want 400: %s", name, w.Code, w.Body)
+		}
+		if _, err := env.users.GetByUsername(context.Background(), name); err == nil {
+			t.Errorf("%s invite created a user", name)
+		}
+	}
+
+	w := env.do(http.MethodPost, "/api/invites/accept", AcceptInviteRequest{Token: newToken, Username: "resent", Password: "password1"})
+	if w.Code != http.StatusCreated {
+		t.Errorf("resent link: got %d, want 201: %s", w.Code, w.Body)
+	}
+}
+
+func TestAcceptInviteRejectsTakenUsername(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.users.Create(context.Background(), &user.User{Username: "bob", Email: "bob@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	_, token := env.invite(t, "ann@example.com", "editor")
+
+	w := env.do(http.MethodPost, "/api/invites/accept", AcceptInviteRequest{Token: token, Username: "bob", Password: "password1"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("got %d, want 409: %s", w.Code, w.Body)
+	}
+
+	// The invite stays open for another try
+	w = env.do(http.MethodPost, "/api/invites/accept", AcceptInviteRequest{Token: token, Username: "ann", Password: "password1"})
+	if w.Code != http.StatusCreated {
+		t.Errorf("retry: got %d, want 201: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,138 @@
+package invite
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	invites []Invite // Kept in insertion order
+	nextID  int
+}
+
+// NewMemoryStore creates an empty in-memory invite store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// Create inserts a new invite and stores the generated ID in i
+func (s *MemoryStore) Create(ctx context.Context, i *Invite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i.ID = s.nextID
+	s.nextID++
+	s.invites = append(s.invites, *i)
+	return nil
+}
+
+// Get returns a single invite by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if idx := s.index(id); idx >= 0 {
+		return s.invites[idx], nil
+	}
+	return Invite{}, ErrNotFound
+}
+
+// GetByTokenHash returns the invite whose link token has the given hash
+func (s *MemoryStore) GetByTokenHash(ctx context.Context, hash string) (Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, i := range s.invites {
+		if i.TokenHash == hash {
+			return i, nil
+		}
+	}
+	return Invite{}, ErrNotFound
+}
+
+// ListOpen returns the invites that were neither accepted nor revoked, newest first
+func (s *MemoryStore) ListOpen(ctx context.Context) ([]Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invites := []Invite{}
+	for idx := len(s.invites) - 1; idx >= 0; idx-- {
+		if open(s.invites[idx]) {
+			invites = append(invites, s.invites[idx])
+		}
+	}
+	return invites, nil
+}
+
+// FindOpenByEmail returns the newest open invite for an email address
+func (s *MemoryStore) FindOpenByEmail(ctx context.Context, email string) (Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx := len(s.invites) - 1; idx >= 0; idx-- {
+		i := s.invites[idx]
+		if open(i) && strings.EqualFold(i.Email, email) {
+			return i, nil
+		}
+	}
+	return Invite{}, ErrNotFound
+}
+
+// RenewToken replaces the token and expiry of an open invite
+func (s *MemoryStore) RenewToken(ctx context.Context, id int, hash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.index(id)
+	if idx < 0 || !open(s.invites[idx]) {
+		return ErrNotFound
+	}
+	s.invites[idx].TokenHash = hash
+	s.invites[idx].ExpiresAt = expiresAt
+	return nil
+}
+
+// Revoke marks an open invite as revoked
+func (s *MemoryStore) Revoke(ctx context.Context, id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.index(id)
+	if idx < 0 || !open(s.invites[idx]) {
+		return ErrNotFound
+	}
+	s.invites[idx].RevokedAt = &at
+	return nil
+}
+
+// MarkAccepted sets AcceptedAt only if the invite is still open
+func (s *MemoryStore) MarkAccepted(ctx context.Context, id int, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.index(id)
+	if idx < 0 || !open(s.invites[idx]) {
+		return false, nil
+	}
+	s.invites[idx].AcceptedAt = &at
+	return true, nil
+}
+
+// index returns the slice position of the invite with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for idx, i := range s.invites {
+		if i.ID == id {
+			return idx
+		}
+	}
+	return -1
+}
+
+// open reports whether an invite was neither accepted nor revoked
+func open(i Invite) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil
+}
@@ -0,0 +1,143 @@
+package invite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates an invite store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// inviteColumns is the column list read by scanInvite
+const inviteColumns = "id, email, role, token_hash, invited_by, expires_at, created_at, accepted_at, revoked_at"
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanInvite reads one row selected with inviteColumns
+func scanInvite(row rowScanner) (Invite, error) {
+	var i Invite
+	var invitedBy sql.NullInt64
+	var acceptedAt, revokedAt sql.NullTime
+	err := row.Scan(&i.ID, &i.Email, &i.Role, &i.TokenHash, &invitedBy, &i.ExpiresAt, &i.CreatedAt, &acceptedAt, &revokedAt)
+	if err != nil {
+		return Invite{}, err
+	}
+	if invitedBy.Valid {
+		id := int(invitedBy.Int64)
+		i.InvitedBy = &id
+	}
+	if acceptedAt.Valid {
+		i.AcceptedAt = &acceptedAt.Time
+	}
+	if revokedAt.Valid {
+		i.RevokedAt = &revokedAt.Time
+	}
+	return i, nil
+}
+
+// Create inserts a new invite and stores the generated ID in i
+func (s *SQLStore) Create(ctx context.Context, i *Invite) error {
+	return s.conn.QueryRowContext(ctx,
+		"INSERT INTO invites (email, role, token_hash, invited_by, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		i.Email, i.Role, i.TokenHash, i.InvitedBy, i.ExpiresAt, i.CreatedAt).Scan(&i.ID)
+}
+
+// Get returns a single invite by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (Invite, error) {
+	i, err := scanInvite(s.conn.QueryRowContext(ctx, "SELECT "+inviteColumns+" FROM invites WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Invite{}, ErrNotFound
+	}
+	return i, err
+}
+
+// GetByTokenHash returns the invite whose link token has the given hash
+func (s *SQLStore) GetByTokenHash(ctx context.Context, hash string) (Invite, error) {
+	i, err := scanInvite(s.conn.QueryRowContext(ctx, "SELECT "+inviteColumns+" FROM invites WHERE token_hash=$1", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Invite{}, ErrNotFound
+	}
+	return i, err
+}
+
+// ListOpen returns the invites that were neither accepted nor revoked, newest first
+func (s *SQLStore) ListOpen(ctx context.Context) ([]Invite, error) {
+	rows, err := s.conn.QueryContext(ctx,
+		"SELECT "+inviteColumns+" FROM invites WHERE accepted_at IS NULL AND revoked_at IS NULL ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []Invite{}
+	for rows.Next() {
+		i, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, i)
+	}
+	return invites, rows.Err()
+}
+
+// FindOpenByEmail returns the newest open invite for an email address
+func (s *SQLStore) FindOpenByEmail(ctx context.Context, email string) (Invite, error) {
+	i, err := scanInvite(s.conn.QueryRowContext(ctx,
+		"SELECT "+inviteColumns+" FROM invites WHERE LOWER(email)=LOWER($1) AND accepted_at IS NULL AND revoked_at IS NULL ORDER BY id DESC LIMIT 1",
+		email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Invite{}, ErrNotFound
+	}
+	return i, err
+}
+
+// RenewToken replaces the token and expiry of an open invite
+func (s *SQLStore) RenewToken(ctx context.Context, id int, hash string, expiresAt time.Time) error {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE invites SET token_hash=$1, expires_at=$2 WHERE id=$3 AND accepted_at IS NULL AND revoked_at IS NULL",
+		hash, expiresAt, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Revoke marks an open invite as revoked
+func (s *SQLStore) Revoke(ctx context.Context, id int, at time.Time) error {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE invites SET revoked_at=$1 WHERE id=$2 AND accepted_at IS NULL AND revoked_at IS NULL", at, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkAccepted sets accepted_at only if the invite is still open
+func (s *SQLStore) MarkAccepted(ctx context.Context, id int, at time.Time) (bool, error) {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE invites SET accepted_at=$1 WHERE id=$2 AND accepted_at IS NULL AND revoked_at IS NULL", at, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
@@ -0,0 +1,61 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when no invite matches the given ID or token
+var ErrNotFound = errors.New("invite not found")
+
+// Invite statuses reported by Status
+const (
+	StatusPending  = "pending"
+	StatusExpired  = "expired"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+)
+
+// Invite is an invitation for an email address to create an account with a role
+type Invite struct {
+	ID         int        `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	TokenHash  string     `json:"-"`                    // SHA-256 of the token in the invite link
+	InvitedBy  *int       `json:"invited_by,omitempty"` // User ID of the inviter, empty once that user is deleted
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Status     string     `json:"status"` // Filled in by the handlers from the fields above
+}
+
+// StatusAt returns the status of the invite at time t
+func (i Invite) StatusAt(t time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return StatusAccepted
+	case i.RevokedAt != nil:
+		return StatusRevoked
+	case !t.Before(i.ExpiresAt):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
+
+// Store persists invites
+type Store interface {
+	Create(ctx context.Context, i *Invite) error                       // Inserts i and sets its ID
+	Get(ctx context.Context, id int) (Invite, error)                   // Single invite by ID
+	GetByTokenHash(ctx context.Context, hash string) (Invite, error)   // Single invite by link token hash
+	ListOpen(ctx context.Context) ([]Invite, error)                    // Invites neither accepted nor revoked, newest first
+	FindOpenByEmail(ctx context.Context, email string) (Invite, error) // Open invite for an email (case-insensitive)
+	// RenewToken replaces the link token and expiry of an open invite, invalidating the old link
+	RenewToken(ctx context.Context, id int, hash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id int, at time.Time) error
+	// MarkAccepted sets AcceptedAt if the invite is still open and reports whether it did,
+	// so one link cannot create two accounts
+	MarkAccepted(ctx context.Context, id int, at time.Time) (bool, error)
+}
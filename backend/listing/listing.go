@@ -0,0 +1,169 @@
+// Package listing implements the conventions shared by the collection endpoints:
+//
+//	GET /api/projects?page=2&per_page=10&sort=-created_at
+//
+// ?page= starts at 1 and ?per_page= is capped at MaxPerPage. ?sort= names one field, reversed
+// by a leading "-". The body stays a JSON array of the page; the number of matches across all
+// pages is sent in X-Total-Count and the first, prev, next and last pages in a Link header.
+package listing
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Params are the page and order of one list request
+type Params struct {
+	Page    int    // Starts at 1
+	PerPage int    // 0 returns every match, for callers inside the backend
+	Sort    string // Field name; empty orders by ID
+	Desc    bool   // Reverse order
+}
+
+// Offset returns the number of matches before the page
+func (p Params) Offset() int {
+	if p.Page < 1 || p.PerPage == 0 {
+		return 0
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+// Parse reads ?page=, ?per_page= and ?sort=. The sort field must be one of sortable;
+// defaultSort, such as "-created_at", applies when none is given.
+func Parse(c *gin.Context, defaultSort string, sortable ...string) (Params, error) {
+	p := Params{Page: 1, PerPage: DefaultPerPage}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return p, fmt.Errorf("invalid page %q", value)
+		}
+		p.Page = page
+	}
+	if value := c.Query("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > MaxPerPage {
+			return p, fmt.Errorf("per_page must be between 1 and %d", MaxPerPage)
+		}
+		p.PerPage = perPage
+	}
+
+	sort := c.DefaultQuery("sort", defaultSort)
+	p.Sort, p.Desc = strings.CutPrefix(sort, "-")
+	if !slices.Contains(sortable, p.Sort) {
+		return p, fmt.Errorf("invalid sort %q (expected one of %s)", sort, strings.Join(sortable, ", "))
+	}
+	return p, nil
+}
+
+// TimeRange reads ?from= and ?to= as RFC 3339 times or YYYY-MM-DD dates; to is exclusive
+func TimeRange(c *gin.Context) (from, to *time.Time, err error) {
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, value); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s date %q", param.name, value)
+			}
+		}
+		t = t.UTC()
+		*param.target = &t
+	}
+	return from, to, nil
+}
+
+// Clause returns the ORDER BY, LIMIT and OFFSET of p for an SQL query. columns maps the sort
+// fields to SQL expressions; rows that tie are ordered by id in the same direction.
+func (p Params) Clause(columns map[string]string) string {
+	column, ok := columns[p.Sort]
+	if !ok {
+		column = "id"
+	}
+	direction := "ASC"
+	if p.Desc {
+		direction = "DESC"
+	}
+
+	clause := fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+	if p.PerPage > 0 {
+		clause += fmt.Sprintf(" LIMIT %d OFFSET %d", p.PerPage, p.Offset())
+	}
+	return clause
+}
+
+// Sort orders items in memory the way Clause orders rows. compare maps the sort fields to
+// comparison functions; id breaks ties.
+func Sort[T any](items []T, p Params, compare map[string]func(a, b T) int, id func(T) int) {
+	byField := compare[p.Sort]
+	slices.SortStableFunc(items, func(a, b T) int {
+		order := 0
+		if byField != nil {
+			order = byField(a, b)
+		}
+		if order == 0 {
+			order = cmp.Compare(id(a), id(b))
+		}
+		if p.Desc {
+			return -order
+		}
+		return order
+	})
+}
+
+// Window returns the page of sorted items described by p
+func Window[T any](items []T, p Params) []T {
+	if p.PerPage == 0 {
+		return items
+	}
+	start := min(p.Offset(), len(items))
+	end := min(start+p.PerPage, len(items))
+	return items[start:end]
+}
+
+// SetHeaders sends the total number of matches in X-Total-Count and links the neighbouring
+// pages in Link
+func SetHeaders(c *gin.Context, p Params, total int) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	if p.PerPage == 0 {
+		return
+	}
+
+	last := max(1, (total+p.PerPage-1)/p.PerPage)
+	var links []string
+	link := func(page int, rel string) {
+		query := c.Request.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(p.PerPage))
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", u.String(), rel))
+	}
+
+	link(1, "first")
+	if p.Page > 1 {
+		link(min(p.Page-1, last), "prev")
+	}
+	if p.Page < last {
+		link(p.Page+1, "next")
+	}
+	link(last, "last")
+	c.Header("Link", strings.Join(links, ", "))
+}
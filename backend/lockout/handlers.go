@@ -0,0 +1,52 @@
+package lockout
+
+import (
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits for the attempt log listing
+const (
+	defaultAttemptLimit = 100
+	maxAttemptLimit     = 1000
+)
+
+// Handler serves the login attempt log using a Store
+type Handler struct {
+	store Store
+}
+
+// NewHandler creates lockout handlers backed by the given store
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// GetAttempts returns the newest login attempts, optionally filtered by ?username= and ?ip=
+func (h *Handler) GetAttempts(c *gin.Context) {
+	filter := AttemptFilter{
+		Username: c.Query("username"),
+		IP:       c.Query("ip"),
+		Limit:    defaultAttemptLimit,
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			problem.Respond(c, problem.BadRequest("Invalid limit"))
+			return
+		}
+		filter.Limit = min(n, maxAttemptLimit)
+	}
+
+	attempts, err := h.store.ListAttempts(c.Request.Context(), filter)
+	if err != nil {
+		fmt.Println("Login attempt list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,185 @@
+package lockout
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBackoff caps the delay between two attempts before the lockout kicks in
+const maxBackoff = time.Minute
+
+// Policy configures when logins are slowed down and locked
+type Policy struct {
+	MaxFailures        int           // Failed logins per account before it is locked
+	IPMaxFailures      int           // Failed logins per IP address before it is locked
+	LockoutDuration    time.Duration // How long a lock lasts; older failures are forgotten
+	MaxResetRequests   int           // Password reset links per email within the lockout duration; 0 for no limit
+	IPMaxResetRequests int           // Password reset links per IP address within the lockout duration; 0 for no limit
+}
+
+// PolicyFromEnv reads LOGIN_MAX_FAILURES (default 5), LOGIN_IP_MAX_FAILURES (default 20),
+// LOGIN_LOCKOUT_DURATION (default 15m), PASSWORD_RESET_MAX_REQUESTS (default 3) and
+// PASSWORD_RESET_IP_MAX_REQUESTS (default 10)
+func PolicyFromEnv() Policy {
+	p := Policy{MaxFailures: 5, IPMaxFailures: 20, LockoutDuration: 15 * time.Minute, MaxResetRequests: 3, IPMaxResetRequests: 10}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILURES")); err == nil && n > 0 {
+		p.MaxFailures = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_IP_MAX_FAILURES")); err == nil && n > 0 {
+		p.IPMaxFailures = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_MAX_REQUESTS")); err == nil && n > 0 {
+		p.MaxResetRequests = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_IP_MAX_REQUESTS")); err == nil && n > 0 {
+		p.IPMaxResetRequests = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION")); err == nil && d > 0 {
+		p.LockoutDuration = d
+	}
+	return p
+}
+
+// Guard applies a Policy to the counters in a Store.
+// Every failure doubles the wait before the next attempt (1s, 2s, 4s, ... up to a minute);
+// once the limit is reached the account or IP is locked for the lockout duration.
+type Guard struct {
+	store  Store
+	policy Policy
+}
+
+// NewGuard creates a guard for the given store and policy
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy}
+}
+
+// accountSubject and ipSubject build the counter keys; usernames are compared case-insensitively
+func accountSubject(username string) string { return "user:" + strings.ToLower(username) }
+func ipSubject(ip string) string            { return "ip:" + ip }
+
+// resetSubject and resetIPSubject count password reset requests apart from failed logins
+func resetSubject(email string) string { return "reset:" + strings.ToLower(email) }
+func resetIPSubject(ip string) string  { return "reset-ip:" + ip }
+
+// Check returns how long the client must wait before it may try to log in, or 0
+func (g *Guard) Check(ctx context.Context, ip, username string) (time.Duration, error) {
+	now := time.Now().UTC()
+
+	account, err := g.store.GetCounter(ctx, accountSubject(username))
+	if err != nil {
+		return 0, err
+	}
+	address, err := g.store.GetCounter(ctx, ipSubject(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	wait := g.wait(account, g.policy.MaxFailures, now)
+	if ipWait := g.wait(address, g.policy.IPMaxFailures, now); ipWait > wait {
+		wait = ipWait
+	}
+	return wait, nil
+}
+
+// Failure counts a failed login against the account and the IP address
+func (g *Guard) Failure(ctx context.Context, ip, username string) error {
+	return g.count(ctx, accountSubject(username), ipSubject(ip))
+}
+
+// CheckReset returns how long a client must wait before it may request another password
+// reset link for email, or 0. Unlike logins there is no backoff: the limits of the policy
+// apply within the lockout duration, whether or not the email belongs to an account.
+func (g *Guard) CheckReset(ctx context.Context, ip, email string) (time.Duration, error) {
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, limit := range []struct {
+		subject string
+		max     int
+	}{{resetSubject(email), g.policy.MaxResetRequests}, {resetIPSubject(ip), g.policy.IPMaxResetRequests}} {
+		c, err := g.store.GetCounter(ctx, limit.subject)
+		if err != nil {
+			return 0, err
+		}
+		if limit.max > 0 && c.Failures >= limit.max {
+			wait = max(wait, c.LastFailureAt.Add(g.policy.LockoutDuration).Sub(now))
+		}
+	}
+	return wait, nil
+}
+
+// ResetRequested counts a password reset request against the email and the IP address
+func (g *Guard) ResetRequested(ctx context.Context, ip, email string) error {
+	return g.count(ctx, resetSubject(email), resetIPSubject(ip))
+}
+
+// count adds one to the counters of subjects, starting over when the last was longer ago
+// than the lockout duration
+func (g *Guard) count(ctx context.Context, subjects ...string) error {
+	now := time.Now().UTC()
+	for _, subject := range subjects {
+		// Failures older than the lockout duration no longer count
+		counter, err := g.store.GetCounter(ctx, subject)
+		if err != nil {
+			return err
+		}
+		if counter.Failures > 0 && now.Sub(counter.LastFailureAt) >= g.policy.LockoutDuration {
+			if err := g.store.ResetCounter(ctx, subject); err != nil {
+				return err
+			}
+		}
+
+		if _, err := g.store.IncrementCounter(ctx, subject, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Success clears the failures of an account after a complete login.
+// The IP counter is kept, so one valid account cannot reset it for guessing others.
+func (g *Guard) Success(ctx context.Context, username string) error {
+	return g.store.ResetCounter(ctx, accountSubject(username))
+}
+
+// Unlock clears the failures and any lock of an account
+func (g *Guard) Unlock(ctx context.Context, username string) error {
+	return g.store.ResetCounter(ctx, accountSubject(username))
+}
+
+// Record adds an attempt to the login attempt log
+func (g *Guard) Record(ctx context.Context, a Attempt) error {
+	a.CreatedAt = time.Now().UTC()
+	return g.store.RecordAttempt(ctx, a)
+}
+
+// wait computes the remaining backoff or lock time of a counter
+func (g *Guard) wait(c Counter, limit int, now time.Time) time.Duration {
+	if c.Failures == 0 || now.Sub(c.LastFailureAt) >= g.policy.LockoutDuration {
+		return 0
+	}
+
+	delay := g.policy.LockoutDuration
+	if c.Failures < limit {
+		delay = backoff(c.Failures)
+	}
+
+	if remaining := c.LastFailureAt.Add(delay).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// backoff returns the delay after the given number of failures
+func backoff(failures int) time.Duration {
+	if failures > 7 {
+		return maxBackoff
+	}
+	delay := time.Second << (failures - 1)
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
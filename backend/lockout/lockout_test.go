@@ -0,0 +1,185 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{MaxFailures: 3, IPMaxFailures: 5, LockoutDuration: 15 * time.Minute, MaxResetRequests: 2, IPMaxResetRequests: 3}
+
+// check returns the wait of a client, failing the test on errors
+func check(t *testing.T, g *Guard, ip, username string) time.Duration {
+	t.Helper()
+	wait, err := g.Check(context.Background(), ip, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wait
+}
+
+// fail counts failed logins, failing the test on errors
+func fail(t *testing.T, g *Guard, ip, username string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := g.Failure(context.Background(), ip, username); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// between reports whether d lies in (max-1s, max]; Check runs a moment after the failure
+func between(d, max time.Duration) bool {
+	return d > max-time.Second && d <= max
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{7, time.Minute},
+		{20, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestGuardLocksAccount(t *testing.T) {
+	g := NewGuard(NewMemoryStore(), testPolicy)
+
+	if wait := check(t, g, "10.0.0.1", "admin"); wait != 0 {
+		t.Fatalf("wait before any failure = %v, want 0", wait)
+	}
+	fail(t, g, "10.0.0.1", "admin", 1)
+	if wait := check(t, g, "10.0.0.1", "admin"); !between(wait, time.Second) {
+		t.Errorf("wait after 1 failure = %v, want about 1s", wait)
+	}
+	fail(t, g, "10.0.0.1", "admin", 1)
+	if wait := check(t, g, "10.0.0.1", "admin"); !between(wait, 2*time.Second) {
+		t.Errorf("wait after 2 failures = %v, want about 2s", wait)
+	}
+
+	// The limit locks the account from every address, names compared case-insensitively
+	fail(t, g, "10.0.0.1", "admin", 1)
+	if wait := check(t, g, "10.0.0.2", "Admin"); !between(wait, testPolicy.LockoutDuration) {
+		t.Errorf("wait after %d failures = %v, want the lockout duration", testPolicy.MaxFailures, wait)
+	}
+	if wait := check(t, g, "10.0.0.2", "editor"); wait != 0 {
+		t.Errorf("other account from another address waits %v, want 0", wait)
+	}
+
+	if err := g.Unlock(context.Background(), "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if wait := check(t, g, "10.0.0.2", "admin"); wait != 0 {
+		t.Errorf("wait after unlock = %v, want 0", wait)
+	}
+}
+
+func TestGuardLocksIP(t *testing.T) {
+	g := NewGuard(NewMemoryStore(), testPolicy)
+
+	// Guessing many accounts from one address locks the address
+	for _, username := range []string{"a", "b", "c", "d", "e"} {
+		fail(t, g, "10.0.0.1", username, 1)
+	}
+	if wait := check(t, g, "10.0.0.1", "f"); !between(wait, testPolicy.LockoutDuration) {
+		t.Errorf("wait of the address = %v, want the lockout duration", wait)
+	}
+	if wait := check(t, g, "10.0.0.2", "f"); wait != 0 {
+		t.Errorf("wait of another address = %v, want 0", wait)
+	}
+}
+
+func TestGuardSuccessKeepsIPCounter(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	g := NewGuard(store, testPolicy)
+	fail(t, g, "10.0.0.1", "admin", 2)
+
+	if err := g.Success(ctx, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := store.GetCounter(ctx, accountSubject("admin")); c.Failures != 0 {
+		t.Errorf("account failures after success = %d, want 0", c.Failures)
+	}
+	if c, _ := store.GetCounter(ctx, ipSubject("10.0.0.1")); c.Failures != 2 {
+		t.Errorf("address failures after success = %d, want 2", c.Failures)
+	}
+}
+
+func TestGuardForgetsOldFailures(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	g := NewGuard(store, testPolicy)
+
+	// A lock from longer ago than the lockout duration has expired
+	old := time.Now().UTC().Add(-testPolicy.LockoutDuration - time.Minute)
+	for i := 0; i < testPolicy.MaxFailures; i++ {
+		if _, err := store.IncrementCounter(ctx, accountSubject("admin"), old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait := check(t, g, "10.0.0.1", "admin"); wait != 0 {
+		t.Fatalf("wait after the lock expired = %v, want 0", wait)
+	}
+
+	// and a new failure starts counting from one again
+	fail(t, g, "10.0.0.1", "admin", 1)
+	if c, _ := store.GetCounter(ctx, accountSubject("admin")); c.Failures != 1 {
+		t.Errorf("failures = %d, want 1", c.Failures)
+	}
+	if wait := check(t, g, "10.0.0.1", "admin"); !between(wait, time.Second) {
+		t.Errorf("wait = %v, want about 1s", wait)
+	}
+}
+
+func TestGuardLimitsResetRequests(t *testing.T) {
+	ctx := context.Background()
+	g := NewGuard(NewMemoryStore(), testPolicy)
+	checkReset := func(ip, email string) time.Duration {
+		t.Helper()
+		wait, err := g.CheckReset(ctx, ip, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wait
+	}
+	request := func(ip, email string) {
+		t.Helper()
+		if err := g.ResetRequested(ctx, ip, email); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No backoff below the limit, then the email waits out the lockout duration
+	request("10.0.0.1", "ann@example.com")
+	if wait := checkReset("10.0.0.1", "ann@example.com"); wait != 0 {
+		t.Errorf("wait after 1 request = %v, want 0", wait)
+	}
+	request("10.0.0.1", "ann@example.com")
+	if wait := checkReset("10.0.0.2", "ANN@example.com"); !between(wait, testPolicy.LockoutDuration) {
+		t.Errorf("wait of the email from another address = %v, want the lockout duration", wait)
+	}
+
+	// The address is limited across emails
+	if wait := checkReset("10.0.0.1", "bob@example.com"); wait != 0 {
+		t.Errorf("wait of another email = %v, want 0", wait)
+	}
+	request("10.0.0.1", "bob@example.com")
+	if wait := checkReset("10.0.0.1", "cy@example.com"); !between(wait, testPolicy.LockoutDuration) {
+		t.Errorf("wait of the address = %v, want the lockout duration", wait)
+	}
+
+	// Reset requests do not count as failed logins
+	if wait := check(t, g, "10.0.0.1", "ann@example.com"); wait != 0 {
+		t.Errorf("login wait = %v, want 0", wait)
+	}
+}
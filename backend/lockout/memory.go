@@ -0,0 +1,79 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory; counters are not shared between instances
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+	attempts []Attempt // Kept in insertion order
+	nextID   int
+}
+
+// NewMemoryStore creates an empty in-memory lockout store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]Counter{}, nextID: 1}
+}
+
+// GetCounter returns the failure counter of a subject
+func (s *MemoryStore) GetCounter(ctx context.Context, subject string) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.counters[subject]; ok {
+		return c, nil
+	}
+	return Counter{Subject: subject}, nil
+}
+
+// IncrementCounter adds a failure and returns the updated counter
+func (s *MemoryStore) IncrementCounter(ctx context.Context, subject string, at time.Time) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.counters[subject]
+	c.Subject = subject
+	c.Failures++
+	c.LastFailureAt = at
+	s.counters[subject] = c
+	return c, nil
+}
+
+// ResetCounter removes the failure counter of a subject
+func (s *MemoryStore) ResetCounter(ctx context.Context, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, subject)
+	return nil
+}
+
+// RecordAttempt appends an entry to the login attempt log
+func (s *MemoryStore) RecordAttempt(ctx context.Context, a Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.ID = s.nextID
+	s.nextID++
+	s.attempts = append(s.attempts, a)
+	return nil
+}
+
+// ListAttempts returns the newest login attempts matching the filter
+func (s *MemoryStore) ListAttempts(ctx context.Context, filter AttemptFilter) ([]Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := []Attempt{}
+	for i := len(s.attempts) - 1; i >= 0 && len(attempts) < filter.Limit; i-- {
+		a := s.attempts[i]
+		if (filter.Username == "" || a.Username == filter.Username) && (filter.IP == "" || a.IP == filter.IP) {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
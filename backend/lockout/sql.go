@@ -0,0 +1,94 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"portfolio/db"
+	"strings"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite, so counters are shared by every instance
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a lockout store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// GetCounter returns the failure counter of a subject
+func (s *SQLStore) GetCounter(ctx context.Context, subject string) (Counter, error) {
+	c := Counter{Subject: subject}
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT failures, last_failure_at FROM login_failures WHERE subject=$1", subject).
+		Scan(&c.Failures, &c.LastFailureAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Counter{Subject: subject}, nil
+	}
+	return c, err
+}
+
+// IncrementCounter adds a failure in a single statement, so concurrent requests never lose a count
+func (s *SQLStore) IncrementCounter(ctx context.Context, subject string, at time.Time) (Counter, error) {
+	c := Counter{Subject: subject}
+	err := s.conn.QueryRowContext(ctx, `INSERT INTO login_failures (subject, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (subject) DO UPDATE SET failures = login_failures.failures + 1, last_failure_at = excluded.last_failure_at
+		RETURNING failures, last_failure_at`, subject, at).
+		Scan(&c.Failures, &c.LastFailureAt)
+	return c, err
+}
+
+// ResetCounter removes the failure counter of a subject
+func (s *SQLStore) ResetCounter(ctx context.Context, subject string) error {
+	_, err := s.conn.ExecContext(ctx, "DELETE FROM login_failures WHERE subject=$1", subject)
+	return err
+}
+
+// RecordAttempt inserts an entry into the login attempt log
+func (s *SQLStore) RecordAttempt(ctx context.Context, a Attempt) error {
+	_, err := s.conn.ExecContext(ctx,
+		"INSERT INTO login_attempts (username, ip, user_agent, success, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		a.Username, a.IP, a.UserAgent, a.Success, a.Reason, a.CreatedAt)
+	return err
+}
+
+// ListAttempts returns the newest login attempts matching the filter
+func (s *SQLStore) ListAttempts(ctx context.Context, filter AttemptFilter) ([]Attempt, error) {
+	var conditions []string
+	var args []any
+	if filter.Username != "" {
+		args = append(args, filter.Username)
+		conditions = append(conditions, fmt.Sprintf("username=$%d", len(args)))
+	}
+	if filter.IP != "" {
+		args = append(args, filter.IP)
+		conditions = append(conditions, fmt.Sprintf("ip=$%d", len(args)))
+	}
+
+	query := "SELECT id, username, ip, COALESCE(user_agent, ''), success, reason, created_at FROM login_attempts"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []Attempt{}
+	for rows.Next() {
+		var a Attempt
+		if err := rows.Scan(&a.ID, &a.Username, &a.IP, &a.UserAgent, &a.Success, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
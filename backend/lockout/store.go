@@ -0,0 +1,51 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Counter holds the recent failed logins of one subject, an account or an IP address
+type Counter struct {
+	Subject       string
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// Attempt is one entry of the login attempt log
+type Attempt struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"` // See the Reason constants
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reasons recorded with a login attempt
+const (
+	ReasonSuccess       = "success"
+	ReasonUnknownUser   = "unknown_user"
+	ReasonWrongPassword = "wrong_password"
+	ReasonInvalidCode   = "invalid_2fa_code"
+	ReasonThrottled     = "throttled" // Rejected without checking the password
+)
+
+// AttemptFilter narrows the login attempt log; empty fields match everything
+type AttemptFilter struct {
+	Username string
+	IP       string
+	Limit    int
+}
+
+// Store persists failure counters and the login attempt log.
+// Counters must be updated atomically so several server instances can share them.
+type Store interface {
+	GetCounter(ctx context.Context, subject string) (Counter, error) // Zero Counter if there is none
+	// IncrementCounter adds one failure at the given time and returns the updated counter
+	IncrementCounter(ctx context.Context, subject string, at time.Time) (Counter, error)
+	ResetCounter(ctx context.Context, subject string) error
+	RecordAttempt(ctx context.Context, a Attempt) error
+	ListAttempts(ctx context.Context, filter AttemptFilter) ([]Attempt, error) // Newest first
+}
@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewMessageID returns a unique Message-ID in the domain of the sender address.
+// The outbox sets it before queueing so every retry of a message keeps the same ID.
+func NewMessageID(from string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
+
+// buildEML renders a message in RFC 5322 format with a text and an HTML part.
+// It returns the raw message and its Message-ID.
+func buildEML(msg Message, now time.Time) ([]byte, string, error) {
+	messageID := msg.Headers["Message-ID"]
+	if messageID == "" {
+		messageID = NewMessageID(msg.From)
+	}
+
+	// CR and LF are dropped from every value, so a visitor's address or name cannot add headers
+	var buf bytes.Buffer
+	stripBreaks := strings.NewReplacer("\r", "", "\n", "")
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, stripBreaks.Replace(value))
+	}
+
+	header("From", msg.From)
+	header("To", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", stripBreaks.Replace(msg.Subject)))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+
+	// Extra headers in a stable order
+	keys := make([]string, 0, len(msg.Headers))
+	for key := range msg.Headers {
+		if key != "Message-ID" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		header(key, msg.Headers[key])
+	}
+
+	writer := multipart.NewWriter(&buf)
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary()))
+	buf.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return nil, "", err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), messageID, nil
+}
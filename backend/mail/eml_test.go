@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildEMLStripsHeaderBreaks(t *testing.T) {
+	injection := "\r\nBcc: evil@example.com"
+	msg := Message{
+		From:    "from@example.com",
+		To:      []string{"to@example.com"},
+		ReplyTo: "ann@example.com" + injection,
+		Subject: "Hello" + injection,
+		Text:    "Hi",
+		HTML:    "<p>Hi</p>",
+		Headers: map[string]string{
+			"Message-ID":  "<1@example.com>",
+			"In-Reply-To": "<0@example.com>" + injection,
+		},
+	}
+
+	raw, messageID, err := buildEML(msg, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messageID != "<1@example.com>" {
+		t.Errorf("Message-ID = %q, want the one of the message", messageID)
+	}
+	if bytes.Contains(raw, []byte("\r\nBcc:")) {
+		t.Errorf("message has an injected Bcc header:\n%s", raw)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcc := parsed.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("Bcc = %q, want none", bcc)
+	}
+	for key, want := range map[string]string{
+		"Reply-To":    "ann@example.comBcc: evil@example.com",
+		"In-Reply-To": "<0@example.com>Bcc: evil@example.com",
+	} {
+		if got := parsed.Header.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || strings.Contains(subject, "\n") {
+		t.Errorf("Subject = %q, %v; want one line", subject, err)
+	}
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSender writes every message as an .eml file to a directory instead of sending it,
+// for development without a mail provider. The files open in any mail client.
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender creates a file driver, creating dir if needed
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+// Send writes the message to <dir>/<timestamp>-<id>.eml and returns its Message-ID
+func (s *FileSender) Send(ctx context.Context, msg Message) (string, error) {
+	now := time.Now()
+	raw, messageID, err := buildEML(msg, now)
+	if err != nil {
+		return "", err
+	}
+
+	id := strings.Trim(messageID, "<>")
+	if at := strings.Index(id, "@"); at >= 0 {
+		id = id[:at]
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), id)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), raw, 0o644); err != nil {
+		return "", err
+	}
+	return messageID, nil
+}
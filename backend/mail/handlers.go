@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the email template endpoints of the admin API
+type Handler struct {
+	mailer *Mailer
+}
+
+// NewHandler creates template handlers for the given mailer
+func NewHandler(mailer *Mailer) *Handler {
+	return &Handler{mailer: mailer}
+}
+
+// GetTemplates lists the names of the email templates
+func (h *Handler) GetTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, TemplateNames())
+}
+
+// PreviewTemplate renders a template with sample data. By default it returns the subject and both
+// bodies as JSON; ?format=html or ?format=text returns just that body, to open in a browser.
+func (h *Handler) PreviewTemplate(c *gin.Context) {
+	name := c.Param("name")
+	if !slices.Contains(TemplateNames(), name) {
+		problem.Respond(c, problem.NotFound("Template not found"))
+		return
+	}
+
+	msg, err := h.mailer.Preview(name)
+	if err != nil {
+		fmt.Println("Template preview error:", err)
+		problem.Respond(c, problem.Internal("Template could not be rendered"))
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+	case "text":
+		c.String(http.StatusOK, msg.Text)
+	case "":
+		c.JSON(http.StatusOK, gin.H{"subject": msg.Subject, "html": msg.HTML, "text": msg.Text})
+	default:
+		problem.Respond(c, problem.BadRequest("Invalid format (use html or text)"))
+	}
+}
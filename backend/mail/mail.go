@@ -1,11 +1,13 @@
 package mail
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
-
-	"github.com/resend/resend-go/v2"
+	"strings"
+	"time"
 )
 
 // getEnvOrDefault gets environment variable or returns default value
@@ -16,19 +18,52 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
-// validateConfig checks if required environment variables are set
-func validateConfig() (string, string, string, error) {
-	apiKey := getEnvOrDefault("RESEND_API_KEY", "")
-	toEmail := getEnvOrDefault("TO_EMAIL", "bkuzey.dev@gmail.com")
-	fromEmail := getEnvOrDefault("FROM_EMAIL", "onboarding@resend.dev")
+// Mailer builds the application's emails from templates and hands them to a Sender
+type Mailer struct {
+	sender    Sender
+	templates *Templates
+	fromEmail string // FROM_EMAIL, sender of every email
+	toEmail   string // TO_EMAIL, where contact form submissions go
+}
+
+// NewMailer creates a mailer that renders with templates and sends through sender
+func NewMailer(sender Sender, templates *Templates, fromEmail, toEmail string) *Mailer {
+	return &Mailer{sender: sender, templates: templates, fromEmail: fromEmail, toEmail: toEmail}
+}
 
-	if apiKey == "" {
-		return "", "", "", fmt.Errorf("RESEND_API_KEY environment variable is required")
+// NewMailerFromEnv creates a mailer that hands its emails to sender, sending from FROM_EMAIL,
+// delivering contact form messages to TO_EMAIL and using templates from MAIL_TEMPLATE_DIR
+func NewMailerFromEnv(sender Sender) (*Mailer, error) {
+	templates, err := LoadTemplatesFromEnv()
+	if err != nil {
+		return nil, err
 	}
-	if toEmail == "" {
-		return "", "", "", fmt.Errorf("TO_EMAIL environment variable is required")
+	return NewMailer(sender, templates,
+		getEnvOrDefault("FROM_EMAIL", "onboarding@resend.dev"),
+		getEnvOrDefault("TO_EMAIL", "bkuzey.dev@gmail.com")), nil
+}
+
+// send delivers a message and logs the result under the given kind of mail
+func (m *Mailer) send(ctx context.Context, kind string, msg Message) error {
+	if msg.From == "" {
+		msg.From = m.fromEmail
+	}
+
+	id, err := m.sender.Send(ctx, msg)
+	if err != nil {
+		log.Printf("%s mail error: %v", kind, err)
+		return fmt.Errorf("failed to send email: %v", err)
 	}
-	return apiKey, toEmail, fromEmail, nil
+
+	log.Printf("%s mail accepted for delivery. ID: %s", kind, id)
+	return nil
+}
+
+// AppURL builds a link into the admin app for emails, such as reset and invite links
+// (APP_URL, default http://localhost:3000)
+func AppURL(path, token string) string {
+	base := strings.TrimSuffix(getEnvOrDefault("APP_URL", "http://localhost:3000"), "/")
+	return base + path + "?token=" + url.QueryEscape(token)
 }
 
 // ContactMailData represents contact form data for email
@@ -39,174 +74,150 @@ type ContactMailData struct {
 	Message string `json:"message"`
 }
 
-// SendContactMail sends contact form submission via email
-func SendContactMail(data ContactMailData) error {
-	// Get environment variables fresh each time
-	apiKey, toEmail, fromEmail, err := validateConfig()
-	if err != nil {
-		log.Printf("Mail configuration error: %v", err)
-		return err
-	}
+// AutoReplyMailData is the acknowledgement sent to a visitor. Subject and Body are already
+// personalised; Message is the visitor's own message, quoted below the body.
+type AutoReplyMailData struct {
+	Subject string
+	Body    string
+	Message string
+}
 
-	// DEBUG - API KEY CONTROL
-	fmt.Printf("=== MAIL DEBUG ===\n")
-	fmt.Printf("API Key length: %d\n", len(apiKey))
-	if len(apiKey) > 10 {
-		fmt.Printf("API Key starts with: %s\n", apiKey[:10])
-		fmt.Printf("API Key ends with: %s\n", apiKey[len(apiKey)-6:])
+// ContactReplyMailData is an admin's answer to a contact message, quoting the original
+type ContactReplyMailData struct {
+	Subject    string
+	Body       string
+	Name       string // Visitor who wrote the original message
+	Message    string // The original message
+	ReceivedAt string // When the original message arrived, already formatted
+}
+
+// Data of the other templates
+type (
+	welcomeMailData struct {
+		Name string
 	}
-	fmt.Printf("TO_EMAIL: %s\n", toEmail)
-	fmt.Printf("FROM_EMAIL: %s\n", fromEmail)
-	fmt.Printf("==================\n")
-
-	// Initialize Resend client
-	client := resend.NewClient(apiKey)
-
-	// Create email content
-	subject := "Portfolio Contact: Contact Form"
-
-	// HTML content
-	htmlContent := `
-		<div style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background-color: #f4f4f4;">
-			<div style="max-width: 600px; margin: 0 auto; background: white; border-radius: 10px; box-shadow: 0 0 20px rgba(0,0,0,0.1); overflow: hidden;">
-				
-				<!-- Header -->
-				<div style="background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); color: white; padding: 30px 20px; text-align: center;">
-					<h1 style="margin: 0; font-size: 24px; font-weight: 300;">📧 New Portfolio Contact</h1>
-					<div style="display: inline-block; background: #28a745; color: white; padding: 5px 15px; border-radius: 20px; font-size: 12px; margin-top: 15px;">New Message Received</div>
-				</div>
-				
-				<!-- Content -->
-				<div style="padding: 30px;">
-					
-					<!-- Name Field -->
-					<div style="margin-bottom: 20px; padding: 15px; background: #f8f9fa; border-left: 4px solid #667eea; border-radius: 5px;">
-						<div style="display: flex; align-items: center; margin-bottom: 10px;">
-							<div style="width: 20px; height: 20px; background: #667eea; border-radius: 50%; display: inline-flex; align-items: center; justify-content: center; color: white; font-size: 12px; margin-right: 10px;">👤</div>
-							<div>
-								<div style="font-weight: bold; color: #333; font-size: 14px; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 5px;">Name</div>
-								<div style="color: #555; font-size: 16px;">` + data.Name + `</div>
-							</div>
-						</div>
-					</div>
-
-					<!-- Email Field -->
-					<div style="margin-bottom: 20px; padding: 15px; background: #f8f9fa; border-left: 4px solid #667eea; border-radius: 5px;">
-						<div style="display: flex; align-items: center; margin-bottom: 10px;">
-							<div style="width: 20px; height: 20px; background: #667eea; border-radius: 50%; display: inline-flex; align-items: center; justify-content: center; color: white; font-size: 12px; margin-right: 10px;">📧</div>
-							<div>
-								<div style="font-weight: bold; color: #333; font-size: 14px; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 5px;">Email Address</div>
-								<div style="color: #555; font-size: 16px;">` + data.Email + `</div>
-							</div>
-						</div>
-					</div>
-
-					<!-- Phone Field -->
-					<div style="margin-bottom: 20px; padding: 15px; background: #f8f9fa; border-left: 4px solid #667eea; border-radius: 5px;">
-						<div style="display: flex; align-items: center; margin-bottom: 10px;">
-							<div style="width: 20px; height: 20px; background: #667eea; border-radius: 50%; display: inline-flex; align-items: center; justify-content: center; color: white; font-size: 12px; margin-right: 10px;">📱</div>
-							<div>
-								<div style="font-weight: bold; color: #333; font-size: 14px; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 5px;">Phone Number</div>
-								<div style="color: #555; font-size: 16px;">` + data.Phone + `</div>
-							</div>
-						</div>
-					</div>
-
-					<!-- Message Field -->
-					<div style="margin-bottom: 20px; padding: 15px; background: #f8f9fa; border-left: 4px solid #667eea; border-radius: 5px;">
-						<div style="font-weight: bold; color: #333; font-size: 14px; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 10px;">💬 Message Content</div>
-						<div style="background: #fff; border: 1px solid #e0e0e0; border-radius: 8px; padding: 20px; font-style: italic; color: #555;">` + data.Message + `</div>
-					</div>
-
-				</div>
-
-				<!-- Footer -->
-				<div style="background: #f8f9fa; padding: 20px; text-align: center; border-top: 1px solid #e0e0e0; color: #666; font-size: 12px;">
-					<p style="margin: 5px 0;">🚀 This message was sent from your portfolio website</p>
-					<p style="margin: 5px 0;">Generated automatically • Portfolio Contact System</p>
-				</div>
-
-			</div>
-		</div>
-	`
-
-	textContent := fmt.Sprintf(`
-		New Portfolio Contact Message
-		
-		Name: %s
-		Email: %s
-		Phone: %s
-		
-		Message:
-		%s
-		
-		---
-		This message was sent from your portfolio website.
-	`, data.Name, data.Email, data.Phone, data.Message)
-
-	// Prepare email parameters
-	params := &resend.SendEmailRequest{
-		From:    fromEmail,
-		To:      []string{toEmail},
-		Subject: subject,
-		Html:    htmlContent,
-		Text:    textContent,
+	passwordResetMailData struct {
+		Name     string
+		URL      string
+		ValidFor string
 	}
+	inviteMailData struct {
+		Inviter  string
+		Role     string
+		URL      string
+		ValidFor string
+	}
+)
 
-	// DEBUG - EMAIL PARAMS
-	fmt.Printf("=== EMAIL PARAMS DEBUG ===\n")
-	fmt.Printf("From: %s\n", params.From)
-	fmt.Printf("To: %v\n", params.To)
-	fmt.Printf("Subject: %s\n", params.Subject)
-	fmt.Printf("========================\n")
-
-	// Send email
-	sent, err := client.Emails.Send(params)
+// render builds an email from a template, sent from FROM_EMAIL to the given addresses
+func (m *Mailer) render(name string, data any, to ...string) (Message, error) {
+	msg, err := m.templates.Render(name, data)
 	if err != nil {
-		log.Printf("Mail sending error: %v", err)
-		return fmt.Errorf("failed to send email: %v", err)
+		return Message{}, err
 	}
+	msg.From = m.fromEmail
+	msg.To = to
+	return msg, nil
+}
 
-	log.Printf("Mail sent successfully. ID: %s", sent.Id)
-	return nil
+// Preview renders a template with sample data, for checking templates in the admin panel
+func (m *Mailer) Preview(name string) (Message, error) {
+	return m.templates.Preview(name)
+}
+
+// ContactMail builds the notification about a contact form submission. It is not sent here:
+// the contact store queues it in the same transaction that saves the submission.
+func (m *Mailer) ContactMail(data ContactMailData) (Message, error) {
+	msg, err := m.render(TemplateContact, data, m.toEmail)
+	// Replies from the mail client go straight to the visitor
+	msg.ReplyTo = data.Email
+	return msg, err
+}
+
+// AutoReplyMail builds the acknowledgement for a visitor; SendAutoReplyMail sends it once the
+// auto-responder has checked that the address may get one.
+func (m *Mailer) AutoReplyMail(to string, data AutoReplyMailData) (Message, error) {
+	msg, err := m.render(TemplateAutoReply, data, to)
+	// Replies reach the site owner; the header keeps other autoresponders from answering (RFC 3834)
+	msg.ReplyTo = m.toEmail
+	msg.Headers = map[string]string{"Auto-Submitted": "auto-replied"}
+	return msg, err
+}
+
+// ContactReplyMail builds an admin's reply to a visitor. headers carries the threading headers
+// (Message-ID, In-Reply-To, References); answers from the visitor go to TO_EMAIL.
+func (m *Mailer) ContactReplyMail(to string, data ContactReplyMailData, headers map[string]string) (Message, error) {
+	msg, err := m.render(TemplateContactReply, data, to)
+	msg.ReplyTo = m.toEmail
+	msg.Headers = headers
+	return msg, err
+}
+
+// FromEmail returns the address every email is sent from
+func (m *Mailer) FromEmail() string {
+	return m.fromEmail
 }
 
 // SendWelcomeMail sends welcome email to new users (bonus feature)
-func SendWelcomeMail(userEmail, userName string) error {
-	apiKey, _, fromEmail, err := validateConfig()
+func (m *Mailer) SendWelcomeMail(ctx context.Context, userEmail, userName string) error {
+	msg, err := m.render(TemplateWelcome, welcomeMailData{Name: userName}, userEmail)
 	if err != nil {
 		return err
 	}
+	return m.send(ctx, "Welcome", msg)
+}
 
-	client := resend.NewClient(apiKey)
-
-	subject := "Welcome to Portfolio Admin Panel!"
-
-	htmlContent := fmt.Sprintf(`
-		<div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f4f4f4;">
-			<div style="background: white; padding: 30px; border-radius: 10px; box-shadow: 0 0 10px rgba(0,0,0,0.1);">
-				<h2 style="color: #333; margin-bottom: 20px;">Welcome %s!</h2>
-				<p style="color: #555; line-height: 1.6;">You have successfully registered to the portfolio admin panel.</p>
-				<p style="color: #555; line-height: 1.6;">You can now manage your portfolio content.</p>
-				<br>
-				<p style="color: #667eea; font-weight: bold;">Happy coding!</p>
-			</div>
-		</div>
-	`, userName)
-
-	params := &resend.SendEmailRequest{
-		From:    fromEmail,
-		To:      []string{userEmail},
-		Subject: subject,
-		Html:    htmlContent,
+// SendAutoReplyMail sends an acknowledgement built by AutoReplyMail
+func (m *Mailer) SendAutoReplyMail(ctx context.Context, msg Message) error {
+	return m.send(ctx, "Auto-reply", msg)
+}
+
+// SendPasswordResetMail sends a password reset link to a user
+func (m *Mailer) SendPasswordResetMail(ctx context.Context, userEmail, userName, resetURL string, validFor time.Duration) error {
+	msg, err := m.render(TemplatePasswordReset, passwordResetMailData{
+		Name:     userName,
+		URL:      resetURL,
+		ValidFor: formatDuration(validFor),
+	}, userEmail)
+	if err != nil {
+		return err
 	}
+	return m.send(ctx, "Password reset", msg)
+}
 
-	sent, err := client.Emails.Send(params)
+// SendInviteMail sends an invitation to create an admin account
+func (m *Mailer) SendInviteMail(ctx context.Context, email, inviterName, role, inviteURL string, validFor time.Duration) error {
+	msg, err := m.render(TemplateInvite, inviteMailData{
+		Inviter:  inviterName,
+		Role:     role,
+		URL:      inviteURL,
+		ValidFor: formatDuration(validFor),
+	}, email)
 	if err != nil {
-		log.Printf("Welcome mail error: %v", err)
 		return err
 	}
+	return m.send(ctx, "Invite", msg)
+}
 
-	log.Printf("Welcome mail sent successfully. ID: %s", sent.Id)
-	return nil
+// formatDuration writes a duration as "2 hours" or "30 minutes" for email text
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		if d == 24*time.Hour {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	case d >= time.Minute:
+		if d/time.Minute == 1 {
+			return "1 minute"
+		}
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	default:
+		return d.String()
+	}
 }
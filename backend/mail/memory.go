@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps sent messages in memory so tests can inspect them
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemorySender creates an empty in-memory driver
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send stores the message and returns its Message-ID
+func (s *MemorySender) Send(ctx context.Context, msg Message) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messageID := msg.Headers["Message-ID"]
+	if messageID == "" {
+		messageID = NewMessageID(msg.From)
+	}
+	s.messages = append(s.messages, msg)
+	return messageID, nil
+}
+
+// Messages returns a copy of every message sent so far, oldest first
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Reset forgets all sent messages
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/resend/resend-go/v2"
+)
+
+// ResendSender sends messages through the Resend API
+type ResendSender struct {
+	client *resend.Client
+}
+
+// NewResendSender creates a Resend driver with the given API key
+func NewResendSender(apiKey string) *ResendSender {
+	return &ResendSender{client: resend.NewClient(apiKey)}
+}
+
+// Send delivers a message and returns Resend's email ID
+func (s *ResendSender) Send(ctx context.Context, msg Message) (string, error) {
+	sent, err := s.client.Emails.SendWithContext(ctx, &resend.SendEmailRequest{
+		From:    msg.From,
+		To:      msg.To,
+		ReplyTo: msg.ReplyTo,
+		Subject: msg.Subject,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+		Headers: msg.Headers,
+	})
+	if err != nil {
+		return "", err
+	}
+	return sent.Id, nil
+}
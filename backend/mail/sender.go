@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is an email ready to be handed to a Sender
+type Message struct {
+	From    string
+	To      []string
+	ReplyTo string
+	Subject string
+	HTML    string
+	Text    string
+	Headers map[string]string // Extra headers, e.g. In-Reply-To for threading
+}
+
+// Sender delivers messages through one transport. Send returns an ID for the sent
+// message: the provider's ID for Resend, the Message-ID header for the other drivers.
+type Sender interface {
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Mail drivers selectable with MAIL_DRIVER
+const (
+	DriverResend = "resend"
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// NewSenderFromEnv creates the Sender chosen by MAIL_DRIVER.
+//
+//   - resend: RESEND_API_KEY
+//   - smtp: SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME, SMTP_PASSWORD and
+//     SMTP_SECURITY ("starttls" by default, "tls" for implicit TLS, or "none")
+//   - file: writes .eml files to MAIL_DIR (default "mail-out")
+//   - memory: keeps messages in process memory
+//
+// Without MAIL_DRIVER, Resend is used when RESEND_API_KEY is set and the file driver otherwise,
+// so development works offline.
+func NewSenderFromEnv() (Sender, error) {
+	driver := os.Getenv("MAIL_DRIVER")
+	if driver == "" {
+		if os.Getenv("RESEND_API_KEY") != "" {
+			driver = DriverResend
+		} else {
+			driver = DriverFile
+			log.Printf("RESEND_API_KEY is not set, writing emails to %s instead", getEnvOrDefault("MAIL_DIR", "mail-out"))
+		}
+	}
+
+	switch driver {
+	case DriverResend:
+		apiKey := os.Getenv("RESEND_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("RESEND_API_KEY environment variable is required for the resend mail driver")
+		}
+		return NewResendSender(apiKey), nil
+
+	case DriverSMTP:
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST environment variable is required for the smtp mail driver")
+		}
+		port, err := strconv.Atoi(getEnvOrDefault("SMTP_PORT", "587"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %v", err)
+		}
+		security := getEnvOrDefault("SMTP_SECURITY", SecuritySTARTTLS)
+		if security != SecuritySTARTTLS && security != SecurityTLS && security != SecurityNone {
+			return nil, fmt.Errorf("invalid SMTP_SECURITY %q (use starttls, tls or none)", security)
+		}
+		return NewSMTPSender(SMTPConfig{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			Security: security,
+		}), nil
+
+	case DriverFile:
+		return NewFileSender(getEnvOrDefault("MAIL_DIR", "mail-out"))
+
+	case DriverMemory:
+		return NewMemorySender(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q (use resend, smtp, file or memory)", driver)
+	}
+}
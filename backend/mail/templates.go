@@ -0,0 +1,218 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// embeddedTemplates are the built-in email templates. Each email has a NAME.html.tmpl and a
+// NAME.txt.tmpl file defining "content" (and optionally "footer") for the layouts in layouts/;
+// the text file also defines "subject". Shared pieces live in partials/.
+//
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// Template names, one per kind of email
+const (
+	TemplateContact       = "contact"
+	TemplateWelcome       = "welcome"
+	TemplatePasswordReset = "password_reset"
+	TemplateInvite        = "invite"
+	TemplateAutoReply     = "auto_reply"
+	TemplateContactReply  = "contact_reply"
+)
+
+// templateSamples holds the data used to check templates at startup and to preview them
+var templateSamples = map[string]any{
+	TemplateContact: ContactMailData{
+		Name:    "Jane Doe",
+		Email:   "jane@example.com",
+		Phone:   "+1 555 0100",
+		Message: "Hello!\nI would like to talk about a project. <b>Tags</b> stay plain text.",
+	},
+	TemplateWelcome: welcomeMailData{Name: "Jane Doe"},
+	TemplatePasswordReset: passwordResetMailData{
+		Name:     "Jane Doe",
+		URL:      "https://example.com/admin/reset-password?token=sample",
+		ValidFor: "1 hour",
+	},
+	TemplateInvite: inviteMailData{
+		Inviter:  "admin",
+		Role:     "editor",
+		URL:      "https://example.com/admin/accept-invite?token=sample",
+		ValidFor: "3 days",
+	},
+	TemplateAutoReply: AutoReplyMailData{
+		Subject: "Thanks for your message, Jane Doe",
+		Body:    "Hi Jane Doe,\n\nThanks for getting in touch. I will reply as soon as I can.",
+		Message: "Hello!\nI would like to talk about a project.",
+	},
+	TemplateContactReply: ContactReplyMailData{
+		Subject:    "Re: Your message on the portfolio",
+		Body:       "Hi Jane,\n\nThanks for reaching out. Does Tuesday work for a call?",
+		Name:       "Jane Doe",
+		Message:    "Hello!\nI would like to talk about a project.",
+		ReceivedAt: "Mon, 2 Jan 2006 15:04 UTC",
+	},
+}
+
+// templateFuncs are available in every template
+var templateFuncs = map[string]any{
+	// dict builds a map from key/value pairs, to pass several values to a partial
+	"dict": func(pairs ...any) (map[string]any, error) {
+		if len(pairs)%2 != 0 {
+			return nil, errors.New("dict needs key/value pairs")
+		}
+		m := make(map[string]any, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("dict key %v is not a string", pairs[i])
+			}
+			m[key] = pairs[i+1]
+		}
+		return m, nil
+	},
+}
+
+// Templates renders the emails. HTML goes through html/template, so values such as a visitor's
+// name or message are escaped and cannot add markup or links; subjects and text bodies use
+// text/template.
+type Templates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// LoadTemplates parses the embedded templates. Files in dir, if set, replace the embedded file
+// with the same path, e.g. dir/contact.html.tmpl or dir/partials/button.html.tmpl.
+// Every template is rendered once with sample data so mistakes are caught at startup.
+func LoadTemplates(dir string) (*Templates, error) {
+	fsys, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		fsys = overlayFS{top: os.DirFS(dir), bottom: fsys}
+	}
+
+	t := &Templates{
+		html: map[string]*htmltemplate.Template{},
+		text: map[string]*texttemplate.Template{},
+	}
+	for _, name := range TemplateNames() {
+		html, err := htmltemplate.New(name).Funcs(templateFuncs).
+			ParseFS(fsys, "layouts/*.html.tmpl", "partials/*.html.tmpl", name+".html.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", name, err)
+		}
+		text, err := texttemplate.New(name).Funcs(templateFuncs).
+			ParseFS(fsys, "layouts/*.txt.tmpl", name+".txt.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", name, err)
+		}
+		t.html[name] = html
+		t.text[name] = text
+
+		if _, err := t.Render(name, templateSamples[name]); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// LoadTemplatesFromEnv loads the templates with overrides from MAIL_TEMPLATE_DIR, if set
+func LoadTemplatesFromEnv() (*Templates, error) {
+	return LoadTemplates(os.Getenv("MAIL_TEMPLATE_DIR"))
+}
+
+// TemplateNames lists the known templates in alphabetical order
+func TemplateNames() []string {
+	names := make([]string, 0, len(templateSamples))
+	for name := range templateSamples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render builds the subject, HTML and text body of a template. The message has no addresses yet.
+func (t *Templates) Render(name string, data any) (Message, error) {
+	html, ok := t.html[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+	text := t.text[name]
+
+	var subject, htmlBody, textBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("template %s: %v", name, err)
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "base", data); err != nil {
+		return Message{}, fmt.Errorf("template %s: %v", name, err)
+	}
+	if err := text.ExecuteTemplate(&textBody, "base", data); err != nil {
+		return Message{}, fmt.Errorf("template %s: %v", name, err)
+	}
+
+	// A subject is a single header line
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    htmlBody.String(),
+		Text:    strings.TrimSpace(textBody.String()) + "\n",
+	}, nil
+}
+
+// Preview renders a template with its sample data
+func (t *Templates) Preview(name string) (Message, error) {
+	data, ok := templateSamples[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+	return t.Render(name, data)
+}
+
+// overlayFS serves files from top when they exist there and from bottom otherwise.
+// Directory listings are merged, so a template directory only needs the files it changes.
+type overlayFS struct {
+	top, bottom fs.FS
+}
+
+// Open opens name from top, falling back to bottom
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if f, err := o.top.Open(name); err == nil {
+		return f, nil
+	}
+	return o.bottom.Open(name)
+}
+
+// ReadDir lists the entries of name in both layers, preferring top for duplicate names
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	bottom, bottomErr := fs.ReadDir(o.bottom, name)
+	top, topErr := fs.ReadDir(o.top, name)
+	if bottomErr != nil && topErr != nil {
+		return nil, bottomErr
+	}
+
+	entries := map[string]fs.DirEntry{}
+	for _, e := range bottom {
+		entries[e.Name()] = e
+	}
+	for _, e := range top {
+		entries[e.Name()] = e
+	}
+
+	merged := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		merged = append(merged, e)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
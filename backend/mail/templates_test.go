@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestMailer(t *testing.T) *Mailer {
+	t.Helper()
+	templates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewMailer(NewMemorySender(), templates, "from@example.com", "to@example.com")
+}
+
+func TestContactMailEscapesVisitorInput(t *testing.T) {
+	m := newTestMailer(t)
+
+	msg, err := m.ContactMail(ContactMailData{
+		Name:    `<script>alert("name")</script>`,
+		Email:   "ann@example.com",
+		Message: `Hi <script>alert("message")</script> <a href="https://evil.example">click</a>`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, markup := range []string{"<script>", `<a href="https://evil.example">`} {
+		if strings.Contains(msg.HTML, markup) {
+			t.Errorf("HTML body contains the visitor's %s", markup)
+		}
+	}
+	for _, escaped := range []string{
+		`&lt;script&gt;alert(&#34;name&#34;)&lt;/script&gt;`,
+		`&lt;script&gt;alert(&#34;message&#34;)&lt;/script&gt;`,
+		`&lt;a href=&#34;https://evil.example&#34;&gt;`,
+	} {
+		if !strings.Contains(msg.HTML, escaped) {
+			t.Errorf("HTML body does not contain %s", escaped)
+		}
+	}
+
+	// The text body is not HTML, so it shows the message as it was typed
+	if !strings.Contains(msg.Text, `<script>alert("message")</script>`) {
+		t.Errorf("text body = %q, want the message unchanged", msg.Text)
+	}
+}
+
+func TestRenderSubjectIsOneLine(t *testing.T) {
+	m := newTestMailer(t)
+
+	msg, err := m.AutoReplyMail("ann@example.com", AutoReplyMailData{
+		Subject: "Thanks, Ann\r\nBcc: evil@example.com",
+		Body:    "Hi",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		t.Errorf("subject = %q, want a single line", msg.Subject)
+	}
+	if msg.Subject != "Thanks, Ann Bcc: evil@example.com" {
+		t.Errorf("subject = %q, want the words on one line", msg.Subject)
+	}
+}
+
+func TestPreviewRendersEveryTemplate(t *testing.T) {
+	m := newTestMailer(t)
+	for _, name := range TemplateNames() {
+		msg, err := m.Preview(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if msg.Subject == "" || msg.HTML == "" || strings.TrimSpace(msg.Text) == "" {
+			t.Errorf("%s: subject %q, %d bytes HTML, %d bytes text; want all three", name, msg.Subject, len(msg.HTML), len(msg.Text))
+		}
+	}
+}
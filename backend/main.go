@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"portfolio/auth"
 	"portfolio/db"
+	"portfolio/mail"
+	"portfolio/middleware"
+	"portfolio/outbox"
+	"portfolio/problem"
+	"portfolio/projects"
 	"portfolio/routes"
 	"portfolio/server"
+	"portfolio/spam"
+	"portfolio/storage"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -21,13 +31,64 @@ func main() {
 		log.Printf("Error loading .env file: %v", err)
 	}
 
+	// "go run . migrate up|down [steps]|status" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if db.Driver() == db.DriverMemory {
+			log.Fatal("Migrations need an SQL backend, DB_DRIVER is memory")
+		}
+		conn := db.ConnectDB()
+		defer conn.Close()
+		if err := db.RunMigrateCommand(context.Background(), conn, os.Args[2:]); err != nil {
+			log.Fatalf("Migration error: %v", err)
+		}
+		return
+	}
+
+	// Refuses to start in release mode without a real signing key
+	if err := auth.LoadKeys(gin.Mode() == gin.ReleaseMode); err != nil {
+		log.Fatalf("JWT key error: %v", err)
+	}
+
+	sender, err := mail.NewSenderFromEnv()
+	if err != nil {
+		log.Fatalf("Mail configuration error: %v", err)
+	}
+
 	server.StartFrontend()
 
-	db.ConnectDB()
-	defer db.Pool.Close()
+	stores, closeStores := storage.Open(context.Background())
+	defer closeStores()
+
+	// Emails are written to the outbox and delivered in the background, with retries
+	go outbox.NewWorker(stores.Outbox, sender, outbox.ConfigFromEnv()).Run(context.Background())
+	mailer, err := mail.NewMailerFromEnv(outbox.NewQueue(stores.Outbox))
+	if err != nil {
+		log.Fatalf("Mail template error: %v", err)
+	}
+
+	// Drafts go live and published projects are archived on their schedule
+	go projects.NewScheduler(stores.Projects, projects.ScheduleIntervalFromEnv()).Run(context.Background())
+
+	// Contact form checks; the per-IP rate limit counts the messages in the contact store
+	spamChecker, err := spam.NewCheckerFromEnv(stores.Contact)
+	if err != nil {
+		log.Fatalf("Spam filter configuration error: %v", err)
+	}
 
-	r := gin.Default()
-	routes.SetupRoutes(r, db.Pool)
+	// Like gin.Default, with request IDs and panics answered as problem responses
+	r := gin.New()
+	r.Use(middleware.RequestID(), gin.Logger(), problem.Recovery())
+
+	// Only proxies listed here may set X-Forwarded-For; otherwise clients could fake their IP
+	// to get around the login limits. The default fits nginx on the same host.
+	trustedProxies := os.Getenv("TRUSTED_PROXIES")
+	if trustedProxies == "" {
+		trustedProxies = "127.0.0.1,::1"
+	}
+	if err := r.SetTrustedProxies(strings.Split(trustedProxies, ",")); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	routes.SetupRoutes(r, stores, mailer, spamChecker)
 
 	server.SetupStaticFiles(r)
 
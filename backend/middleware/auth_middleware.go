@@ -1,25 +1,23 @@
 package middleware
 
 import (
-	"net/http"
 	"portfolio/auth"
+	"portfolio/problem"
+	"portfolio/rbac"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates the JWT token
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates the JWT token and checks that its session is still active
+func AuthMiddleware(sessions auth.SessionStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 
 		// Return an error if the header is missing
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
-			c.Abort() // Stop the request and do not continue to the next handlers
+			problem.Respond(c, problem.Unauthorized("Authorization header is required"))
 			return
 		}
 
@@ -29,20 +27,21 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Return an error if the token is empty
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token not found",
-			})
-			c.Abort()
+			problem.Respond(c, problem.Unauthorized("Token not found"))
 			return
 		}
 
 		// Validate the token using the auth package function
 		claims, err := auth.ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+			problem.Respond(c, problem.Unauthorized("Invalid or expired token"))
+			return
+		}
+
+		// A revoked session (logout, token reuse, admin action) invalidates its tokens immediately
+		session, err := sessions.GetSession(c.Request.Context(), claims.SessionID)
+		if err != nil || session.RevokedAt != nil || session.UserID != claims.UserID {
+			problem.Respond(c, problem.Unauthorized("Session has been revoked"))
 			return
 		}
 
@@ -50,35 +49,44 @@ func AuthMiddleware() gin.HandlerFunc {
 		// This lets the next handlers access the current user
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
 
 		// Token is valid, continue to the next handler
 		c.Next()
 	}
 }
 
-// SuperAdminMiddleware - only for specific users
-func SuperAdminMiddleware() gin.HandlerFunc {
+// RequirePermission allows the request only if the current user's roles grant every
+// given permission. Permissions are resolved from the database on each request, so
+// role changes apply immediately. It must run after AuthMiddleware.
+func RequirePermission(roles rbac.Store, required ...rbac.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Normal auth must have already run
-		username, exists := c.Get("username")
+		userID, _, exists := GetCurrentUser(c)
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authentication required",
-			})
-			c.Abort()
+			problem.Respond(c, problem.Unauthorized("Authentication required"))
 			return
 		}
 
-		// Super admin check - only the "admin" user
-		if username != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Super admin privileges required for user management",
-			})
-			c.Abort()
+		// Load the permissions once per request, even when several checks run
+		var granted []rbac.Permission
+		if cached, ok := c.Get("permissions"); ok {
+			granted = cached.([]rbac.Permission)
+		} else {
+			var err error
+			granted, err = roles.UserPermissions(c.Request.Context(), userID)
+			if err != nil {
+				problem.Respond(c, problem.Internal("Could not load permissions"))
+				return
+			}
+			c.Set("permissions", granted)
+		}
+
+		if !rbac.HasAll(granted, required...) {
+			problem.Respond(c, problem.Forbidden("You do not have permission to perform this action"))
 			return
 		}
 
-		// Continue if the user is a super admin
+		// Continue if every permission is granted
 		c.Next()
 	}
 }
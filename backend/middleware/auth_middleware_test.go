@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"portfolio/internal/testutil"
+	"portfolio/rbac"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// newPermissionRouter serves GET /check behind RequirePermission(required) for the user with
+// userID, or for an anonymous request when userID is 0
+func newPermissionRouter(roles rbac.Store, userID int, required ...rbac.Permission) *gin.Engine {
+	r := gin.New()
+	r.GET("/check", func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+			c.Set("username", "user")
+		}
+	}, RequirePermission(roles, required...), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func check(r *gin.Engine) int {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/check", nil))
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	roles := rbac.NewMemoryStore()
+	for i, role := range rbac.DefaultRoles {
+		if err := roles.SetUserRoles(context.Background(), i+1, []string{role.Name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every default role against every permission: allowed exactly when the role grants it
+	for i, role := range rbac.DefaultRoles {
+		for _, p := range rbac.AllPermissions {
+			want := http.StatusForbidden
+			if rbac.HasAll(role.Permissions, p) {
+				want = http.StatusNoContent
+			}
+			if got := check(newPermissionRouter(roles, i+1, p)); got != want {
+				t.Errorf("%s requiring %s: got %d, want %d", role.Name, p, got, want)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		userID   int
+		required []rbac.Permission
+		want     int
+	}{
+		{"anonymous", 0, []rbac.Permission{rbac.HomeWrite}, http.StatusUnauthorized},
+		{"user without roles", 99, []rbac.Permission{rbac.HomeWrite}, http.StatusForbidden},
+		{"all of several", 3, []rbac.Permission{rbac.HomeWrite, rbac.ProjectsWrite}, http.StatusNoContent},
+		{"one of several missing", 3, []rbac.Permission{rbac.HomeWrite, rbac.ContactsRead}, http.StatusForbidden},
+		{"nothing required", 99, nil, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if got := check(newPermissionRouter(roles, tt.userID, tt.required...)); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequirePermissionSeesRoleChanges(t *testing.T) {
+	roles := rbac.NewMemoryStore()
+	r := newPermissionRouter(roles, 1, rbac.ContactsRead)
+
+	if got := check(r); got != http.StatusForbidden {
+		t.Fatalf("before the role: got %d, want 403", got)
+	}
+	if err := roles.SetUserRoles(context.Background(), 1, []string{"admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := check(r); got != http.StatusNoContent {
+		t.Errorf("after the role: got %d, want 204", got)
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"portfolio/problem"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDPattern limits IDs taken from clients or proxies, so they are safe to log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an ID, sent back in X-Request-ID and in problem responses.
+// An ID set by a proxy in X-Request-ID is kept, so logs can be matched across services.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			b := make([]byte, 8)
+			if _, err := rand.Read(b); err != nil {
+				c.Next()
+				return
+			}
+			id = hex.EncodeToString(b)
+		}
+
+		c.Set(problem.RequestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits for the outbox listing
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// Handler serves the outbox admin endpoints using a Store
+type Handler struct {
+	store Store
+}
+
+// NewHandler creates outbox handlers backed by the given store
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// GetEntries lists outbox entries by ?status= (dead by default, or pending or sent), newest first
+func (h *Handler) GetEntries(c *gin.Context) {
+	status := c.DefaultQuery("status", StatusDead)
+	if status != StatusPending && status != StatusSent && status != StatusDead {
+		problem.Respond(c, problem.BadRequest("Invalid status (use pending, sent or dead)"))
+		return
+	}
+
+	limit := defaultListLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			problem.Respond(c, problem.BadRequest("Invalid limit"))
+			return
+		}
+		limit = min(n, maxListLimit)
+	}
+
+	entries, err := h.store.List(c.Request.Context(), status, limit)
+	if err != nil {
+		fmt.Println("Outbox list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// RequeueEntry gives a dead entry a fresh set of delivery attempts, starting right away
+func (h *Handler) RequeueEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	err = h.store.Requeue(c.Request.Context(), id, time.Now().UTC())
+	switch {
+	case errors.Is(err, ErrNotFound):
+		problem.Respond(c, problem.NotFound("Outbox entry not found"))
+		return
+	case errors.Is(err, ErrNotDead):
+		problem.Respond(c, problem.Conflict("Only dead entries can be requeued"))
+		return
+	case err != nil:
+		fmt.Println("Outbox requeue error:", err)
+		problem.Respond(c, problem.Internal("Entry could not be requeued"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Outbox entry %d requeued", id)})
+}
@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry // Kept in insertion order, which is also ID order
+	nextID  int
+}
+
+// NewMemoryStore creates an empty in-memory outbox
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// Enqueue inserts a pending entry and sets its ID
+func (s *MemoryStore) Enqueue(ctx context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.nextID
+	s.nextID++
+	s.entries = append(s.entries, *e)
+	return nil
+}
+
+// Claim takes up to limit due entries, oldest due first, and hides them until now+lease
+func (s *MemoryStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []int{}
+	for i, e := range s.entries {
+		if e.Status == StatusPending && !e.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	sort.SliceStable(due, func(a, b int) bool {
+		return s.entries[due[a]].NextAttemptAt.Before(s.entries[due[b]].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	sort.Ints(due)
+
+	claimed := make([]Entry, 0, len(due))
+	for _, i := range due {
+		s.entries[i].Attempts++
+		s.entries[i].NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, s.entries[i])
+	}
+	return claimed, nil
+}
+
+// MarkSent records a successful delivery
+func (s *MemoryStore) MarkSent(ctx context.Context, id int, providerID string, at time.Time) error {
+	return s.update(id, func(e *Entry) {
+		e.Status = StatusSent
+		e.ProviderID = providerID
+		e.SentAt = &at
+	})
+}
+
+// Retry records a failed attempt and schedules the next one
+func (s *MemoryStore) Retry(ctx context.Context, id int, lastError string, at time.Time) error {
+	return s.update(id, func(e *Entry) {
+		e.LastError = lastError
+		e.NextAttemptAt = at
+	})
+}
+
+// MarkDead records the last failure and stops retrying
+func (s *MemoryStore) MarkDead(ctx context.Context, id int, lastError string) error {
+	return s.update(id, func(e *Entry) {
+		e.Status = StatusDead
+		e.LastError = lastError
+	})
+}
+
+// update applies change to the entry with the given ID
+func (s *MemoryStore) update(id int, change func(e *Entry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.index(id); i >= 0 {
+		change(&s.entries[i])
+		return nil
+	}
+	return ErrNotFound
+}
+
+// Get returns a single entry by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.entries[i], nil
+	}
+	return Entry{}, ErrNotFound
+}
+
+// List returns the newest entries with the given status
+func (s *MemoryStore) List(ctx context.Context, status string, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := []Entry{}
+	for i := len(s.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if s.entries[i].Status == status {
+			entries = append(entries, s.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+// Requeue moves a dead entry back to pending, due at the given time, with its attempts reset
+func (s *MemoryStore) Requeue(ctx context.Context, id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if s.entries[i].Status != StatusDead {
+		return ErrNotDead
+	}
+	s.entries[i].Status = StatusPending
+	s.entries[i].Attempts = 0
+	s.entries[i].NextAttemptAt = at
+	return nil
+}
+
+// index returns the slice position of the entry with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, e := range s.entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,28 @@
+package outbox
+
+import (
+	"context"
+	"portfolio/mail"
+	"strconv"
+)
+
+// Queue is a mail.Sender that writes messages to the outbox; the Worker delivers them.
+// Giving it to mail.NewMailer makes every email durable and keeps slow providers off
+// the request path.
+type Queue struct {
+	store Store
+}
+
+// NewQueue creates a sender that queues into store
+func NewQueue(store Store) *Queue {
+	return &Queue{store: store}
+}
+
+// Send queues the message and returns its outbox ID
+func (q *Queue) Send(ctx context.Context, msg mail.Message) (string, error) {
+	e := NewEntry(msg)
+	if err := q.store.Enqueue(ctx, &e); err != nil {
+		return "", err
+	}
+	return "outbox:" + strconv.Itoa(e.ID), nil
+}
@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"portfolio/db"
+	"sort"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates an outbox store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+const entryColumns = "id, payload, recipients, subject, status, attempts, next_attempt_at, COALESCE(last_error, ''), COALESCE(provider_id, ''), created_at, sent_at"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// scanEntry reads one outbox row selected with entryColumns
+func scanEntry(row rowScanner) (Entry, error) {
+	var e Entry
+	var payload string
+	var sentAt sql.NullTime
+	err := row.Scan(&e.ID, &payload, &e.Recipients, &e.Subject, &e.Status, &e.Attempts,
+		&e.NextAttemptAt, &e.LastError, &e.ProviderID, &e.CreatedAt, &sentAt)
+	if err != nil {
+		return e, err
+	}
+	if sentAt.Valid {
+		e.SentAt = &sentAt.Time
+	}
+	return e, json.Unmarshal([]byte(payload), &e.Message)
+}
+
+// EnqueueTx inserts a pending entry inside tx, so the email is only queued if the
+// rest of the transaction, e.g. saving the contact message it is about, commits
+func EnqueueTx(ctx context.Context, tx *sql.Tx, e *Entry) error {
+	return insert(ctx, tx, e)
+}
+
+// insert adds e to the outbox and sets its ID
+func insert(ctx context.Context, q rowQuerier, e *Entry) error {
+	payload, err := json.Marshal(e.Message)
+	if err != nil {
+		return err
+	}
+	return q.QueryRowContext(ctx,
+		`INSERT INTO mail_outbox (payload, recipients, subject, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		string(payload), e.Recipients, e.Subject, e.Status, e.Attempts, e.NextAttemptAt, e.CreatedAt).Scan(&e.ID)
+}
+
+// Enqueue inserts a pending entry and sets its ID
+func (s *SQLStore) Enqueue(ctx context.Context, e *Entry) error {
+	return insert(ctx, s.conn, e)
+}
+
+// Claim takes due entries in one statement. On PostgreSQL, SKIP LOCKED lets several instances
+// claim at the same time without taking the same entry; SQLite only has a single writer anyway.
+// All timestamps are written by Go in UTC, so SQLite's text comparison orders them correctly.
+func (s *SQLStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Entry, error) {
+	lock := ""
+	if s.conn.Dialect == db.Postgres {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `UPDATE mail_outbox SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (SELECT id FROM mail_outbox WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at, id LIMIT $4`+lock+`)
+		RETURNING `+entryColumns,
+		now.Add(lease), StatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// MarkSent records a successful delivery
+func (s *SQLStore) MarkSent(ctx context.Context, id int, providerID string, at time.Time) error {
+	return s.update(ctx, "UPDATE mail_outbox SET status=$1, provider_id=$2, sent_at=$3 WHERE id=$4",
+		StatusSent, providerID, at, id)
+}
+
+// Retry records a failed attempt and schedules the next one
+func (s *SQLStore) Retry(ctx context.Context, id int, lastError string, at time.Time) error {
+	return s.update(ctx, "UPDATE mail_outbox SET last_error=$1, next_attempt_at=$2 WHERE id=$3",
+		lastError, at, id)
+}
+
+// MarkDead records the last failure and stops retrying
+func (s *SQLStore) MarkDead(ctx context.Context, id int, lastError string) error {
+	return s.update(ctx, "UPDATE mail_outbox SET status=$1, last_error=$2 WHERE id=$3",
+		StatusDead, lastError, id)
+}
+
+// update runs a statement that changes one entry, returning ErrNotFound when it matched none
+func (s *SQLStore) update(ctx context.Context, query string, args ...any) error {
+	result, err := s.conn.ExecContext(ctx, query, args...)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Get returns a single entry by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (Entry, error) {
+	e, err := scanEntry(s.conn.QueryRowContext(ctx, "SELECT "+entryColumns+" FROM mail_outbox WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, ErrNotFound
+	}
+	return e, err
+}
+
+// List returns the newest entries with the given status
+func (s *SQLStore) List(ctx context.Context, status string, limit int) ([]Entry, error) {
+	rows, err := s.conn.QueryContext(ctx,
+		"SELECT "+entryColumns+" FROM mail_outbox WHERE status=$1 ORDER BY id DESC LIMIT $2", status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Requeue moves a dead entry back to pending, due at the given time, with its attempts reset
+func (s *SQLStore) Requeue(ctx context.Context, id int, at time.Time) error {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE mail_outbox SET status=$1, attempts=0, next_attempt_at=$2 WHERE id=$3 AND status=$4",
+		StatusPending, at, id, StatusDead)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil
+	}
+
+	// Nothing changed: tell a missing entry from one that is not dead
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return ErrNotDead
+}
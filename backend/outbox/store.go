@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"portfolio/mail"
+	"strings"
+	"time"
+)
+
+// Delivery states of an outbox entry
+const (
+	StatusPending = "pending" // Waiting for its next delivery attempt
+	StatusSent    = "sent"    // Accepted by the mail driver
+	StatusDead    = "dead"    // Gave up after the maximum number of attempts
+)
+
+// Outbox errors
+var (
+	ErrNotFound = errors.New("outbox entry not found")
+	ErrNotDead  = errors.New("outbox entry is not dead")
+)
+
+// Entry is an email waiting for delivery, or the record of one that was delivered or given up on
+type Entry struct {
+	ID            int          `json:"id"`
+	Message       mail.Message `json:"-"`
+	Recipients    string       `json:"recipients"` // The To addresses, comma separated, for listings
+	Subject       string       `json:"subject"`
+	Status        string       `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty"`
+	ProviderID    string       `json:"provider_id,omitempty"` // ID returned by the mail driver
+	CreatedAt     time.Time    `json:"created_at"`
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+}
+
+// NewEntry prepares a pending entry for msg that is due right away.
+// The message gets its Message-ID here so retries never produce two different emails.
+func NewEntry(msg mail.Message) Entry {
+	headers := make(map[string]string, len(msg.Headers)+1)
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	if headers["Message-ID"] == "" {
+		headers["Message-ID"] = mail.NewMessageID(msg.From)
+	}
+	msg.Headers = headers
+
+	now := time.Now().UTC()
+	return Entry{
+		Message:       msg,
+		Recipients:    strings.Join(msg.To, ", "),
+		Subject:       msg.Subject,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// Store persists the outbox
+type Store interface {
+	Enqueue(ctx context.Context, e *Entry) error // Inserts a pending entry and sets its ID
+	// Claim takes up to limit pending entries due at now, counts an attempt for each and hides them
+	// from other workers until now+lease, so a crashed worker's entries are retried after the lease
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Entry, error)
+	MarkSent(ctx context.Context, id int, providerID string, at time.Time) error
+	Retry(ctx context.Context, id int, lastError string, at time.Time) error // Schedules the next attempt
+	MarkDead(ctx context.Context, id int, lastError string) error
+	Get(ctx context.Context, id int) (Entry, error)
+	List(ctx context.Context, status string, limit int) ([]Entry, error) // Newest first
+	Requeue(ctx context.Context, id int, at time.Time) error             // Moves a dead entry back to pending with no attempts
+}
@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"os"
+	"portfolio/mail"
+	"strconv"
+	"time"
+)
+
+// Delivery limits that do not need tuning per deployment
+const (
+	batchSize   = 20
+	sendTimeout = time.Minute
+	claimLease  = 2 * sendTimeout // Claimed entries are retried after this if the worker dies
+)
+
+// Config controls how often the worker polls and how it retries failed deliveries
+type Config struct {
+	MaxAttempts   int           // Attempts before an entry is marked dead
+	RetryDelay    time.Duration // Wait before the first retry, doubled after every further failure
+	MaxRetryDelay time.Duration // Upper bound for the wait between retries
+	PollInterval  time.Duration // How often the outbox is checked for due entries
+}
+
+// ConfigFromEnv reads OUTBOX_MAX_ATTEMPTS (default 8), OUTBOX_RETRY_DELAY (default 1m),
+// OUTBOX_MAX_RETRY_DELAY (default 1h) and OUTBOX_POLL_INTERVAL (default 5s)
+func ConfigFromEnv() Config {
+	return Config{
+		MaxAttempts:   envInt("OUTBOX_MAX_ATTEMPTS", 8),
+		RetryDelay:    envDuration("OUTBOX_RETRY_DELAY", time.Minute),
+		MaxRetryDelay: envDuration("OUTBOX_MAX_RETRY_DELAY", time.Hour),
+		PollInterval:  envDuration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+	}
+}
+
+// envInt reads a positive integer, falling back to the default when unset or invalid
+func envInt(key string, defaultValue int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return defaultValue
+}
+
+// envDuration reads a positive duration such as "30s", falling back to the default when unset or invalid
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
+
+// Worker delivers queued emails in the background
+type Worker struct {
+	store  Store
+	sender mail.Sender
+	config Config
+}
+
+// NewWorker creates a worker that delivers entries of store through sender
+func NewWorker(store Store, sender mail.Sender, config Config) *Worker {
+	return &Worker{store: store, sender: sender, config: config}
+}
+
+// Run delivers due entries until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more may be waiting, so keep going without sleeping
+		if n, err := w.DeliverDue(ctx); err != nil {
+			log.Printf("Outbox error: %v", err)
+		} else if n == batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes one delivery attempt for each due entry, up to one batch, and
+// returns how many entries it tried
+func (w *Worker) DeliverDue(ctx context.Context) (int, error) {
+	entries, err := w.store.Claim(ctx, time.Now().UTC(), claimLease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range entries {
+		w.deliver(ctx, e)
+	}
+	return len(entries), nil
+}
+
+// deliver sends one claimed entry and records the outcome
+func (w *Worker) deliver(ctx context.Context, e Entry) {
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	providerID, err := w.sender.Send(sendCtx, e.Message)
+	cancel()
+
+	var storeErr error
+	switch {
+	case err == nil:
+		log.Printf("Outbox: mail %d to %s sent. ID: %s", e.ID, e.Recipients, providerID)
+		storeErr = w.store.MarkSent(ctx, e.ID, providerID, time.Now().UTC())
+	case e.Attempts >= w.config.MaxAttempts:
+		log.Printf("Outbox: giving up on mail %d to %s after %d attempts: %v", e.ID, e.Recipients, e.Attempts, err)
+		storeErr = w.store.MarkDead(ctx, e.ID, err.Error())
+	default:
+		retryAt := time.Now().UTC().Add(w.backoff(e.Attempts))
+		log.Printf("Outbox: attempt %d of mail %d to %s failed, retrying at %s: %v",
+			e.Attempts, e.ID, e.Recipients, retryAt.Format(time.RFC3339), err)
+		storeErr = w.store.Retry(ctx, e.ID, err.Error(), retryAt)
+	}
+	if storeErr != nil {
+		log.Printf("Outbox: could not record the result for mail %d: %v", e.ID, storeErr)
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts:
+// RetryDelay, then twice as long after each further failure, up to MaxRetryDelay
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.config.RetryDelay
+	for i := 1; i < attempts && delay < w.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, w.config.MaxRetryDelay)
+}
@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"portfolio/mail"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySender fails the first failures sends, then accepts every message
+type flakySender struct {
+	mu       sync.Mutex
+	failures int
+	sent     []mail.Message // Every message it was given, failed or not
+}
+
+func (s *flakySender) Send(ctx context.Context, msg mail.Message) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, msg)
+	if len(s.sent) <= s.failures {
+		return "", errors.New("provider unavailable")
+	}
+	return "provider-1", nil
+}
+
+var testConfig = Config{MaxAttempts: 3, RetryDelay: time.Minute, MaxRetryDelay: 10 * time.Minute, PollInterval: time.Second}
+
+// enqueue adds a pending message for ann@example.com
+func enqueue(t *testing.T, store Store) Entry {
+	t.Helper()
+	e := NewEntry(mail.Message{From: "from@example.com", To: []string{"ann@example.com"}, Subject: "Hello", Text: "Hi"})
+	if err := store.Enqueue(context.Background(), &e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// deliverNow makes the entry due and runs one delivery round
+func deliverNow(t *testing.T, w *Worker, store Store, id int) Entry {
+	t.Helper()
+	ctx := context.Background()
+	e, err := store.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Retry(ctx, id, e.LastError, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := w.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue = %d, %v; want 1 entry", n, err)
+	}
+	e, err = store.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestBackoff(t *testing.T) {
+	w := NewWorker(NewMemoryStore(), &flakySender{}, testConfig)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{40, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := w.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	sender := &flakySender{failures: 2}
+	w := NewWorker(store, sender, testConfig)
+	id := enqueue(t, store).ID
+
+	before := time.Now().UTC()
+	if n, err := w.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue = %d, %v; want 1 entry", n, err)
+	}
+	e, _ := store.Get(ctx, id)
+	if e.Status != StatusPending || e.Attempts != 1 || e.LastError != "provider unavailable" {
+		t.Errorf("after the first failure: %+v, want pending with 1 attempt and the error", e)
+	}
+	if wait := e.NextAttemptAt.Sub(before); wait < testConfig.RetryDelay || wait > testConfig.RetryDelay+time.Second {
+		t.Errorf("next attempt in %v, want %v", wait, testConfig.RetryDelay)
+	}
+	if n, _ := w.DeliverDue(ctx); n != 0 {
+		t.Errorf("DeliverDue before the retry is due tried %d entries, want 0", n)
+	}
+
+	before = time.Now().UTC()
+	e = deliverNow(t, w, store, id)
+	if wait := e.NextAttemptAt.Sub(before); e.Attempts != 2 || wait < 2*testConfig.RetryDelay || wait > 2*testConfig.RetryDelay+time.Second {
+		t.Errorf("after the second failure: %d attempts, next in %v; want 2 and %v", e.Attempts, wait, 2*testConfig.RetryDelay)
+	}
+
+	e = deliverNow(t, w, store, id)
+	if e.Status != StatusSent || e.ProviderID != "provider-1" || e.SentAt == nil || e.Attempts != 3 {
+		t.Errorf("after the third attempt: %+v, want sent by provider-1", e)
+	}
+
+	// Every attempt sent the same email
+	for _, msg := range sender.sent[1:] {
+		if msg.Headers["Message-ID"] != sender.sent[0].Headers["Message-ID"] {
+			t.Errorf("Message-ID changed between attempts: %q, %q", sender.sent[0].Headers["Message-ID"], msg.Headers["Message-ID"])
+		}
+	}
+}
+
+func TestWorkerMarksDeadAndRequeue(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	sender := &flakySender{failures: testConfig.MaxAttempts}
+	w := NewWorker(store, sender, testConfig)
+	id := enqueue(t, store).ID
+
+	var e Entry
+	for i := 0; i < testConfig.MaxAttempts; i++ {
+		e = deliverNow(t, w, store, id)
+	}
+	if e.Status != StatusDead || e.Attempts != testConfig.MaxAttempts || e.LastError == "" {
+		t.Fatalf("after %d failures: %+v, want dead with the last error", testConfig.MaxAttempts, e)
+	}
+	if dead, _ := store.List(ctx, StatusDead, 10); len(dead) != 1 || dead[0].ID != id {
+		t.Errorf("dead entries = %+v, want the entry", dead)
+	}
+
+	// A dead entry is not tried again until it is requeued
+	if err := store.Retry(ctx, id, e.LastError, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := w.DeliverDue(ctx); n != 0 || len(sender.sent) != testConfig.MaxAttempts {
+		t.Errorf("dead entry was tried again: %d entries, %d sends", n, len(sender.sent))
+	}
+
+	if err := store.Requeue(ctx, id, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := store.Get(ctx, id); e.Status != StatusPending || e.Attempts != 0 {
+		t.Errorf("after Requeue: %+v, want pending with no attempts", e)
+	}
+	if n, err := w.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue after Requeue = %d, %v; want 1 entry", n, err)
+	}
+	if e, _ := store.Get(ctx, id); e.Status != StatusSent || e.Attempts != 1 {
+		t.Errorf("after the requeued attempt: %+v, want sent after 1 attempt", e)
+	}
+
+	if err := store.Requeue(ctx, id, time.Now().UTC()); !errors.Is(err, ErrNotDead) {
+		t.Errorf("Requeue of a sent entry = %v, want ErrNotDead", err)
+	}
+	if err := store.Requeue(ctx, 99, time.Now().UTC()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Requeue of a missing entry = %v, want ErrNotFound", err)
+	}
+}
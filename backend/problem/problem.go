@@ -0,0 +1,185 @@
+// Package problem writes API errors as RFC 7807 problem details.
+//
+// Handlers return one of the typed errors below through Respond, which answers with
+// Content-Type application/problem+json:
+//
+//	{
+//	  "type": "urn:portfolio:problem:not-found",
+//	  "title": "Not Found",
+//	  "status": 404,
+//	  "detail": "Contact not found",
+//	  "instance": "/api/admin/contact/42",
+//	  "request_id": "4f1c2a9e0b7d3e65"
+//	}
+//
+// "type" is stable for each kind of error, so clients can branch on it; "detail" is for people.
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// RequestIDKey is the gin context key holding the request ID, set by middleware.RequestID
+const RequestIDKey = "request_id"
+
+// typePrefix starts every problem type URI
+const typePrefix = "urn:portfolio:problem:"
+
+// Problem types
+const (
+	TypeBadRequest      = typePrefix + "bad-request"
+	TypeUnauthorized    = typePrefix + "unauthorized"
+	TypeForbidden       = typePrefix + "forbidden"
+	TypeNotFound        = typePrefix + "not-found"
+	TypeConflict        = typePrefix + "conflict"
+	TypePayloadTooLarge = typePrefix + "payload-too-large"
+	TypeValidation      = typePrefix + "validation"    // Fields break their rules; see "errors"
+	TypeUnprocessable   = typePrefix + "unprocessable" // Well-formed, but cannot be carried out
+	TypeTooManyRequests = typePrefix + "too-many-requests"
+	TypeInternal        = typePrefix + "internal"
+)
+
+// FieldError is one broken rule of a request body
+type FieldError struct {
+	Field  string `json:"field"`  // JSON name of the field, dotted for nested fields
+	Reason string `json:"reason"` // Human readable, e.g. "is required"
+}
+
+// Problem is the response body
+type Problem struct {
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Status     int          `json:"status"`
+	Detail     string       `json:"detail,omitempty"`
+	Instance   string       `json:"instance,omitempty"`    // Path of the request
+	RequestID  string       `json:"request_id,omitempty"`  // Also sent as X-Request-ID; quote it in bug reports
+	Errors     []FieldError `json:"errors,omitempty"`      // Validation problems only
+	RetryAfter int          `json:"retry_after,omitempty"` // Seconds, for too-many-requests
+}
+
+// Error is an API error that Respond turns into a Problem
+type Error struct {
+	Status     int
+	Type       string
+	Detail     string
+	Fields     []FieldError
+	RetryAfter time.Duration
+}
+
+// Error returns the detail, so an Error can travel as a plain error
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func newError(status int, typ, detail string) *Error {
+	return &Error{Status: status, Type: typ, Detail: detail}
+}
+
+// BadRequest is for requests that cannot be understood, such as malformed JSON or IDs
+func BadRequest(detail string) *Error {
+	return newError(http.StatusBadRequest, TypeBadRequest, detail)
+}
+
+// Unauthorized is for missing or invalid credentials
+func Unauthorized(detail string) *Error {
+	return newError(http.StatusUnauthorized, TypeUnauthorized, detail)
+}
+
+// Forbidden is for authenticated users without the needed permission
+func Forbidden(detail string) *Error {
+	return newError(http.StatusForbidden, TypeForbidden, detail)
+}
+
+// NotFound is for resources that do not exist
+func NotFound(detail string) *Error {
+	return newError(http.StatusNotFound, TypeNotFound, detail)
+}
+
+// Conflict is for requests that clash with the current state, such as duplicates
+func Conflict(detail string) *Error {
+	return newError(http.StatusConflict, TypeConflict, detail)
+}
+
+// PayloadTooLarge is for request bodies over the limit
+func PayloadTooLarge(detail string) *Error {
+	return newError(http.StatusRequestEntityTooLarge, TypePayloadTooLarge, detail)
+}
+
+// Validation lists the fields of a request body that break their rules
+func Validation(fields ...FieldError) *Error {
+	e := newError(http.StatusUnprocessableEntity, TypeValidation, "One or more fields are invalid")
+	e.Fields = fields
+	return e
+}
+
+// Unprocessable is for valid requests that cannot be carried out
+func Unprocessable(detail string) *Error {
+	return newError(http.StatusUnprocessableEntity, TypeUnprocessable, detail)
+}
+
+// TooManyRequests asks the client to wait retryAfter before trying again
+func TooManyRequests(detail string, retryAfter time.Duration) *Error {
+	e := newError(http.StatusTooManyRequests, TypeTooManyRequests, detail)
+	e.RetryAfter = retryAfter
+	return e
+}
+
+// Internal is for failures on the server side; the cause is logged by the caller, not sent
+func Internal(detail string) *Error {
+	return newError(http.StatusInternalServerError, TypeInternal, detail)
+}
+
+// Respond writes err as a problem response and aborts the handler chain. Errors that are not
+// an *Error are answered as internal errors without their message.
+func Respond(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = Internal("An unexpected error occurred")
+	}
+
+	p := Problem{
+		Type:      e.Type,
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: RequestID(c),
+		Errors:    e.Fields,
+	}
+	if e.Status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", "Bearer")
+	}
+	if e.RetryAfter > 0 {
+		// Round up so clients never retry a moment too early
+		p.RetryAfter = int((e.RetryAfter + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(p.RetryAfter))
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(e.Status, p)
+}
+
+// RequestID returns the ID of the current request, or "" outside middleware.RequestID
+func RequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// Recovery turns panics into internal problem responses; gin logs the panic and stack
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		Respond(c, Internal("An unexpected error occurred"))
+	})
+}
+
+// NoRoute answers requests for unknown API paths
+func NoRoute(c *gin.Context) {
+	Respond(c, NotFound("No endpoint at "+c.Request.Method+" "+c.Request.URL.Path))
+}
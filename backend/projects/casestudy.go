@@ -0,0 +1,72 @@
+package projects
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// CaseStudy is the long-form content of a project page
+type CaseStudy struct {
+	Body     string         `json:"body" binding:"max=100000"`     // Markdown, rendered by the frontend
+	Role     string         `json:"role" binding:"max=200"`        // What the author did on the project
+	Duration string         `json:"duration" binding:"max=100"`    // Free text, such as "3 months"
+	Client   string         `json:"client" binding:"max=200"`      // Who the project was for
+	Gallery  []GalleryImage `json:"gallery" binding:"max=50,dive"` // Screenshots, in display order
+	Metrics  []Metric       `json:"metrics" binding:"max=20,dive"` // Outcomes, such as "Load time" / "-60%"
+}
+
+// GalleryImage is one image of a case study gallery
+type GalleryImage struct {
+	URL     string `json:"url" binding:"required,http_url,max=2048"`
+	Caption string `json:"caption" binding:"max=300"`
+	Alt     string `json:"alt" binding:"max=300"` // Text alternative for screen readers
+}
+
+// Metric is one outcome of a project
+type Metric struct {
+	Label string `json:"label" binding:"notblank,max=100"`
+	Value string `json:"value" binding:"notblank,max=100"`
+}
+
+// clone returns a copy of cs that shares no slices with it, with empty lists instead of nil
+func (cs *CaseStudy) clone() *CaseStudy {
+	if cs == nil {
+		return &CaseStudy{Gallery: []GalleryImage{}, Metrics: []Metric{}}
+	}
+	out := *cs
+	out.Gallery = slices.Clone(cs.Gallery)
+	out.Metrics = slices.Clone(cs.Metrics)
+	if out.Gallery == nil {
+		out.Gallery = []GalleryImage{}
+	}
+	if out.Metrics == nil {
+		out.Metrics = []Metric{}
+	}
+	return &out
+}
+
+// encodeLists returns the gallery and metrics of cs as the JSON stored in the database
+func (cs *CaseStudy) encodeLists() (gallery, metrics string, err error) {
+	cs = cs.clone()
+	g, err := json.Marshal(cs.Gallery)
+	if err != nil {
+		return "", "", err
+	}
+	m, err := json.Marshal(cs.Metrics)
+	if err != nil {
+		return "", "", err
+	}
+	return string(g), string(m), nil
+}
+
+// decodeLists reads the gallery and metrics of cs from the JSON stored in the database
+func (cs *CaseStudy) decodeLists(gallery, metrics string) error {
+	if err := json.Unmarshal([]byte(gallery), &cs.Gallery); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(metrics), &cs.Metrics); err != nil {
+		return err
+	}
+	*cs = *cs.clone()
+	return nil
+}
@@ -0,0 +1,280 @@
+package projects
+
+import (
+	"context"
+	"maps"
+	"portfolio/listing"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements Store in process memory, for local development and tests
+type MemoryStore struct {
+	mu        sync.RWMutex
+	projects  []Project // Kept in insertion (ID) order
+	nextID    int
+	redirects map[string]int // Old slugs and the projects they belong to
+}
+
+// NewMemoryStore creates an empty in-memory project store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1, redirects: map[string]int{}}
+}
+
+// compareProjects are the fields a project list can be sorted by; List adds relevance for searches
+var compareProjects = map[string]func(a, b Project) int{
+	"id":         func(a, b Project) int { return 0 }, // listing.Sort breaks ties by ID
+	"name":       func(a, b Project) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"created_at": func(a, b Project) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b Project) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+	"position":   func(a, b Project) int { return a.Position - b.Position },
+}
+
+// List returns the page of projects matching filter and the number of matches; a search
+// requires every word to appear in the text, like the SQLite store
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Project, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	terms := searchTerms(filter.Search)
+	projects := []Project{}
+	for _, p := range s.projects {
+		switch {
+		case filter.IDs != nil && !slices.Contains(filter.IDs, p.ID):
+		case filter.Status != "" && p.Status != filter.Status:
+		case filter.LiveAt != nil && !p.IsLive(*filter.LiveAt):
+		case filter.Featured != nil && p.Featured != *filter.Featured:
+		case filter.From != nil && p.CreatedAt.Before(*filter.From):
+		case filter.To != nil && !p.CreatedAt.Before(*filter.To):
+		case !matchesSearch(p, terms):
+		default:
+			if len(terms) > 0 {
+				p.Snippet = snippet(p, terms)
+			}
+			p.CaseStudy = p.CaseStudy.clone()
+			projects = append(projects, p)
+		}
+	}
+
+	compare := maps.Clone(compareProjects)
+	if len(terms) > 0 {
+		// Matches in the name come first
+		inName := func(p Project) int {
+			if strings.Contains(strings.ToLower(p.Name), terms[0]) {
+				return 0
+			}
+			return 1
+		}
+		compare["relevance"] = func(a, b Project) int { return inName(a) - inName(b) }
+	}
+	listing.Sort(projects, filter.Params, compare, func(p Project) int { return p.ID })
+	return listing.Window(projects, filter.Params), len(projects), nil
+}
+
+// Get returns a single project by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.copyOf(i), nil
+	}
+	return Project{}, ErrNotFound
+}
+
+// GetBySlug returns the project with the given current slug
+func (s *MemoryStore) GetBySlug(ctx context.Context, slug string) (Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.slugOwner(slug); i >= 0 {
+		return s.copyOf(i), nil
+	}
+	return Project{}, ErrNotFound
+}
+
+// GetByOldSlug returns the project that used to have the given slug
+func (s *MemoryStore) GetByOldSlug(ctx context.Context, slug string) (Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id, ok := s.redirects[slug]; ok {
+		return s.copyOf(s.index(id)), nil
+	}
+	return Project{}, ErrNotFound
+}
+
+// Create inserts a new project ahead of the others and stores the generated ID, slug, position
+// and times in p
+func (s *MemoryStore) Create(ctx context.Context, p *Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.Slug == "" {
+		base := Slugify(p.Name)
+		p.Slug = base
+		for n := 2; s.slugUsed(p.Slug); n++ {
+			p.Slug = slugCandidate(base, n)
+		}
+	} else if s.slugOwner(p.Slug) >= 0 {
+		return ErrSlugTaken
+	}
+	delete(s.redirects, p.Slug)
+	p.CaseStudy = p.CaseStudy.clone()
+
+	p.Position = 0 // Like the SQL store: one before the first project
+	if len(s.projects) > 0 {
+		first := slices.MinFunc(s.projects, func(a, b Project) int { return a.Position - b.Position })
+		p.Position = first.Position - 1
+	}
+	p.ID = s.nextID
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = p.CreatedAt
+	s.nextID++
+	s.projects = append(s.projects, *p)
+	s.projects[len(s.projects)-1].CaseStudy = p.CaseStudy.clone()
+	return nil
+}
+
+// Update saves the fields of the project with p.ID, except its position and featured flag, and
+// stamps the edit time
+func (s *MemoryStore) Update(ctx context.Context, p Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(p.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if current := s.projects[i].Slug; p.Slug != current {
+		if j := s.slugOwner(p.Slug); j >= 0 && j != i {
+			return ErrSlugTaken
+		}
+		delete(s.redirects, p.Slug)
+		s.redirects[current] = p.ID
+	}
+	p.CreatedAt = s.projects[i].CreatedAt
+	p.Position, p.Featured = s.projects[i].Position, s.projects[i].Featured
+	p.CaseStudy = p.CaseStudy.clone()
+	p.UpdatedAt = time.Now().UTC()
+	s.projects[i] = p
+	return nil
+}
+
+// Delete removes a project by ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.projects = append(s.projects[:i], s.projects[i+1:]...)
+	for slug, projectID := range s.redirects {
+		if projectID == id {
+			delete(s.redirects, slug)
+		}
+	}
+	return nil
+}
+
+// Reorder renumbers every project
+func (s *MemoryStore) Reorder(ctx context.Context, ids []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byPosition := slices.Clone(s.projects)
+	slices.SortStableFunc(byPosition, func(a, b Project) int { return a.Position - b.Position })
+	current := make([]int, len(byPosition))
+	for i, p := range byPosition {
+		current[i] = p.ID
+	}
+
+	if err := checkOrder(current, ids); err != nil {
+		return err
+	}
+	for i, id := range ids {
+		s.projects[s.index(id)].Position = i + 1
+	}
+	return nil
+}
+
+// SetFeatured sets the featured flag of a project unless that goes over the limit
+func (s *MemoryStore) SetFeatured(ctx context.Context, id int, featured bool, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if featured && !s.projects[i].Featured {
+		n := 0
+		for _, p := range s.projects {
+			if p.Featured {
+				n++
+			}
+		}
+		if n >= limit {
+			return ErrFeaturedLimit
+		}
+	}
+	s.projects[i].Featured = featured
+	return nil
+}
+
+// ApplySchedule publishes the drafts and archives the published projects whose time has come
+func (s *MemoryStore) ApplySchedule(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	for i := range s.projects {
+		p := &s.projects[i]
+		if p.Status == StatusDraft && p.PublishAt != nil && !p.PublishAt.After(now) {
+			p.Status, p.PublishAt = StatusPublished, nil
+			changed++
+		}
+		if p.Status == StatusPublished && p.UnpublishAt != nil && !p.UnpublishAt.After(now) {
+			p.Status, p.UnpublishAt = StatusArchived, nil
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// copyOf returns the project at slice position i with its own copy of the case study
+func (s *MemoryStore) copyOf(i int) Project {
+	p := s.projects[i]
+	p.CaseStudy = p.CaseStudy.clone()
+	return p
+}
+
+// slugOwner returns the slice position of the project with the given current slug, or -1
+func (s *MemoryStore) slugOwner(slug string) int {
+	for i, p := range s.projects {
+		if p.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// slugUsed reports whether slug is the current or an old slug of a project
+func (s *MemoryStore) slugUsed(slug string) bool {
+	_, old := s.redirects[slug]
+	return old || s.slugOwner(slug) >= 0
+}
+
+// index returns the slice position of the project with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, p := range s.projects {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
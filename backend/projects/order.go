@@ -0,0 +1,118 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"portfolio/problem"
+	"portfolio/validation"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderRequest is the body of PUT /api/admin/projects/order
+type OrderRequest struct {
+	IDs []int `json:"ids" binding:"required,min=1,max=1000"` // Projects in their new order, first to last
+}
+
+// FeaturedRequest is the body of PUT /api/admin/projects/:id/featured
+type FeaturedRequest struct {
+	Featured *bool `json:"featured" binding:"required"`
+}
+
+// FeaturedLimitFromEnv reads FEATURED_PROJECTS_LIMIT (default 3), how many projects can be
+// featured at once
+func FeaturedLimitFromEnv() int {
+	if n, err := strconv.Atoi(os.Getenv("FEATURED_PROJECTS_LIMIT")); err == nil && n > 0 {
+		return n
+	}
+	return 3
+}
+
+// checkOrder returns ErrNotFound if ids has an ID that is not in current, and
+// ErrIncompleteOrder unless ids lists every ID of current exactly once
+func checkOrder(current, ids []int) error {
+	for _, id := range ids {
+		if !slices.Contains(current, id) {
+			return ErrNotFound
+		}
+	}
+	if len(ids) != len(current) {
+		return ErrIncompleteOrder
+	}
+	for _, id := range current {
+		if !slices.Contains(ids, id) {
+			return ErrIncompleteOrder
+		}
+	}
+	return nil
+}
+
+// ReorderProjects sets the order of the portfolio. The list must name every project once;
+// otherwise nothing changes.
+func (h *Handler) ReorderProjects(c *gin.Context) {
+	var req OrderRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+	seen := map[int]bool{}
+	for _, id := range req.IDs {
+		if seen[id] {
+			validation.Fail(c, validation.FieldError{Field: "ids", Reason: fmt.Sprintf("lists project %d more than once", id)})
+			return
+		}
+		seen[id] = true
+	}
+
+	err := h.store.Reorder(c.Request.Context(), req.IDs)
+	if errors.Is(err, ErrNotFound) {
+		validation.Fail(c, validation.FieldError{Field: "ids", Reason: "includes a project that does not exist"})
+		return
+	}
+	if errors.Is(err, ErrIncompleteOrder) {
+		validation.Fail(c, validation.FieldError{Field: "ids", Reason: "must list every project"})
+		return
+	}
+	if err != nil {
+		fmt.Println("Reorder error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project order saved"})
+}
+
+// SetFeatured features a project on the home page, or takes it off, and returns the project
+func (h *Handler) SetFeatured(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	var req FeaturedRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.store.SetFeatured(ctx, id, *req.Featured, h.featuredLimit)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Project not found"))
+		return
+	}
+	if errors.Is(err, ErrFeaturedLimit) {
+		problem.Respond(c, problem.Conflict(fmt.Sprintf("At most %d projects can be featured; unfeature another one first", h.featuredLimit)))
+		return
+	}
+	if err != nil {
+		fmt.Println("Featured update error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	h.GetProject(c)
+}
@@ -1,98 +1,258 @@
 package projects
 
 import (
-	"context" // Context for DB operations
-	"fmt"     // For printing to console
-	"strconv" // For string-int conversions
+	"errors"            // For matching store errors
+	"fmt"               // For printing to console
+	"net/http"          // For status codes
+	"portfolio/listing" // Pagination and sorting
+	"portfolio/problem"
+	"portfolio/tags"       // Technology tags
+	"portfolio/validation" // Request validation
+	"strconv"              // For string-int conversions
+	"time"                 // For timestamps
 
-	"github.com/gin-gonic/gin"        // Gin framework
-	"github.com/jackc/pgx/v5/pgxpool" // PostgreSQL pool library
+	"github.com/gin-gonic/gin" // Gin framework
 )
 
 // Project struct represents the data in the projects table
 type Project struct {
-	ID           int    `json:"id"`           // Shown as id in JSON
-	Name         string `json:"name"`         // Shown as name in JSON
-	Description  string `json:"description"`  // Shown as description in JSON
-	Message      string `json:"message"`      // Shown as message in JSON
-	ImageURL     string `json:"image_url"`    // New field for project image
-	Technologies string `json:"technologies"` // New field for tech stack
-	GithubURL    string `json:"github_url"`   // New field for GitHub link
-	DemoURL      string `json:"demo_url"`     // New field for demo link
+	ID           int    `json:"id"`                                               // Shown as id in JSON
+	Name         string `json:"name" binding:"notblank,max=200"`                  // Shown as name in JSON
+	Description  string `json:"description" binding:"max=2000"`                   // Shown as description in JSON
+	Message      string `json:"message" binding:"max=20000"`                      // Shown as message in JSON
+	ImageURL     string `json:"image_url" binding:"omitempty,http_url,max=2048"`  // New field for project image
+	Technologies string `json:"technologies" binding:"max=500"`                   // New field for tech stack
+	GithubURL    string `json:"github_url" binding:"omitempty,http_url,max=2048"` // New field for GitHub link
+	DemoURL      string `json:"demo_url" binding:"omitempty,http_url,max=2048"`   // New field for demo link
+	Slug         string `json:"slug" binding:"max=100"`                           // URL name; generated from the name when empty
+
+	CaseStudy *CaseStudy `json:"case_study"` // Project page content; left out of an update, it stays as it is
+
+	Status      string     `json:"status" binding:"omitempty,oneof=draft published archived"` // See StatusDraft and the others
+	PublishAt   *time.Time `json:"publish_at"`                                                // When a draft goes live
+	UnpublishAt *time.Time `json:"unpublish_at"`                                              // When a published project is archived
+
+	Position int  `json:"position"` // Place on the portfolio, lowest first; set with PUT /api/admin/projects/order
+	Featured bool `json:"featured"` // Shown on the home page; set with PUT /api/admin/projects/:id/featured
+
+	CreatedAt time.Time  `json:"created_at"`        // Set by the store
+	UpdatedAt time.Time  `json:"updated_at"`        // Set by the store on every update
+	Snippet   string     `json:"snippet,omitempty"` // HTML-escaped text around the matches of a ?q= search, matches in <mark>
+	Tags      []tags.Ref `json:"tags"`              // Canonical tags of Technologies, set by the handler
 }
 
-var Pool *pgxpool.Pool // Global database pool
+// Handler serves the project endpoints using a Store
+type Handler struct {
+	store         Store
+	tags          tags.Store
+	featuredLimit int // How many projects can be featured at once
+}
 
-func SetDB(pool *pgxpool.Pool) {
-	Pool = pool // Set the global pool
+// NewHandler creates project handlers backed by the given project and tag stores
+func NewHandler(store Store, tagStore tags.Store, featuredLimit int) *Handler {
+	return &Handler{store: store, tags: tagStore, featuredLimit: featuredLimit}
 }
 
 // DeleteProject Gin handler: For delete operation
-func DeleteProject(c *gin.Context) {
+func (h *Handler) DeleteProject(c *gin.Context) {
 	idStr := c.Param("id")         // Get :id parameter from URL
 	id, err := strconv.Atoi(idStr) // Convert string to int
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid ID"}) // Return JSON error for invalid ID
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // Return JSON error for invalid ID
 		return
 	}
 
-	_, err = Pool.Exec(context.Background(), "DELETE FROM projects WHERE id=$1", id) // Delete query
+	err = h.store.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Project not found")) // Nothing to delete
+		return
+	}
 	if err != nil {
 		fmt.Println("Delete error:", err)
-		c.JSON(500, gin.H{"error": "Delete operation failed"}) // Return JSON error if DB error
+		problem.Respond(c, problem.Internal("Delete operation failed")) // Return JSON error if DB error
 		return
 	}
+	if err := h.tags.SetProjectTags(c.Request.Context(), id, nil); err != nil {
+		fmt.Println("Tag unlink error:", err) // The project is gone either way
+	}
 
 	c.JSON(200, gin.H{"message": fmt.Sprintf("Project ID %d deleted successfully", id)}) // Success message as JSON
 }
 
-// GetProjects returns all projects as JSON
-func GetProjects(c *gin.Context) {
-	rows, err := Pool.Query(context.Background(),
-		"SELECT id, name, description, message, COALESCE(image_url, ''), COALESCE(technologies, ''), COALESCE(github_url, ''), COALESCE(demo_url, '') FROM projects ORDER BY id DESC") // Updated query with new fields
+// GetProjects returns a page of all projects as JSON for the admin panel, filtered by ?status=,
+// ?featured=, ?tag= (or ?technology=), ?from=, ?to= and the ?q= search
+func (h *Handler) GetProjects(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		problem.Respond(c, problem.BadRequest(err.Error())) // Return JSON error for invalid parameters
+		return
+	}
+	if status := c.Query("status"); status != "" {
+		if status != StatusDraft && status != StatusPublished && status != StatusArchived {
+			problem.Respond(c, problem.BadRequest(fmt.Sprintf("invalid status %q", status)))
+			return
+		}
+		filter.Status = status
+	}
+	h.list(c, filter)
+}
+
+// GetLiveProjects returns a page of the projects live on the portfolio as JSON, with the
+// filters of GetProjects except ?status=
+func (h *Handler) GetLiveProjects(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		problem.Respond(c, problem.BadRequest(err.Error())) // Return JSON error for invalid parameters
+		return
+	}
+	now := time.Now().UTC()
+	filter.LiveAt = &now
+	h.list(c, filter)
+}
+
+// list responds with the page of projects matching filter and the tag in the query
+func (h *Handler) list(c *gin.Context, filter Filter) {
+	ctx := c.Request.Context()
+	if err := h.tagFilter(c, &filter); err != nil {
+		fmt.Println("Tag filter error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+	projects, total, err := h.store.List(ctx, filter)
+	if err == nil {
+		err = h.withTags(ctx, projects)
+	}
 	if err != nil {
 		fmt.Println("Query error:", err)
-		c.JSON(500, gin.H{"error": "Data could not be retrieved"}) // Return JSON error if failed
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // Return JSON error if failed
+		return
+	}
+
+	listing.SetHeaders(c, filter.Params, total) // X-Total-Count and Link
+	c.JSON(200, projects)                       // Successfully return projects as JSON
+}
+
+// GetProject returns a single project by ID as JSON, whatever its status
+func (h *Handler) GetProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id")) // Get :id parameter from URL
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // Return JSON error for invalid ID
+		return
+	}
+
+	p, err := h.store.Get(c.Request.Context(), id)
+	h.respond(c, p, err)
+}
+
+// GetLiveProject returns a project live on the portfolio as JSON, by its slug or, for older
+// links, its ID. An old slug answers 301 with the URL of the current one.
+func (h *Handler) GetLiveProject(c *gin.Context) {
+	key := c.Param("slug")
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+
+	if id, err := strconv.Atoi(key); err == nil { // Slugs always contain a letter
+		p, err := h.store.Get(ctx, id)
+		if err == nil && !p.IsLive(now) {
+			err = ErrNotFound
+		}
+		h.respond(c, p, err)
 		return
 	}
-	defer rows.Close()
 
-	var projects []Project
-	for rows.Next() {
-		var p Project
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Message, &p.ImageURL, &p.Technologies, &p.GithubURL, &p.DemoURL); err != nil { // Updated scan with new fields
-			fmt.Println("Row scan error:", err)
-			c.JSON(500, gin.H{"error": "Could not read row"}) // Row read error
+	p, err := h.store.GetBySlug(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		p, err = h.store.GetByOldSlug(ctx, key)
+		if err == nil && p.IsLive(now) {
+			c.Redirect(http.StatusMovedPermanently, "/api/projects/"+p.Slug)
 			return
 		}
-		projects = append(projects, p)
+	}
+	if err == nil && !p.IsLive(now) {
+		err = ErrNotFound
+	}
+	h.respond(c, p, err)
+}
+
+// respond writes the project p with its tags, or the problem for err from loading it
+func (h *Handler) respond(c *gin.Context, p Project, err error) {
+	ctx := c.Request.Context()
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Project not found"))
+		return
+	}
+	if err == nil {
+		list := []Project{p}
+		err = h.withTags(ctx, list)
+		p = list[0]
+	}
+	if err != nil {
+		fmt.Println("Query error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved")) // Return JSON error if failed
+		return
 	}
 
-	fmt.Printf("Found %d projects\n", len(projects)) // Debug log
-	c.JSON(200, projects)                            // Successfully return projects as JSON
+	c.JSON(200, p)
 }
 
 // UpdateProject Gin handler for update operation
-func UpdateProject(c *gin.Context) {
+func (h *Handler) UpdateProject(c *gin.Context) {
 	idStr := c.Param("id")         // Get id from URL
 	id, err := strconv.Atoi(idStr) // Convert string to int
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid ID"}) // Return error if ID is invalid
+		problem.Respond(c, problem.BadRequest("Invalid ID")) // Return error if ID is invalid
 		return
 	}
 
 	var p Project
-	if err := c.ShouldBindJSON(&p); err != nil { // Bind JSON to Project struct
-		fmt.Println("JSON bind error:", err)
-		c.JSON(400, gin.H{"error": "Invalid data"}) // JSON parse error
+	if !validation.Bind(c, &p) { // Bind and validate JSON into Project struct
+		return
+	}
+	if !validTechnologies(c, p) || !validSlugField(c, p) {
+		return
+	}
+	p.ID = id // The URL decides which project is updated
+
+	ctx := c.Request.Context()
+	current, err := h.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Project not found")) // Nothing to update
+		return
+	}
+	if err != nil {
+		fmt.Println("Update error:", err)
+		problem.Respond(c, problem.Internal("Update failed")) // DB error
+		return
+	}
+	if p.Status == "" { // Clients that predate the status leave it and the schedule alone
+		p.Status, p.PublishAt, p.UnpublishAt = current.Status, current.PublishAt, current.UnpublishAt
+	}
+	if p.Slug == "" {
+		p.Slug = current.Slug
+	}
+	if p.CaseStudy == nil {
+		p.CaseStudy = current.CaseStudy
+	}
+	p.PublishAt, p.UnpublishAt = utcTime(p.PublishAt), utcTime(p.UnpublishAt)
+	if !validSchedule(c, p) {
 		return
 	}
 
-	sql := `UPDATE projects SET name=$1, description=$2, message=$3, image_url=$4, technologies=$5, github_url=$6, demo_url=$7 WHERE id=$8`
-	_, err = Pool.Exec(context.Background(), sql, p.Name, p.Description, p.Message, p.ImageURL, p.Technologies, p.GithubURL, p.DemoURL, id) // Updated query with new fields
+	err = h.store.Update(ctx, p)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Project not found")) // Nothing to update
+		return
+	}
+	if errors.Is(err, ErrSlugTaken) {
+		problem.Respond(c, problem.Conflict("The slug is already used by another project"))
+		return
+	}
+	if err == nil {
+		err = h.setTags(ctx, &p) // Only once the project is known to exist
+	}
 	if err != nil {
 		fmt.Println("Update error:", err)
-		c.JSON(500, gin.H{"error": "Update failed"}) // DB error
+		problem.Respond(c, problem.Internal("Update failed")) // DB error
 		return
 	}
 
@@ -100,22 +260,36 @@ func UpdateProject(c *gin.Context) {
 }
 
 // CreateProject Gin handler for adding a new project
-func CreateProject(c *gin.Context) {
+func (h *Handler) CreateProject(c *gin.Context) {
 	var p Project
-	if err := c.ShouldBindJSON(&p); err != nil { // Bind JSON to Project struct
-		fmt.Println("JSON bind error:", err)
-		c.JSON(400, gin.H{"error": "Invalid data"}) // JSON parse error
+	if !validation.Bind(c, &p) || !validTechnologies(c, p) || !validSlugField(c, p) { // Bind and validate JSON into Project struct
+		return
+	}
+	if p.Status == "" {
+		p.Status = StatusPublished // Projects were public on creation before there was a status
+	}
+	p.Featured = false // Featured separately, where the limit is checked
+	p.CaseStudy = p.CaseStudy.clone()
+	p.PublishAt, p.UnpublishAt = utcTime(p.PublishAt), utcTime(p.UnpublishAt)
+	if !validSchedule(c, p) {
 		return
 	}
 
-	_, err := Pool.Exec(context.Background(),
-		"INSERT INTO projects (name, description, message, image_url, technologies, github_url, demo_url) VALUES ($1, $2, $3, $4, $5, $6, $7)",
-		p.Name, p.Description, p.Message, p.ImageURL, p.Technologies, p.GithubURL, p.DemoURL) // Updated insert with new fields
+	ctx := c.Request.Context()
+	err := h.store.Create(ctx, &p)
+	if errors.Is(err, ErrSlugTaken) {
+		problem.Respond(c, problem.Conflict("The slug is already used by another project"))
+		return
+	}
+	if err == nil {
+		err = h.setTags(ctx, &p) // Also returns the canonical technologies
+	}
 	if err != nil {
 		fmt.Println("Insert error:", err)
-		c.JSON(500, gin.H{"error": "Record could not be added"}) // Return error if failed
+		problem.Respond(c, problem.Internal("Record could not be added")) // Return error if failed
 		return
 	}
 
-	c.JSON(201, gin.H{"message": "Project record added successfully"}) // Success message
+	c.Header("Location", fmt.Sprintf("/api/admin/projects/%d", p.ID)) // Where the new project can be fetched, live or not
+	c.JSON(201, p)                                                    // Return the new project
 }
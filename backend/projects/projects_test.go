@@ -0,0 +1,277 @@
+package projects
+
+import (
+	"net/http"
+	"portfolio/internal/testutil"
+	"portfolio/tags"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// newTestRouter returns a project handler on memory stores, routed like routes.SetupRoutes
+func newTestRouter() *gin.Engine {
+	h := NewHandler(NewMemoryStore(), tags.NewMemoryStore(), 2)
+
+	r := gin.New()
+	r.GET("/api/projects", h.GetLiveProjects)
+	r.GET("/api/projects/:slug", h.GetLiveProject)
+	admin := r.Group("/api/admin")
+	admin.POST("/projects", h.CreateProject)
+	admin.PUT("/projects/order", h.ReorderProjects)
+	admin.PUT("/projects/:id", h.UpdateProject)
+	admin.PUT("/projects/:id/featured", h.SetFeatured)
+	admin.DELETE("/projects/:id", h.DeleteProject)
+	admin.GET("/projects", h.GetProjects)
+	admin.GET("/projects/:id", h.GetProject)
+	return r
+}
+
+// create adds a project, failing the test unless it is created
+func create(t *testing.T, r *gin.Engine, p Project) Project {
+	t.Helper()
+	w := testutil.Do(r, http.MethodPost, "/api/admin/projects", p)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create %q: got %d, want 201: %s", p.Name, w.Code, w.Body)
+	}
+	var created Project
+	testutil.Decode(t, w, &created)
+	return created
+}
+
+func TestCreateAndListProjects(t *testing.T) {
+	r := newTestRouter()
+	create(t, r, Project{Name: "Secret", Status: StatusDraft, Technologies: "Go"})
+	chat := create(t, r, Project{Name: "Chat App", Technologies: "go, React"})
+
+	if chat.Slug != "chat-app" || chat.Status != StatusPublished {
+		t.Errorf("created project = %+v, want slug chat-app and published", chat)
+	}
+	if chat.Technologies != "Go, React" || len(chat.Tags) != 2 {
+		t.Errorf("technologies = %q with tags %v, want the existing tag Go and a new React", chat.Technologies, chat.Tags)
+	}
+
+	w := testutil.Do(r, http.MethodGet, "/api/projects", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: got %d: %s", w.Code, w.Body)
+	}
+	var live []Project
+	testutil.Decode(t, w, &live)
+	if len(live) != 1 || live[0].ID != chat.ID {
+		t.Errorf("live projects = %+v, want only %q", live, chat.Name)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "1" {
+		t.Errorf("X-Total-Count = %q, want 1", got)
+	}
+
+	w = testutil.Do(r, http.MethodGet, "/api/admin/projects", nil)
+	var all []Project
+	testutil.Decode(t, w, &all)
+	if len(all) != 2 {
+		t.Errorf("admin list has %d projects, want 2 with the draft", len(all))
+	}
+}
+
+func TestGetLiveProjectBySlug(t *testing.T) {
+	r := newTestRouter()
+	p := create(t, r, Project{Name: "Chat App"})
+
+	w := testutil.Do(r, http.MethodGet, "/api/projects/chat-app", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200: %s", w.Code, w.Body)
+	}
+	var got Project
+	testutil.Decode(t, w, &got)
+	if got.ID != p.ID {
+		t.Errorf("got project %d, want %d", got.ID, p.ID)
+	}
+
+	// A renamed project keeps answering on its old slug with a redirect
+	p.Slug = "chat"
+	if w := testutil.Do(r, http.MethodPut, "/api/admin/projects/1", p); w.Code != http.StatusOK {
+		t.Fatalf("update: got %d: %s", w.Code, w.Body)
+	}
+	w = testutil.Do(r, http.MethodGet, "/api/projects/chat-app", nil)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/projects/chat" {
+		t.Errorf("old slug: got %d to %q, want 301 to /api/projects/chat", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestCreateProjectLocation(t *testing.T) {
+	r := newTestRouter()
+
+	w := testutil.Do(r, http.MethodPost, "/api/admin/projects", Project{Name: "Chat App", Status: StatusDraft})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+	var created Project
+	testutil.Decode(t, w, &created)
+	location := w.Header().Get("Location")
+	if location != "/api/admin/projects/"+strconv.Itoa(created.ID) {
+		t.Fatalf("Location = %q, want the admin URL of project %d", location, created.ID)
+	}
+
+	// Drafts are fetched by ID in the admin API, not by slug in the public one
+	w = testutil.Do(r, http.MethodGet, location, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get %s: got %d, want 200: %s", location, w.Code, w.Body)
+	}
+	var got Project
+	testutil.Decode(t, w, &got)
+	if got.ID != created.ID || got.Name != "Chat App" {
+		t.Errorf("got %+v, want project %d", got, created.ID)
+	}
+	if w := testutil.Do(r, http.MethodGet, "/api/projects/chat-app", nil); w.Code != http.StatusNotFound {
+		t.Errorf("draft by slug: got %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestMissingProject(t *testing.T) {
+	r := newTestRouter()
+	create(t, r, Project{Name: "Chat App"})
+
+	tests := []struct {
+		method, path string
+		body         any
+		want         int
+	}{
+		{http.MethodGet, "/api/admin/projects/99", nil, http.StatusNotFound},
+		{http.MethodGet, "/api/admin/projects/abc", nil, http.StatusBadRequest},
+		{http.MethodGet, "/api/projects/no-such-project", nil, http.StatusNotFound},
+		{http.MethodPut, "/api/admin/projects/99", Project{Name: "Other"}, http.StatusNotFound},
+		{http.MethodPut, "/api/admin/projects/99/featured", map[string]bool{"featured": true}, http.StatusNotFound},
+		{http.MethodDelete, "/api/admin/projects/99", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := testutil.Do(r, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+	}
+
+	// A deleted project is gone
+	if w := testutil.Do(r, http.MethodDelete, "/api/admin/projects/1", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d, want 200: %s", w.Code, w.Body)
+	}
+	for _, path := range []string{"/api/admin/projects/1", "/api/projects/chat-app"} {
+		if w := testutil.Do(r, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s after delete: got %d, want 404: %s", path, w.Code, w.Body)
+		}
+	}
+}
+
+func TestFeaturedLimit(t *testing.T) {
+	r := newTestRouter() // The limit is 2
+	var ids []int
+	for _, name := range []string{"One", "Two", "Three"} {
+		ids = append(ids, create(t, r, Project{Name: name}).ID)
+	}
+	feature := func(id int, featured bool) int {
+		return testutil.Do(r, http.MethodPut, "/api/admin/projects/"+strconv.Itoa(id)+"/featured", map[string]bool{"featured": featured}).Code
+	}
+
+	for _, id := range ids[:2] {
+		if got := feature(id, true); got != http.StatusOK {
+			t.Fatalf("feature %d: got %d, want 200", id, got)
+		}
+	}
+	if got := feature(ids[2], true); got != http.StatusConflict {
+		t.Errorf("feature over the limit: got %d, want 409", got)
+	}
+	if got := feature(ids[0], true); got != http.StatusOK {
+		t.Errorf("feature an already featured project: got %d, want 200", got)
+	}
+
+	w := testutil.Do(r, http.MethodGet, "/api/projects?featured=true", nil)
+	var featured []Project
+	testutil.Decode(t, w, &featured)
+	if len(featured) != 2 {
+		t.Errorf("featured projects = %+v, want the first two", featured)
+	}
+
+	// Unfeaturing one makes room for another
+	if got := feature(ids[0], false); got != http.StatusOK {
+		t.Fatalf("unfeature: got %d, want 200", got)
+	}
+	if got := feature(ids[2], true); got != http.StatusOK {
+		t.Errorf("feature after unfeaturing: got %d, want 200", got)
+	}
+}
+
+func TestReorderProjects(t *testing.T) {
+	r := newTestRouter()
+	var ids []int
+	for _, name := range []string{"One", "Two", "Three"} {
+		ids = append(ids, create(t, r, Project{Name: name}).ID)
+	}
+	order := func() []int {
+		w := testutil.Do(r, http.MethodGet, "/api/projects?sort=position", nil)
+		var list []Project
+		testutil.Decode(t, w, &list)
+		var got []int
+		for _, p := range list {
+			got = append(got, p.ID)
+		}
+		return got
+	}
+
+	want := []int{ids[1], ids[0], ids[2]}
+	if w := testutil.Do(r, http.MethodPut, "/api/admin/projects/order", OrderRequest{IDs: want}); w.Code != http.StatusOK {
+		t.Fatalf("reorder: got %d, want 200: %s", w.Code, w.Body)
+	}
+	if got := order(); !slices.Equal(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		ids  []int
+	}{
+		{"partial list", []int{ids[2], ids[0]}},
+		{"unknown ID", []int{ids[2], ids[1], ids[0], 99}},
+		{"duplicate ID", []int{ids[2], ids[2], ids[1], ids[0]}},
+		{"empty list", []int{}},
+	}
+	for _, tt := range tests {
+		w := testutil.Do(r, http.MethodPut, "/api/admin/projects/order", OrderRequest{IDs: tt.ids})
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got %d, want 422: %s", tt.name, w.Code, w.Body)
+			continue
+		}
+		var resp struct {
+			Errors []struct{ Field string }
+		}
+		testutil.Decode(t, w, &resp)
+		if len(resp.Errors) != 1 || resp.Errors[0].Field != "ids" {
+			t.Errorf("%s: errors = %+v, want one for ids", tt.name, resp.Errors)
+		}
+	}
+	if got := order(); !slices.Equal(got, want) {
+		t.Errorf("order after rejected requests = %v, want it unchanged", got)
+	}
+}
+
+func TestSearchSnippetIsEscaped(t *testing.T) {
+	r := newTestRouter()
+	create(t, r, Project{Name: "Widget", Description: `An embeddable widget <script>alert("widget")</script> for <b>any</b> page`})
+
+	w := testutil.Do(r, http.MethodGet, "/api/projects?q=alert", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("search: got %d: %s", w.Code, w.Body)
+	}
+	var found []Project
+	testutil.Decode(t, w, &found)
+	if len(found) != 1 {
+		t.Fatalf("search found %+v, want the widget", found)
+	}
+
+	want := `An embeddable widget <mark>&lt;script&gt;alert(&#34;widget&#34;)&lt;/script&gt;</mark> for &lt;b&gt;any&lt;/b&gt; page`
+	if found[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", found[0].Snippet, want)
+	}
+}
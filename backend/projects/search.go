@@ -0,0 +1,118 @@
+package projects
+
+import (
+	"fmt"
+	"html"
+	"portfolio/listing"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search matches are wrapped in these markers in snippets; the rest of the snippet is HTML-escaped
+const (
+	markStart = "<mark>"
+	markEnd   = "</mark>"
+)
+
+// ts_headline on PostgreSQL wraps matches in these control characters instead of the markers,
+// so the text around them can be escaped before they are swapped for the markers
+const (
+	headlineStart = "\x02"
+	headlineEnd   = "\x03"
+)
+
+// headlineOptions configures ts_headline to match snippet
+const headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineEnd + ", MaxWords=30, MinWords=10, MaxFragments=2"
+
+// headlineMarks swaps the ts_headline control characters for the markers
+var headlineMarks = strings.NewReplacer(headlineStart, markStart, headlineEnd, markEnd)
+
+// highlight turns a ts_headline result into a snippet
+func highlight(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// snippetWords is the length of a snippet built by snippet
+const snippetWords = 30
+
+// parseFilter reads the list filters ?featured=, ?from= and ?to= (creation time, RFC 3339 or
+// YYYY-MM-DD, to is exclusive) and ?q=, and the page and sort (position, id, name, created_at,
+// updated_at or relevance; portfolio order, or best match first with ?q=). The tag filter is
+// read by the handler.
+func parseFilter(c *gin.Context) (Filter, error) {
+	filter := Filter{Search: strings.TrimSpace(c.Query("q"))}
+	if value := c.Query("featured"); value != "" {
+		featured, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid featured %q, use true or false", value)
+		}
+		filter.Featured = &featured
+	}
+
+	sortable := []string{"position", "id", "name", "created_at", "updated_at"}
+	defaultSort := "position"
+	if filter.Search != "" {
+		sortable = append(sortable, "relevance")
+		defaultSort = "relevance"
+	}
+	params, err := listing.Parse(c, defaultSort, sortable...)
+	if err != nil {
+		return filter, err
+	}
+	filter.Params = params
+
+	filter.From, filter.To, err = listing.TimeRange(c)
+	return filter, err
+}
+
+// searchTerms splits a search into lower-case words, all of which must match
+func searchTerms(search string) []string {
+	return strings.Fields(strings.ToLower(search))
+}
+
+// matchesSearch reports whether every term appears in the name, description or message of p
+func matchesSearch(p Project, terms []string) bool {
+	text := strings.ToLower(p.Name + "\n" + p.Description + "\n" + p.Message)
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet returns up to snippetWords words of the description and message of p, starting a
+// little before the first match, HTML-escaped and with the matching words wrapped in markers
+func snippet(p Project, terms []string) string {
+	words := strings.Fields(p.Description + " " + p.Message)
+	matches := func(word string) bool {
+		word = strings.ToLower(word)
+		for _, term := range terms {
+			if strings.Contains(word, term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	start := 0
+	for i, word := range words {
+		if matches(word) {
+			start = max(0, i-5)
+			break
+		}
+	}
+	end := min(start+snippetWords, len(words))
+
+	out := make([]string, 0, end-start)
+	for _, word := range words[start:end] {
+		escaped := html.EscapeString(word)
+		if matches(word) {
+			escaped = markStart + escaped + markEnd
+		}
+		out = append(out, escaped)
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,86 @@
+package projects
+
+import (
+	"fmt"
+	"portfolio/validation"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength is the longest slug; generated slugs are cut shorter to leave room for a suffix
+const maxSlugLength = 100
+
+// slugPattern is the form of a slug: lower-case letters and digits joined by single hyphens
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// foldLetters spells letters that do not decompose into a base letter and an accent
+var foldLetters = map[rune]string{'ı': "i", 'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ł': "l"}
+
+// Slugify turns a project name into a slug, "Çağrı's Chat App" into "cagri-s-chat-app". The
+// result always contains a letter, so it cannot be mistaken for a project ID.
+func Slugify(name string) string {
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)))
+	folded, _, err := transform.String(stripAccents, strings.ToLower(name))
+	if err != nil {
+		folded = strings.ToLower(name)
+	}
+
+	var b strings.Builder
+	hyphen := false
+	for _, r := range folded {
+		s, ok := foldLetters[r]
+		if !ok && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			hyphen = true
+			continue
+		}
+		if !ok {
+			s = string(r)
+		}
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteString(s)
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength-10 {
+		slug = strings.TrimRight(slug[:maxSlugLength-10], "-")
+	}
+	switch {
+	case slug == "":
+		return "project"
+	case !strings.ContainsFunc(slug, unicode.IsLetter):
+		return "project-" + slug
+	}
+	return slug
+}
+
+// slugCandidate returns the nth slug to try for base: base itself, then base-2, base-3 and so on
+func slugCandidate(base string, n int) string {
+	if n == 1 {
+		return base
+	}
+	return fmt.Sprintf("%s-%d", base, n)
+}
+
+// validSlug reports whether slug is in slug form and contains a letter
+func validSlug(slug string) bool {
+	return len(slug) <= maxSlugLength && slugPattern.MatchString(slug) && strings.ContainsFunc(slug, unicode.IsLetter)
+}
+
+// validSlugField checks the slug given for p, if any, writing a validation problem and
+// returning false when it is not a valid slug
+func validSlugField(c *gin.Context, p Project) bool {
+	if p.Slug != "" && !validSlug(p.Slug) {
+		validation.Fail(c, validation.FieldError{Field: "slug", Reason: "must be lower-case letters, digits and single hyphens, with at least one letter"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,441 @@
+package projects
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"portfolio/db"
+	"strings"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a project store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+const projectColumns = "id, name, COALESCE(description, ''), COALESCE(message, ''), COALESCE(image_url, ''), COALESCE(technologies, ''), COALESCE(github_url, ''), COALESCE(demo_url, ''), created_at, updated_at, status, publish_at, unpublish_at, position, featured, COALESCE(slug, ''), body, role, duration, client, gallery, metrics"
+
+// sortColumns are the fields a project list can be sorted by; List adds relevance for searches
+var sortColumns = map[string]string{
+	"id":         "id",
+	"name":       "LOWER(name)",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"position":   "position",
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// querier is satisfied by both *db.DB and *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// scanProject reads one project row selected with projectColumns, followed by any extra columns
+func scanProject(row rowScanner, extra ...any) (Project, error) {
+	p := Project{CaseStudy: &CaseStudy{}}
+	var gallery, metrics string
+	dest := []any{&p.ID, &p.Name, &p.Description, &p.Message, &p.ImageURL, &p.Technologies, &p.GithubURL, &p.DemoURL,
+		&p.CreatedAt, &p.UpdatedAt, &p.Status, &p.PublishAt, &p.UnpublishAt,
+		&p.Position, &p.Featured, &p.Slug, &p.CaseStudy.Body, &p.CaseStudy.Role, &p.CaseStudy.Duration,
+		&p.CaseStudy.Client, &gallery, &metrics}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return p, err
+	}
+	return p, p.CaseStudy.decodeLists(gallery, metrics)
+}
+
+// List returns the page of projects matching filter and the number of matches. PostgreSQL
+// searches the full-text index; SQLite requires every search word to appear in the text.
+func (s *SQLStore) List(ctx context.Context, filter Filter) ([]Project, int, error) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) int {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+		return len(args)
+	}
+	if filter.IDs != nil {
+		placeholders := []string{"NULL"} // Matches nothing when there are no IDs
+		for _, id := range filter.IDs {
+			args = append(args, id)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		conditions = append(conditions, "id IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.LiveAt != nil {
+		add(`(status = 'published' OR (status = 'draft' AND publish_at <= $%[1]d))
+			AND (unpublish_at IS NULL OR unpublish_at > $%[1]d)`, *filter.LiveAt)
+	}
+	if filter.Featured != nil {
+		add("featured = $%d", *filter.Featured)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+
+	columns := projectColumns
+	sorts := maps.Clone(sortColumns)
+	fullText := filter.Search != "" && s.conn.Dialect == db.Postgres
+	terms := searchTerms(filter.Search)
+	if fullText {
+		query := fmt.Sprintf("websearch_to_tsquery('english', $%d)",
+			add("search_vector @@ websearch_to_tsquery('english', $%d)", filter.Search))
+		columns += ", ts_headline('english', COALESCE(description, '') || ' ' || COALESCE(message, ''), " +
+			query + ", '" + headlineOptions + "')"
+		sorts["relevance"] = "-ts_rank(search_vector, " + query + ")" // Negated so the best match comes first
+	} else {
+		for i, term := range terms {
+			n := add(`(LOWER(name) LIKE $%[1]d ESCAPE '\' OR LOWER(COALESCE(description, '')) LIKE $%[1]d ESCAPE '\'
+				OR LOWER(COALESCE(message, '')) LIKE $%[1]d ESCAPE '\')`, db.ContainsPattern(term))
+			if i == 0 {
+				sorts["relevance"] = fmt.Sprintf(`CASE WHEN LOWER(name) LIKE $%d ESCAPE '\' THEN 0 ELSE 1 END`, n)
+			}
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	var total int
+	if err := s.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM projects"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.conn.QueryContext(ctx, "SELECT "+columns+" FROM projects"+where+filter.Clause(sorts), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		var headline string
+		var extra []any
+		if fullText {
+			extra = append(extra, &headline)
+		}
+		p, err := scanProject(rows, extra...)
+		if err != nil {
+			return nil, 0, err
+		}
+		if fullText {
+			p.Snippet = highlight(headline)
+		} else if len(terms) > 0 {
+			p.Snippet = snippet(p, terms)
+		}
+		projects = append(projects, p)
+	}
+	return projects, total, rows.Err()
+}
+
+// Get returns a single project by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (Project, error) {
+	return s.getWhere(ctx, "id=$1", id)
+}
+
+// GetBySlug returns the project with the given current slug
+func (s *SQLStore) GetBySlug(ctx context.Context, slug string) (Project, error) {
+	return s.getWhere(ctx, "slug=$1", slug)
+}
+
+// GetByOldSlug returns the project that used to have the given slug
+func (s *SQLStore) GetByOldSlug(ctx context.Context, slug string) (Project, error) {
+	return s.getWhere(ctx, "id=(SELECT project_id FROM project_slug_redirects WHERE slug=$1)", slug)
+}
+
+// getWhere returns the project matching condition
+func (s *SQLStore) getWhere(ctx context.Context, condition string, arg any) (Project, error) {
+	p, err := scanProject(s.conn.QueryRowContext(ctx, "SELECT "+projectColumns+" FROM projects WHERE "+condition, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, ErrNotFound
+	}
+	return p, err
+}
+
+// Create inserts a new project ahead of the others and stores the generated ID, slug, position
+// and times in p
+func (s *SQLStore) Create(ctx context.Context, p *Project) error {
+	gallery, metrics, err := p.CaseStudy.encodeLists()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if p.Slug == "" {
+		p.Slug, err = freeSlug(ctx, tx, Slugify(p.Name))
+	} else {
+		err = claimSlug(ctx, tx, p.Slug, 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = p.CreatedAt
+	cs := p.CaseStudy.clone()
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO projects (name, description, message, image_url, technologies, github_url, demo_url, created_at, updated_at, status, publish_at, unpublish_at, featured,
+			slug, body, role, duration, client, gallery, metrics, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			(SELECT COALESCE(MIN(position), 1) - 1 FROM projects)) RETURNING id, position`,
+		p.Name, p.Description, p.Message, p.ImageURL, p.Technologies, p.GithubURL, p.DemoURL, p.CreatedAt, p.UpdatedAt,
+		p.Status, p.PublishAt, p.UnpublishAt, p.Featured,
+		p.Slug, cs.Body, cs.Role, cs.Duration, cs.Client, gallery, metrics).Scan(&p.ID, &p.Position)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update saves the fields of the project with p.ID, except its position and featured flag, and
+// stamps the edit time. A new slug keeps the old one as a redirect.
+func (s *SQLStore) Update(ctx context.Context, p Project) error {
+	gallery, metrics, err := p.CaseStudy.encodeLists()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(slug, '') FROM projects WHERE id=$1", p.ID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if p.Slug != current {
+		if err := claimSlug(ctx, tx, p.Slug, p.ID); err != nil {
+			return err
+		}
+		if current != "" {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO project_slug_redirects (slug, project_id, created_at) VALUES ($1, $2, $3)",
+				current, p.ID, time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+	}
+
+	cs := p.CaseStudy.clone()
+	_, err = tx.ExecContext(ctx,
+		`UPDATE projects SET name=$1, description=$2, message=$3, image_url=$4, technologies=$5, github_url=$6, demo_url=$7, updated_at=$8,
+			status=$9, publish_at=$10, unpublish_at=$11, slug=$12, body=$13, role=$14, duration=$15, client=$16, gallery=$17, metrics=$18
+		WHERE id=$19`,
+		p.Name, p.Description, p.Message, p.ImageURL, p.Technologies, p.GithubURL, p.DemoURL, time.Now().UTC(),
+		p.Status, p.PublishAt, p.UnpublishAt, p.Slug, cs.Body, cs.Role, cs.Duration, cs.Client, gallery, metrics, p.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// claimSlug makes slug available to the project with the given ID (0 for a new project): it
+// returns ErrSlugTaken if another project has it now, and otherwise drops any redirect using it
+func claimSlug(ctx context.Context, tx *sql.Tx, slug string, id int) error {
+	var owner int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM projects WHERE slug=$1", slug).Scan(&owner)
+	if err == nil && owner != id {
+		return ErrSlugTaken
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM project_slug_redirects WHERE slug=$1", slug)
+	return err
+}
+
+// freeSlug returns the first candidate for base that is neither a current nor an old slug
+func freeSlug(ctx context.Context, q querier, base string) (string, error) {
+	for n := 1; ; n++ {
+		slug := slugCandidate(base, n)
+		var used bool
+		err := q.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM projects WHERE slug=$1) OR EXISTS (SELECT 1 FROM project_slug_redirects WHERE slug=$1)`,
+			slug).Scan(&used)
+		if err != nil || !used {
+			return slug, err
+		}
+	}
+}
+
+// BackfillSlugs gives the projects saved before slugs existed a slug generated from their name.
+// It returns the number of projects updated.
+func (s *SQLStore) BackfillSlugs(ctx context.Context) (int, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT id, name FROM projects WHERE slug IS NULL ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	names := map[int]string{}
+	var ids []int
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		names[id] = name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		slug, err := freeSlug(ctx, tx, Slugify(names[id]))
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE projects SET slug=$1 WHERE id=$2", slug, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), tx.Commit()
+}
+
+// Delete removes a project by ID
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM projects WHERE id=$1", id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Reorder renumbers every project in one transaction
+func (s *SQLStore) Reorder(ctx context.Context, ids []int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM projects ORDER BY position, id")
+	if err != nil {
+		return err
+	}
+	var current []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current = append(current, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := checkOrder(current, ids); err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, "UPDATE projects SET position=$1 WHERE id=$2", i+1, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// featuredLockID is the advisory lock key that keeps two PostgreSQL transactions from
+// featuring projects at once, which could take both over the limit
+const featuredLockID = 7419253002
+
+// SetFeatured sets the featured flag of a project unless that goes over the limit. The limit
+// is checked by the update itself; SQLite has a single writer, and PostgreSQL takes a lock
+// for the transaction.
+func (s *SQLStore) SetFeatured(ctx context.Context, id int, featured bool, limit int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !featured {
+		result, err := tx.ExecContext(ctx, "UPDATE projects SET featured=$1 WHERE id=$2", false, id)
+		if err := db.RequireRows(result, err, ErrNotFound); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	if s.conn.Dialect == db.Postgres {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", featuredLockID); err != nil {
+			return err
+		}
+	}
+	result, err := tx.ExecContext(ctx, `UPDATE projects SET featured=$1 WHERE id=$2
+		AND (featured OR (SELECT COUNT(*) FROM projects WHERE featured AND id<>$2) < $3)`, true, id, limit)
+	err = db.RequireRows(result, err, ErrFeaturedLimit)
+	if errors.Is(err, ErrFeaturedLimit) {
+		// Nothing changed: either the project does not exist or the limit is reached
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id=$1)", id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return ErrFeaturedLimit
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ApplySchedule publishes the drafts and archives the published projects whose time has come
+func (s *SQLStore) ApplySchedule(ctx context.Context, now time.Time) (int, error) {
+	// Publishing runs first, so a project whose whole window has passed ends up archived
+	statements := []string{
+		"UPDATE projects SET status='published', publish_at=NULL WHERE status='draft' AND publish_at <= $1",
+		"UPDATE projects SET status='archived', unpublish_at=NULL WHERE status='published' AND unpublish_at <= $1",
+	}
+	changed := 0
+	for _, statement := range statements {
+		result, err := s.conn.ExecContext(ctx, statement, now)
+		if err != nil {
+			return changed, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return changed, err
+		}
+		changed += int(n)
+	}
+	return changed, nil
+}
@@ -0,0 +1,108 @@
+package projects
+
+import (
+	"context"
+	"log"
+	"os"
+	"portfolio/validation"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Statuses of a project
+const (
+	StatusDraft     = "draft"     // Only in the admin panel, unless its publish_at has passed
+	StatusPublished = "published" // On the portfolio, until its unpublish_at
+	StatusArchived  = "archived"  // Taken off the portfolio, kept for the record
+)
+
+// IsLive reports whether p is shown on the public portfolio at time t. The scheduler moves
+// projects to the status their schedule calls for, but the times are checked here as well so
+// a project goes live or comes down on time between two scheduler runs.
+func (p Project) IsLive(t time.Time) bool {
+	if p.UnpublishAt != nil && !p.UnpublishAt.After(t) {
+		return false
+	}
+	switch p.Status {
+	case StatusPublished:
+		return true
+	case StatusDraft:
+		return p.PublishAt != nil && !p.PublishAt.After(t)
+	}
+	return false
+}
+
+// validSchedule checks that the schedule of p fits its status, writing a validation problem
+// and returning false when it does not
+func validSchedule(c *gin.Context, p Project) bool {
+	var errs validation.Errors
+	if p.PublishAt != nil && p.Status != StatusDraft {
+		errs = append(errs, validation.FieldError{Field: "publish_at", Reason: "can only be set on a draft"})
+	}
+	if p.UnpublishAt != nil && p.Status == StatusArchived {
+		errs = append(errs, validation.FieldError{Field: "unpublish_at", Reason: "cannot be set on an archived project"})
+	}
+	if p.PublishAt != nil && p.UnpublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+		errs = append(errs, validation.FieldError{Field: "unpublish_at", Reason: "must be after publish_at"})
+	}
+	if len(errs) > 0 {
+		validation.Fail(c, errs...)
+		return false
+	}
+	return true
+}
+
+// utcTime returns t in UTC, so schedule times compare correctly with the stored times
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// Scheduler moves projects along their publishing schedule in the background
+type Scheduler struct {
+	store    Store
+	interval time.Duration
+}
+
+// NewScheduler creates a scheduler that checks store every interval
+func NewScheduler(store Store, interval time.Duration) *Scheduler {
+	return &Scheduler{store: store, interval: interval}
+}
+
+// ScheduleIntervalFromEnv reads PROJECT_SCHEDULE_INTERVAL (default 1m), how often the
+// scheduler runs
+func ScheduleIntervalFromEnv() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("PROJECT_SCHEDULE_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// Run publishes and archives due projects until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx, time.Now().UTC())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick publishes and archives the projects that are due at now
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	if n, err := s.store.ApplySchedule(ctx, now); err != nil {
+		log.Printf("Project schedule error: %v", err)
+	} else if n > 0 {
+		log.Printf("Project schedule: %d projects published or archived", n)
+	}
+}
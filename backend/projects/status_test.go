@@ -0,0 +1,171 @@
+package projects
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"portfolio/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// at returns a pointer to now moved by d
+func at(now time.Time, d time.Duration) *time.Time {
+	t := now.Add(d)
+	return &t
+}
+
+func TestIsLive(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name string
+		p    Project
+		want bool
+	}{
+		{"published", Project{Status: StatusPublished}, true},
+		{"draft", Project{Status: StatusDraft}, false},
+		{"archived", Project{Status: StatusArchived}, false},
+		{"draft due", Project{Status: StatusDraft, PublishAt: at(now, -time.Minute)}, true},
+		{"draft due now", Project{Status: StatusDraft, PublishAt: at(now, 0)}, true},
+		{"draft not yet due", Project{Status: StatusDraft, PublishAt: at(now, time.Minute)}, false},
+		{"published until later", Project{Status: StatusPublished, UnpublishAt: at(now, time.Minute)}, true},
+		{"published until now", Project{Status: StatusPublished, UnpublishAt: at(now, 0)}, false},
+		{"published until earlier", Project{Status: StatusPublished, UnpublishAt: at(now, -time.Minute)}, false},
+		{"draft due and past its end", Project{Status: StatusDraft, PublishAt: at(now, -time.Hour), UnpublishAt: at(now, -time.Minute)}, false},
+		{"draft due until later", Project{Status: StatusDraft, PublishAt: at(now, -time.Hour), UnpublishAt: at(now, time.Hour)}, true},
+		{"archived with a past publish_at", Project{Status: StatusArchived, PublishAt: at(now, -time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.p.IsLive(now); got != tt.want {
+			t.Errorf("%s: IsLive = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidSchedule(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name  string
+		p     Project
+		field string // The field of the expected error, or "" when the schedule is valid
+	}{
+		{"no schedule", Project{Status: StatusPublished}, ""},
+		{"draft with publish_at", Project{Status: StatusDraft, PublishAt: at(now, time.Hour)}, ""},
+		{"draft with both", Project{Status: StatusDraft, PublishAt: at(now, time.Hour), UnpublishAt: at(now, 2*time.Hour)}, ""},
+		{"published with unpublish_at", Project{Status: StatusPublished, UnpublishAt: at(now, time.Hour)}, ""},
+		{"published with publish_at", Project{Status: StatusPublished, PublishAt: at(now, time.Hour)}, "publish_at"},
+		{"archived with unpublish_at", Project{Status: StatusArchived, UnpublishAt: at(now, time.Hour)}, "unpublish_at"},
+		{"unpublish before publish", Project{Status: StatusDraft, PublishAt: at(now, 2*time.Hour), UnpublishAt: at(now, time.Hour)}, "unpublish_at"},
+		{"unpublish at publish", Project{Status: StatusDraft, PublishAt: at(now, time.Hour), UnpublishAt: at(now, time.Hour)}, "unpublish_at"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/admin/projects/1", nil)
+		ok := validSchedule(c, tt.p)
+
+		if tt.field == "" {
+			if !ok || w.Code != http.StatusOK {
+				t.Errorf("%s: got invalid with %d: %s", tt.name, w.Code, w.Body)
+			}
+			continue
+		}
+		if ok || w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got valid with %d, want 422", tt.name, w.Code)
+			continue
+		}
+		var resp struct {
+			Errors []struct{ Field string }
+		}
+		testutil.Decode(t, w, &resp)
+		if len(resp.Errors) != 1 || resp.Errors[0].Field != tt.field {
+			t.Errorf("%s: errors = %+v, want one for %s", tt.name, resp.Errors, tt.field)
+		}
+	}
+}
+
+func TestSchedulerTick(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now().UTC()
+	create := func(p Project) int {
+		t.Helper()
+		if err := store.Create(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+		return p.ID
+	}
+	due := create(Project{Name: "Due", Status: StatusDraft, PublishAt: at(now, -time.Minute)})
+	later := create(Project{Name: "Later", Status: StatusDraft, PublishAt: at(now, time.Hour)})
+	ending := create(Project{Name: "Ending", Status: StatusPublished, UnpublishAt: at(now, -time.Minute)})
+	staying := create(Project{Name: "Staying", Status: StatusPublished, UnpublishAt: at(now, time.Hour)})
+	brief := create(Project{Name: "Brief", Status: StatusDraft, PublishAt: at(now, -time.Hour), UnpublishAt: at(now, -time.Minute)})
+
+	NewScheduler(store, time.Minute).tick(ctx, now)
+
+	for id, want := range map[int]string{
+		due:     StatusPublished,
+		later:   StatusDraft,
+		ending:  StatusArchived,
+		staying: StatusPublished,
+		brief:   StatusArchived,
+	} {
+		p, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Status != want {
+			t.Errorf("%s: status = %s, want %s", p.Name, p.Status, want)
+		}
+		if p.IsLive(now) != (want == StatusPublished) {
+			t.Errorf("%s: IsLive = %v after the tick, want %v", p.Name, p.IsLive(now), want == StatusPublished)
+		}
+	}
+
+	// Applied schedule times are cleared, the pending ones kept
+	if p, _ := store.Get(ctx, due); p.PublishAt != nil {
+		t.Errorf("%s: publish_at = %v, want it cleared", p.Name, p.PublishAt)
+	}
+	if p, _ := store.Get(ctx, later); p.PublishAt == nil {
+		t.Errorf("%s: publish_at cleared before it was due", p.Name)
+	}
+	if p, _ := store.Get(ctx, staying); p.UnpublishAt == nil {
+		t.Errorf("%s: unpublish_at cleared before it was due", p.Name)
+	}
+}
+
+func TestSchedulerRunStopsWithContext(t *testing.T) {
+	store := NewMemoryStore()
+	p := Project{Name: "Due", Status: StatusDraft, PublishAt: at(time.Now().UTC(), -time.Minute)}
+	if err := store.Create(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewScheduler(store, time.Hour).Run(ctx)
+		close(done)
+	}()
+
+	// Run applies the schedule once before it waits for the ticker
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got, _ := store.Get(context.Background(), p.ID); got.Status == StatusPublished {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Run did not publish the due project")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,64 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"portfolio/listing"
+	"time"
+)
+
+// ErrNotFound is returned when no project matches the given ID
+var ErrNotFound = errors.New("project not found")
+
+// ErrSlugTaken is returned when another project already has the slug
+var ErrSlugTaken = errors.New("slug already in use")
+
+// ErrIncompleteOrder is returned when a new order leaves out some of the projects
+var ErrIncompleteOrder = errors.New("order does not list every project")
+
+// ErrFeaturedLimit is returned when featuring a project would go over the limit
+var ErrFeaturedLimit = errors.New("featured project limit reached")
+
+// Filter selects projects in List; zero fields match everything
+type Filter struct {
+	IDs      []int      // When not nil, only these projects; the handler fills it for a tag filter
+	Status   string     // Only projects with this status
+	LiveAt   *time.Time // Only projects live on the portfolio at this time, see Project.IsLive
+	Featured *bool      // Only featured projects, or only the others
+	From     *time.Time // Created at or after From
+	To       *time.Time // Created before To
+	Search   string     // Full-text query over the name, description and message
+
+	listing.Params // Page and order; "relevance" orders by best match when Search is set
+}
+
+// Store is the persistence interface the project handlers depend on
+type Store interface {
+	// List returns the page of projects matching filter and the number of matches. With a
+	// search, each project carries a snippet of its text with the matches highlighted.
+	List(ctx context.Context, filter Filter) ([]Project, int, error)
+	Get(ctx context.Context, id int) (Project, error)               // Single project by ID
+	GetBySlug(ctx context.Context, slug string) (Project, error)    // Single project by its current slug
+	GetByOldSlug(ctx context.Context, slug string) (Project, error) // The project that used to have slug
+	// Create inserts p first in the order and sets its ID, position and times. An empty slug is
+	// generated from the name, skipping current and old slugs; a given slug that another project
+	// has gives ErrSlugTaken.
+	Create(ctx context.Context, p *Project) error
+	// Update updates the project with p.ID, or returns ErrNotFound. When the slug changes, the old
+	// one redirects to the project; a slug that another project has gives ErrSlugTaken.
+	Update(ctx context.Context, p Project) error
+	Delete(ctx context.Context, id int) error // Removes the project by ID, or ErrNotFound
+
+	// Reorder renumbers the projects in the given order. It changes nothing and returns
+	// ErrNotFound if an ID does not exist, or ErrIncompleteOrder unless every project is
+	// listed once.
+	Reorder(ctx context.Context, ids []int) error
+	// SetFeatured features or unfeatures a project. It returns ErrFeaturedLimit, changing
+	// nothing, when more than limit projects would be featured.
+	SetFeatured(ctx context.Context, id int, featured bool, limit int) error
+
+	// ApplySchedule publishes the drafts whose publish_at has passed and archives the published
+	// projects whose unpublish_at has passed, clearing the time that was acted on. It returns
+	// the number of projects changed.
+	ApplySchedule(ctx context.Context, now time.Time) (int, error)
+}
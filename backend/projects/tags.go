@@ -0,0 +1,103 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"portfolio/tags"
+	"portfolio/validation"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withTags fills in the tags of each project, and rewrites Technologies from them so older
+// clients keep reading a comma-separated list
+func (h *Handler) withTags(ctx context.Context, projects []Project) error {
+	ids := make([]int, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	refs, err := h.tags.ProjectTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range projects {
+		projects[i].Tags = refs[projects[i].ID]
+		if projects[i].Tags == nil {
+			projects[i].Tags = []tags.Ref{}
+		}
+		projects[i].Technologies = tags.JoinNames(projects[i].Tags)
+	}
+	return nil
+}
+
+// setTags links p to the tags named in its Technologies, creating unknown ones, and fills in
+// its Tags and canonical Technologies
+func (h *Handler) setTags(ctx context.Context, p *Project) error {
+	refs, err := h.tags.Resolve(ctx, tags.SplitNames(p.Technologies))
+	if err != nil {
+		return err
+	}
+	ids := make([]int, len(refs))
+	for i, r := range refs {
+		ids[i] = r.ID
+	}
+	if err := h.tags.SetProjectTags(ctx, p.ID, ids); err != nil {
+		return err
+	}
+	p.Tags = refs
+	p.Technologies = tags.JoinNames(refs)
+	return nil
+}
+
+// tagFilter narrows filter to the projects carrying the tag named by ?tag= or, as before,
+// ?technology=; an unknown name matches no projects
+func (h *Handler) tagFilter(c *gin.Context, filter *Filter) error {
+	name := c.Query("tag")
+	if name == "" {
+		name = c.Query("technology")
+	}
+	if name == "" {
+		return nil
+	}
+
+	ctx := c.Request.Context()
+	t, err := h.tags.Lookup(ctx, name)
+	if errors.Is(err, tags.ErrNotFound) {
+		filter.IDs = []int{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	filter.IDs, err = h.tags.ProjectIDs(ctx, t.ID)
+	return err
+}
+
+// validTechnologies checks that each name in the technologies list fits a tag, writing a
+// validation problem and returning false when one does not
+func validTechnologies(c *gin.Context, p Project) bool {
+	for _, name := range tags.SplitNames(p.Technologies) {
+		if utf8.RuneCountInString(name) > tags.MaxNameLength {
+			validation.Fail(c, validation.FieldError{Field: "technologies", Reason: "each technology must be at most 50 characters"})
+			return false
+		}
+	}
+	return true
+}
+
+// LiveProjectIDs returns the IDs of the projects live on the portfolio now, so public tag
+// counts leave out drafts and archived projects
+func (h *Handler) LiveProjectIDs(ctx context.Context) ([]int, error) {
+	now := time.Now().UTC()
+	projects, _, err := h.store.List(ctx, Filter{LiveAt: &now})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	return ids, nil
+}
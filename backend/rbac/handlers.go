@@ -0,0 +1,218 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"portfolio/validation"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserRolesRequest struct for assigning roles to a user
+type UserRolesRequest struct {
+	Roles []string `json:"roles" binding:"dive,notblank,max=50"`
+}
+
+// Handler serves the role management endpoints using a Store
+type Handler struct {
+	store      Store
+	userExists func(ctx context.Context, id int) (bool, error) // Roles are only read and assigned for existing users
+}
+
+// NewHandler creates role handlers backed by the given store; userExists looks up the users
+// whose roles are read or replaced
+func NewHandler(store Store, userExists func(ctx context.Context, id int) (bool, error)) *Handler {
+	return &Handler{store: store, userExists: userExists}
+}
+
+// GetPermissions returns every permission that can be granted
+func (h *Handler) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, AllPermissions)
+}
+
+// GetRoles returns all roles with their permissions
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles, err := h.store.ListRoles(c.Request.Context())
+	if err != nil {
+		fmt.Println("Role list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole adds a new role
+func (h *Handler) CreateRole(c *gin.Context) {
+	var r Role
+	if !validation.Bind(c, &r) {
+		return
+	}
+	if bad := unknownPermission(r.Permissions); bad != "" {
+		problem.Respond(c, problem.BadRequest(fmt.Sprintf("Unknown permission %q", bad)))
+		return
+	}
+
+	if err := h.store.CreateRole(c.Request.Context(), &r); err != nil {
+		if errors.Is(err, ErrRoleExists) {
+			problem.Respond(c, problem.Conflict("A role with this name already exists"))
+			return
+		}
+		fmt.Println("Role create error:", err)
+		problem.Respond(c, problem.Internal("Role could not be created"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, r)
+}
+
+// UpdateRole changes the name, description and permissions of a role
+func (h *Handler) UpdateRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	var r Role
+	if !validation.Bind(c, &r) {
+		return
+	}
+	if bad := unknownPermission(r.Permissions); bad != "" {
+		problem.Respond(c, problem.BadRequest(fmt.Sprintf("Unknown permission %q", bad)))
+		return
+	}
+	r.ID = id
+
+	// The super admin role must keep its name and user management, or nobody could fix roles again
+	existing, err := h.store.GetRole(c.Request.Context(), id)
+	if err == nil && existing.Name == SuperAdminRole && (r.Name != SuperAdminRole || !HasAll(r.Permissions, UsersManage)) {
+		problem.Respond(c, problem.BadRequest("The superadmin role must keep its name and the users:manage permission"))
+		return
+	}
+
+	if err := h.store.UpdateRole(c.Request.Context(), r); err != nil {
+		switch {
+		case errors.Is(err, ErrRoleNotFound):
+			problem.Respond(c, problem.NotFound("Role not found"))
+		case errors.Is(err, ErrRoleExists):
+			problem.Respond(c, problem.Conflict("A role with this name already exists"))
+		default:
+			fmt.Println("Role update error:", err)
+			problem.Respond(c, problem.Internal("Update failed"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+// DeleteRole removes a role and unassigns it from all users
+func (h *Handler) DeleteRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	if existing, err := h.store.GetRole(c.Request.Context(), id); err == nil && existing.Name == SuperAdminRole {
+		problem.Respond(c, problem.BadRequest("The superadmin role cannot be deleted"))
+		return
+	}
+
+	if err := h.store.DeleteRole(c.Request.Context(), id); err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			problem.Respond(c, problem.NotFound("Role not found"))
+			return
+		}
+		fmt.Println("Role delete error:", err)
+		problem.Respond(c, problem.Internal("Delete operation failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Role ID %d deleted successfully", id)})
+}
+
+// GetUserRoles returns the roles assigned to a user
+func (h *Handler) GetUserRoles(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	if !h.requireUser(c, userID) {
+		return
+	}
+
+	roles, err := h.store.UserRoles(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Println("User role list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// SetUserRoles replaces the roles of a user
+func (h *Handler) SetUserRoles(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	var req UserRolesRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+	if !h.requireUser(c, userID) {
+		return
+	}
+
+	if err := h.store.SetUserRoles(c.Request.Context(), userID, req.Roles); err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			problem.Respond(c, problem.BadRequest(err.Error()))
+			return
+		}
+		if errors.Is(err, ErrLastSuperAdmin) {
+			problem.Respond(c, problem.Conflict("This is the last superadmin; give another user the role first"))
+			return
+		}
+		fmt.Println("User role update error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Roles of user ID %d updated successfully", userID)})
+}
+
+// requireUser reports whether the user exists, answering 404 or 500 when it does not
+func (h *Handler) requireUser(c *gin.Context, userID int) bool {
+	exists, err := h.userExists(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Println("User fetch error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return false
+	}
+	if !exists {
+		problem.Respond(c, problem.NotFound("User not found"))
+		return false
+	}
+	return true
+}
+
+// unknownPermission returns the first permission that is not known, or ""
+func unknownPermission(permissions []Permission) Permission {
+	for _, p := range permissions {
+		if !ValidPermission(p) {
+			return p
+		}
+	}
+	return ""
+}
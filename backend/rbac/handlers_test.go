@@ -0,0 +1,80 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"portfolio/internal/testutil"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// newTestRouter returns role handlers on a memory store where users 1 and 2 exist, routed
+// like routes.SetupRoutes
+func newTestRouter(store *MemoryStore) *gin.Engine {
+	h := NewHandler(store, func(ctx context.Context, id int) (bool, error) {
+		return id == 1 || id == 2, nil
+	})
+
+	r := gin.New()
+	superAdmin := r.Group("/api/superadmin")
+	superAdmin.GET("/users/:id/roles", h.GetUserRoles)
+	superAdmin.PUT("/users/:id/roles", h.SetUserRoles)
+	return r
+}
+
+func TestSetUserRoles(t *testing.T) {
+	store := NewMemoryStore()
+	r := newTestRouter(store)
+
+	if w := testutil.Do(r, http.MethodPut, "/api/superadmin/users/2/roles", UserRolesRequest{Roles: []string{"editor"}}); w.Code != http.StatusOK {
+		t.Fatalf("set: got %d, want 200: %s", w.Code, w.Body)
+	}
+	w := testutil.Do(r, http.MethodGet, "/api/superadmin/users/2/roles", nil)
+	var roles []Role
+	if err := json.Unmarshal(w.Body.Bytes(), &roles); err != nil || len(roles) != 1 || roles[0].Name != "editor" {
+		t.Errorf("roles = %s, want editor", w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"get unknown user", http.MethodGet, "/api/superadmin/users/99/roles", nil, http.StatusNotFound},
+		{"set unknown user", http.MethodPut, "/api/superadmin/users/99/roles", UserRolesRequest{Roles: []string{"editor"}}, http.StatusNotFound},
+		{"set unknown role", http.MethodPut, "/api/superadmin/users/2/roles", UserRolesRequest{Roles: []string{"owner"}}, http.StatusBadRequest},
+		{"invalid ID", http.MethodGet, "/api/superadmin/users/abc/roles", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := testutil.Do(r, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s: got %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestSetUserRolesKeepsLastSuperAdmin(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.SetUserRoles(context.Background(), 1, []string{SuperAdminRole}); err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRouter(store)
+
+	w := testutil.Do(r, http.MethodPut, "/api/superadmin/users/1/roles", UserRolesRequest{Roles: []string{"admin"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("demoting the last superadmin: got %d, want 409: %s", w.Code, w.Body)
+	}
+
+	// Once another user has the role, the first may give it up
+	testutil.Do(r, http.MethodPut, "/api/superadmin/users/2/roles", UserRolesRequest{Roles: []string{SuperAdminRole}})
+	if w := testutil.Do(r, http.MethodPut, "/api/superadmin/users/1/roles", UserRolesRequest{Roles: []string{"admin"}}); w.Code != http.StatusOK {
+		t.Errorf("demoting one of two superadmins: got %d, want 200: %s", w.Code, w.Body)
+	}
+}
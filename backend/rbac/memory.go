@@ -0,0 +1,218 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryStore implements Store in process memory; it starts with the DefaultRoles
+type MemoryStore struct {
+	mu        sync.RWMutex
+	roles     []Role
+	userRoles map[int][]int // User ID -> role IDs
+	nextID    int
+}
+
+// NewMemoryStore creates an in-memory role store seeded with the default roles
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{userRoles: map[int][]int{}, nextID: 1}
+	for _, r := range DefaultRoles {
+		r.ID = s.nextID
+		r.Permissions = append([]Permission(nil), r.Permissions...)
+		s.nextID++
+		s.roles = append(s.roles, r)
+	}
+	return s
+}
+
+// ListRoles returns all roles ordered by name
+func (s *MemoryStore) ListRoles(ctx context.Context) ([]Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := make([]Role, 0, len(s.roles))
+	for _, r := range s.roles {
+		roles = append(roles, copyRole(r))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// GetRole returns a single role by ID
+func (s *MemoryStore) GetRole(ctx context.Context, id int) (Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return copyRole(s.roles[i]), nil
+	}
+	return Role{}, ErrRoleNotFound
+}
+
+// CreateRole inserts a role and stores the generated ID in r
+func (s *MemoryStore) CreateRole(ctx context.Context, r *Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nameTaken(r.Name, 0) {
+		return ErrRoleExists
+	}
+
+	r.ID = s.nextID
+	s.nextID++
+	s.roles = append(s.roles, copyRole(*r))
+	return nil
+}
+
+// UpdateRole replaces the name, description and permissions of the role with r.ID
+func (s *MemoryStore) UpdateRole(ctx context.Context, r Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(r.ID)
+	if i < 0 {
+		return ErrRoleNotFound
+	}
+	if s.nameTaken(r.Name, r.ID) {
+		return ErrRoleExists
+	}
+	s.roles[i] = copyRole(r)
+	return nil
+}
+
+// DeleteRole removes a role and its user assignments
+func (s *MemoryStore) DeleteRole(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrRoleNotFound
+	}
+	s.roles = append(s.roles[:i], s.roles[i+1:]...)
+
+	for userID, roleIDs := range s.userRoles {
+		kept := roleIDs[:0]
+		for _, roleID := range roleIDs {
+			if roleID != id {
+				kept = append(kept, roleID)
+			}
+		}
+		s.userRoles[userID] = kept
+	}
+	return nil
+}
+
+// UserRoles returns the roles assigned to a user
+func (s *MemoryStore) UserRoles(ctx context.Context, userID int) ([]Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := []Role{}
+	for _, roleID := range s.userRoles[userID] {
+		if i := s.index(roleID); i >= 0 {
+			roles = append(roles, copyRole(s.roles[i]))
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles of a user with the named roles
+func (s *MemoryStore) SetUserRoles(ctx context.Context, userID int, roleNames []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var roleIDs []int
+	for _, name := range roleNames {
+		found := false
+		for _, r := range s.roles {
+			if r.Name == name {
+				roleIDs = append(roleIDs, r.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, name)
+		}
+	}
+
+	if s.hasRole(s.userRoles[userID], SuperAdminRole) && !s.hasRole(roleIDs, SuperAdminRole) && !s.otherHasRole(userID, SuperAdminRole) {
+		return ErrLastSuperAdmin
+	}
+
+	s.userRoles[userID] = roleIDs
+	return nil
+}
+
+// UserPermissions returns the union of the permissions of all roles of a user
+func (s *MemoryStore) UserPermissions(ctx context.Context, userID int) ([]Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := map[Permission]bool{}
+	var permissions []Permission
+	for _, roleID := range s.userRoles[userID] {
+		i := s.index(roleID)
+		if i < 0 {
+			continue
+		}
+		for _, p := range s.roles[i].Permissions {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions, nil
+}
+
+// index returns the slice position of the role with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, r := range s.roles {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasRole reports whether roleIDs include the role with the given name
+func (s *MemoryStore) hasRole(roleIDs []int, name string) bool {
+	for _, roleID := range roleIDs {
+		if i := s.index(roleID); i >= 0 && s.roles[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// otherHasRole reports whether a user other than userID has the role with the given name
+func (s *MemoryStore) otherHasRole(userID int, name string) bool {
+	for other, roleIDs := range s.userRoles {
+		if other != userID && s.hasRole(roleIDs, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameTaken reports whether a role other than exceptID already uses name
+func (s *MemoryStore) nameTaken(name string, exceptID int) bool {
+	for _, r := range s.roles {
+		if r.Name == name && r.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// copyRole returns r with its own permission slice so callers cannot change stored data
+func copyRole(r Role) Role {
+	r.Permissions = append([]Permission{}, r.Permissions...)
+	return r
+}
@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+)
+
+// Permission is a single action a role may grant, in "resource:action" form
+type Permission string
+
+// Permissions checked by the admin routes
+const (
+	HomeWrite     Permission = "home:write"
+	AboutWrite    Permission = "about:write"
+	ProjectsWrite Permission = "projects:write"
+	ContactsRead  Permission = "contacts:read"
+	ContactsWrite Permission = "contacts:write"
+	UsersManage   Permission = "users:manage"
+	MailManage    Permission = "mail:manage"
+)
+
+// AllPermissions lists every permission a role can be given
+var AllPermissions = []Permission{
+	HomeWrite,
+	AboutWrite,
+	ProjectsWrite,
+	ContactsRead,
+	ContactsWrite,
+	UsersManage,
+	MailManage,
+}
+
+// SuperAdminRole is the built-in role that can manage users and roles; it cannot be deleted
+const SuperAdminRole = "superadmin"
+
+// Role errors
+var (
+	ErrRoleNotFound   = errors.New("role not found")
+	ErrRoleExists     = errors.New("role name already exists")
+	ErrLastSuperAdmin = errors.New("the last superadmin cannot lose the role")
+)
+
+// Role is a named set of permissions that can be assigned to users
+type Role struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name" binding:"notblank,max=50"`
+	Description string       `json:"description" binding:"max=500"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// DefaultRoles are created by the migrations and by the in-memory store
+var DefaultRoles = []Role{
+	{Name: SuperAdminRole, Description: "Full access including user and role management", Permissions: AllPermissions},
+	{Name: "admin", Description: "Manages all content and contact messages", Permissions: []Permission{
+		HomeWrite, AboutWrite, ProjectsWrite, ContactsRead, ContactsWrite, MailManage,
+	}},
+	{Name: "editor", Description: "Edits home, about and project content", Permissions: []Permission{
+		HomeWrite, AboutWrite, ProjectsWrite,
+	}},
+}
+
+// Store persists roles and the roles assigned to each user
+type Store interface {
+	ListRoles(ctx context.Context) ([]Role, error)
+	GetRole(ctx context.Context, id int) (Role, error)
+	CreateRole(ctx context.Context, r *Role) error
+	UpdateRole(ctx context.Context, r Role) error
+	DeleteRole(ctx context.Context, id int) error
+	UserRoles(ctx context.Context, userID int) ([]Role, error)
+	SetUserRoles(ctx context.Context, userID int, roleNames []string) error // Replaces all roles of the user; ErrLastSuperAdmin if nobody would keep SuperAdminRole
+	UserPermissions(ctx context.Context, userID int) ([]Permission, error)  // Union of the permissions of all roles
+}
+
+// ValidPermission reports whether p is a known permission
+func ValidPermission(p Permission) bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll reports whether granted contains every required permission
+func HasAll(granted []Permission, required ...Permission) bool {
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,257 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"portfolio/db"
+	"sort"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a role store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// ListRoles returns all roles with their permissions, ordered by name
+func (s *SQLStore) ListRoles(ctx context.Context) ([]Role, error) {
+	return s.queryRoles(ctx, "SELECT id, name, COALESCE(description, '') FROM roles ORDER BY name")
+}
+
+// GetRole returns a single role by ID
+func (s *SQLStore) GetRole(ctx context.Context, id int) (Role, error) {
+	roles, err := s.queryRoles(ctx, "SELECT id, name, COALESCE(description, '') FROM roles WHERE id=$1", id)
+	if err != nil {
+		return Role{}, err
+	}
+	if len(roles) == 0 {
+		return Role{}, ErrRoleNotFound
+	}
+	return roles[0], nil
+}
+
+// CreateRole inserts a role with its permissions and stores the generated ID in r
+func (s *SQLStore) CreateRole(ctx context.Context, r *Role) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name=$1)", r.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrRoleExists
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING id",
+		r.Name, r.Description).Scan(&r.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertPermissions(ctx, tx, r.ID, r.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateRole replaces the name, description and permissions of the role with r.ID
+func (s *SQLStore) UpdateRole(ctx context.Context, r Role) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name=$1 AND id<>$2)", r.Name, r.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrRoleExists
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE roles SET name=$1, description=$2 WHERE id=$3", r.Name, r.Description, r.ID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrRoleNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id=$1", r.ID); err != nil {
+		return err
+	}
+	if err := insertPermissions(ctx, tx, r.ID, r.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteRole removes a role; its permissions and user assignments are removed by cascade
+func (s *SQLStore) DeleteRole(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM roles WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// UserRoles returns the roles assigned to a user
+func (s *SQLStore) UserRoles(ctx context.Context, userID int) ([]Role, error) {
+	return s.queryRoles(ctx, `SELECT r.id, r.name, COALESCE(r.description, '') FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id=$1 ORDER BY r.name`, userID)
+}
+
+// SetUserRoles replaces the roles of a user with the named roles
+func (s *SQLStore) SetUserRoles(ctx context.Context, userID int, roleNames []string) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, wasSuperAdmin, err := superAdmins(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	for _, name := range roleNames {
+		var roleID int
+		err := tx.QueryRowContext(ctx, "SELECT id FROM roles WHERE name=$1", name).Scan(&roleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, name)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", userID, roleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Checked after the change inside the transaction, so two admins demoting each other
+	// cannot both succeed
+	if wasSuperAdmin {
+		remaining, _, err := superAdmins(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return ErrLastSuperAdmin
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UserPermissions returns the union of the permissions of all roles of a user
+func (s *SQLStore) UserPermissions(ctx context.Context, userID int) ([]Permission, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT DISTINCT rp.permission FROM role_permissions rp
+		JOIN user_roles ur ON ur.role_id = rp.role_id
+		WHERE ur.user_id=$1 ORDER BY rp.permission`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []Permission
+	for rows.Next() {
+		var p Permission
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
+
+// queryRoles runs a query selecting (id, name, description) and attaches each role's permissions
+func (s *SQLStore) queryRoles(ctx context.Context, query string, args ...any) ([]Role, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []Role
+	for rows.Next() {
+		var r Role
+		if err := rows.Scan(&r.ID, &r.Name, &r.Description); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Permissions are loaded after the role rows are closed; SQLite runs on a single connection
+	for i := range roles {
+		permissions, err := s.rolePermissions(ctx, roles[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		roles[i].Permissions = permissions
+	}
+	return roles, nil
+}
+
+// rolePermissions returns the sorted permissions of a role
+func (s *SQLStore) rolePermissions(ctx context.Context, roleID int) ([]Permission, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT permission FROM role_permissions WHERE role_id=$1", roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []Permission{}
+	for rows.Next() {
+		var p Permission
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions, rows.Err()
+}
+
+// superAdmins returns the number of users with SuperAdminRole and whether userID is one of them
+func superAdmins(ctx context.Context, tx *sql.Tx, userID int) (count int, includesUser bool, err error) {
+	var own int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(CASE WHEN ur.user_id=$2 THEN 1 ELSE 0 END), 0)
+		FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+		WHERE r.name=$1`, SuperAdminRole, userID).Scan(&count, &own)
+	return count, own > 0, err
+}
+
+// insertPermissions adds the permissions of a role inside a transaction
+func insertPermissions(ctx context.Context, tx *sql.Tx, roleID int, permissions []Permission) error {
+	for _, p := range permissions {
+		_, err := tx.ExecContext(ctx, "INSERT INTO role_permissions (role_id, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING", roleID, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,17 +3,28 @@ package routes
 import (
 	"os"
 	"portfolio/about"
+	"portfolio/auth"
+	"portfolio/autoreply"
 	"portfolio/contact"
 	"portfolio/home"
+	"portfolio/invite"
+	"portfolio/lockout"
+	"portfolio/mail"
 	"portfolio/middleware"
+	"portfolio/outbox"
+	"portfolio/problem"
 	"portfolio/projects"
+	"portfolio/rbac"
+	"portfolio/spam"
+	"portfolio/storage"
+	"portfolio/tags"
+	"portfolio/twofactor"
 	"portfolio/user"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func SetupRoutes(r *gin.Engine, dbPool *pgxpool.Pool) {
+func SetupRoutes(r *gin.Engine, stores *storage.Stores, mailer *mail.Mailer, spamChecker *spam.Checker) {
 	// CORS settings - Fixed for credentials
 	r.Use(func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
@@ -26,7 +37,8 @@ func SetupRoutes(r *gin.Engine, dbPool *pgxpool.Pool) {
 		}
 
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, Location, Link, X-Total-Count")
 		c.Header("Access-Control-Allow-Credentials", "false")
 
 		// Handle preflight OPTIONS requests
@@ -38,58 +50,155 @@ func SetupRoutes(r *gin.Engine, dbPool *pgxpool.Pool) {
 		c.Next()
 	})
 
-	// Set database connection for all packages
-	home.SetDB(dbPool)
-	about.SetDB(dbPool)
-	projects.SetDB(dbPool)
-	contact.SetDB(dbPool)
-	user.SetDB(dbPool)
+	// Unknown paths get a problem response; in release mode SetupStaticFiles keeps this for /api
+	r.NoRoute(problem.NoRoute)
+
+	// Build handlers on top of the configured stores
+	homeHandler := home.NewHandler(stores.Home)
+	aboutHandler := about.NewHandler(stores.About)
+	projectHandler := projects.NewHandler(stores.Projects, stores.Tags, projects.FeaturedLimitFromEnv())
+	tagHandler := tags.NewHandler(stores.Tags, projectHandler.LiveProjectIDs)
+	autoReplyHandler := autoreply.NewHandler(stores.AutoReply)
+	contactHandler := contact.NewHandler(stores.Contact, stores.Users, mailer,
+		autoreply.NewResponder(stores.AutoReply, mailer, autoreply.Interval()), spamChecker)
+	spamHandler := spam.NewHandler(spamChecker)
+	loginGuard := lockout.NewGuard(stores.Lockout, lockout.PolicyFromEnv())
+	userHandler := user.NewHandler(stores.Users, stores.Sessions, stores.TwoFactor, stores.ResetTokens, loginGuard, mailer)
+	roleHandler := rbac.NewHandler(stores.Roles, userHandler.UserExists)
+	twoFactorHandler := twofactor.NewHandler(stores.TwoFactor)
+	lockoutHandler := lockout.NewHandler(stores.Lockout)
+	inviteHandler := invite.NewHandler(stores.Invites, stores.Users, stores.Roles, mailer)
+	outboxHandler := outbox.NewHandler(stores.Outbox)
+	mailHandler := mail.NewHandler(mailer)
+
+	// can builds the permission check for a route
+	can := func(permissions ...rbac.Permission) gin.HandlerFunc {
+		return middleware.RequirePermission(stores.Roles, permissions...)
+	}
+
+	// Public keys so other services can verify our tokens
+	r.GET("/.well-known/jwks.json", auth.JWKSHandler)
 
 	// PUBLIC ROUTES
 	publicAPI := r.Group("/api")
 	{
-		publicAPI.GET("/home", home.GetHomes)
-		publicAPI.GET("/about", about.GetAbouts)
-		publicAPI.GET("/projects", projects.GetProjects)
-		publicAPI.POST("/contact", contact.CreateContact)
-		publicAPI.POST("/login", user.Login)
+		publicAPI.GET("/home", homeHandler.GetHomes)
+		publicAPI.GET("/home/:id", homeHandler.GetHome)
+		publicAPI.GET("/about", aboutHandler.GetAbouts)
+		publicAPI.GET("/about/:id", aboutHandler.GetAbout)
+		publicAPI.GET("/projects", projectHandler.GetLiveProjects)
+		publicAPI.GET("/projects/:slug", projectHandler.GetLiveProject) // A slug, or an ID for older links
+		publicAPI.GET("/tags", tagHandler.GetLiveTags)
+		publicAPI.GET("/contact/token", spamHandler.GetFormToken)
+		publicAPI.POST("/contact", contactHandler.CreateContact)
+		publicAPI.POST("/login", userHandler.Login)
+		publicAPI.POST("/login/2fa", userHandler.LoginTwoFactor)
+		publicAPI.POST("/refresh", userHandler.Refresh)
+		publicAPI.POST("/logout", userHandler.Logout)
+		publicAPI.POST("/password/forgot", userHandler.ForgotPassword)
+		publicAPI.POST("/password/reset", userHandler.ResetPassword)
+		publicAPI.GET("/invites/:token", inviteHandler.GetInviteByToken)
+		publicAPI.POST("/invites/accept", inviteHandler.AcceptInvite)
+	}
+
+	// ACCOUNT ROUTES - settings of the logged-in user, no extra permission needed
+	meAPI := r.Group("/api/me")
+	meAPI.Use(middleware.AuthMiddleware(stores.Sessions))
+	{
+		meAPI.PUT("/password", userHandler.ChangePassword)
+		meAPI.GET("/2fa", twoFactorHandler.Status)
+		meAPI.POST("/2fa/enroll", twoFactorHandler.Enroll)
+		meAPI.POST("/2fa/confirm", twoFactorHandler.Confirm)
+		meAPI.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		meAPI.DELETE("/2fa", twoFactorHandler.Disable)
 	}
 
-	// ADMIN ROUTES
+	// ADMIN ROUTES - each route requires its own permission
 	adminAPI := r.Group("/api/admin")
-	adminAPI.Use(middleware.AuthMiddleware())
+	adminAPI.Use(middleware.AuthMiddleware(stores.Sessions))
 	{
 		// Contact management
-		adminAPI.GET("/contact", contact.GetContacts)
-		adminAPI.DELETE("/contact/:id", contact.DeleteContact)
-		adminAPI.PUT("/contact", contact.UpdateContact)
+		adminAPI.GET("/contact", can(rbac.ContactsRead), contactHandler.GetContacts)
+		adminAPI.GET("/contact/counts", can(rbac.ContactsRead), contactHandler.GetCounts)
+		adminAPI.GET("/contact/:id", can(rbac.ContactsRead), contactHandler.GetContact)
+		adminAPI.POST("/contact/:id/replies", can(rbac.ContactsWrite), contactHandler.CreateReply)
+		adminAPI.PUT("/contact/:id/status", can(rbac.ContactsWrite), contactHandler.SetStatus)
+		adminAPI.PUT("/contact/:id/assignee", can(rbac.ContactsWrite), contactHandler.Assign)
+		adminAPI.POST("/contact/:id/notes", can(rbac.ContactsWrite), contactHandler.CreateNote)
+		adminAPI.DELETE("/contact/:id/notes/:noteId", can(rbac.ContactsWrite), contactHandler.DeleteNote)
+		adminAPI.DELETE("/contact/:id", can(rbac.ContactsWrite), contactHandler.DeleteContact)
+		adminAPI.PUT("/contact/:id", can(rbac.ContactsWrite), contactHandler.UpdateContact)
+		adminAPI.PUT("/contact", can(rbac.ContactsWrite), contactHandler.UpdateContact) // ID in the body
+		adminAPI.GET("/contact/auto-reply", can(rbac.ContactsRead), autoReplyHandler.GetSettings)
+		adminAPI.PUT("/contact/auto-reply", can(rbac.ContactsWrite), autoReplyHandler.UpdateSettings)
+
+		// Outgoing email that could not be delivered
+		adminAPI.GET("/outbox", can(rbac.MailManage), outboxHandler.GetEntries)
+		adminAPI.POST("/outbox/:id/requeue", can(rbac.MailManage), outboxHandler.RequeueEntry)
+
+		// Email templates
+		adminAPI.GET("/mail/templates", can(rbac.MailManage), mailHandler.GetTemplates)
+		adminAPI.GET("/mail/templates/:name/preview", can(rbac.MailManage), mailHandler.PreviewTemplate)
 
 		// Home management
-		adminAPI.GET("/home", home.GetHomes)
-		adminAPI.POST("/home", home.CreateHome)
-		adminAPI.PUT("/home", home.UpdateHome)
-		adminAPI.DELETE("/home/:id", home.DeleteHome)
+		adminAPI.GET("/home", can(rbac.HomeWrite), homeHandler.GetHomes)
+		adminAPI.POST("/home", can(rbac.HomeWrite), homeHandler.CreateHome)
+		adminAPI.GET("/home/:id", can(rbac.HomeWrite), homeHandler.GetHome)
+		adminAPI.PUT("/home/:id", can(rbac.HomeWrite), homeHandler.UpdateHome)
+		adminAPI.PUT("/home", can(rbac.HomeWrite), homeHandler.UpdateHome) // ID in the body
+		adminAPI.DELETE("/home/:id", can(rbac.HomeWrite), homeHandler.DeleteHome)
 
 		// About management
-		adminAPI.POST("/about", about.CreateAbout)
-		adminAPI.PUT("/about", about.UpdateAbout)
-		adminAPI.DELETE("/about/:id", about.DeleteAbout)
+		adminAPI.POST("/about", can(rbac.AboutWrite), aboutHandler.CreateAbout)
+		adminAPI.PUT("/about/:id", can(rbac.AboutWrite), aboutHandler.UpdateAbout)
+		adminAPI.PUT("/about", can(rbac.AboutWrite), aboutHandler.UpdateAbout) // ID in the body
+		adminAPI.DELETE("/about/:id", can(rbac.AboutWrite), aboutHandler.DeleteAbout)
 
 		// Project management
-		adminAPI.POST("/projects", projects.CreateProject)
-		adminAPI.PUT("/projects/:id", projects.UpdateProject)
-		adminAPI.DELETE("/projects/:id", projects.DeleteProject)
-		adminAPI.GET("/projects", projects.GetProjects) // Add this line!
+		adminAPI.POST("/projects", can(rbac.ProjectsWrite), projectHandler.CreateProject)
+		adminAPI.PUT("/projects/order", can(rbac.ProjectsWrite), projectHandler.ReorderProjects)
+		adminAPI.PUT("/projects/:id", can(rbac.ProjectsWrite), projectHandler.UpdateProject)
+		adminAPI.PUT("/projects/:id/featured", can(rbac.ProjectsWrite), projectHandler.SetFeatured)
+		adminAPI.DELETE("/projects/:id", can(rbac.ProjectsWrite), projectHandler.DeleteProject)
+		adminAPI.GET("/projects", can(rbac.ProjectsWrite), projectHandler.GetProjects)
+		adminAPI.GET("/projects/:id", can(rbac.ProjectsWrite), projectHandler.GetProject)
+
+		// Technology tags
+		adminAPI.GET("/tags", can(rbac.ProjectsWrite), tagHandler.GetTags)
+		adminAPI.POST("/tags", can(rbac.ProjectsWrite), tagHandler.CreateTag)
+		adminAPI.GET("/tags/:id", can(rbac.ProjectsWrite), tagHandler.GetTag)
+		adminAPI.PUT("/tags/:id", can(rbac.ProjectsWrite), tagHandler.UpdateTag)
+		adminAPI.DELETE("/tags/:id", can(rbac.ProjectsWrite), tagHandler.DeleteTag)
+		adminAPI.POST("/tags/:id/merge", can(rbac.ProjectsWrite), tagHandler.MergeTags)
 	}
 
-	// SUPER ADMIN ROUTES
+	// SUPER ADMIN ROUTES - user and role management
 	superAdminAPI := r.Group("/api/superadmin")
-	superAdminAPI.Use(middleware.AuthMiddleware())
-	superAdminAPI.Use(middleware.SuperAdminMiddleware())
+	superAdminAPI.Use(middleware.AuthMiddleware(stores.Sessions))
+	superAdminAPI.Use(can(rbac.UsersManage))
 	{
-		superAdminAPI.GET("/users", user.GetUsers)
-		superAdminAPI.POST("/users", user.CreateUser)
-		superAdminAPI.PUT("/users", user.UpdateUser)
-		superAdminAPI.DELETE("/users/:id", user.DeleteUser)
+		superAdminAPI.GET("/users", userHandler.GetUsers)
+		superAdminAPI.POST("/users", userHandler.CreateUser)
+		superAdminAPI.GET("/users/:id", userHandler.GetUser)
+		superAdminAPI.PUT("/users/:id", userHandler.UpdateUser)
+		superAdminAPI.PUT("/users", userHandler.UpdateUser) // ID in the body
+		superAdminAPI.DELETE("/users/:id", userHandler.DeleteUser)
+		superAdminAPI.DELETE("/users/:id/sessions", userHandler.RevokeSessions)
+		superAdminAPI.DELETE("/users/:id/2fa", twoFactorHandler.Reset)
+		superAdminAPI.POST("/users/:id/unlock", userHandler.UnlockUser)
+		superAdminAPI.GET("/login-attempts", lockoutHandler.GetAttempts)
+
+		superAdminAPI.GET("/invites", inviteHandler.GetInvites)
+		superAdminAPI.POST("/invites", inviteHandler.CreateInvite)
+		superAdminAPI.POST("/invites/:id/resend", inviteHandler.ResendInvite)
+		superAdminAPI.DELETE("/invites/:id", inviteHandler.RevokeInvite)
+		superAdminAPI.GET("/users/:id/roles", roleHandler.GetUserRoles)
+		superAdminAPI.PUT("/users/:id/roles", roleHandler.SetUserRoles)
+
+		superAdminAPI.GET("/permissions", roleHandler.GetPermissions)
+		superAdminAPI.GET("/roles", roleHandler.GetRoles)
+		superAdminAPI.POST("/roles", roleHandler.CreateRole)
+		superAdminAPI.PUT("/roles/:id", roleHandler.UpdateRole)
+		superAdminAPI.DELETE("/roles/:id", roleHandler.DeleteRole)
 	}
 }
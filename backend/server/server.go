@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"portfolio/problem"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,6 +40,10 @@ func SetupStaticFiles(r *gin.Engine) {
 	r.StaticFile("/admin", "./frontend/build/index.html")
 	r.StaticFile("/favicon.ico", "./frontend/build/favicon.ico")
 	r.NoRoute(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			problem.NoRoute(c)
+			return
+		}
 		c.File("./frontend/build/index.html")
 	})
 }
@@ -0,0 +1,56 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CAPTCHA drivers selectable with CAPTCHA_DRIVER
+const (
+	CaptchaNone = "none"
+	CaptchaStub = "stub"
+)
+
+// ErrCaptchaFailed is returned by a Verifier when the visitor did not solve the challenge
+var ErrCaptchaFailed = errors.New("captcha verification failed")
+
+// Verifier checks the CAPTCHA response sent with a form. Implementations for hosted services
+// (hCaptcha, Turnstile, reCAPTCHA) post the response and the visitor's IP to the provider.
+type Verifier interface {
+	Verify(ctx context.Context, response, remoteIP string) error // ErrCaptchaFailed when not solved
+}
+
+// StubVerifier accepts one fixed answer. It stands in for a real provider in development
+// and tests, so the form flow can be exercised offline.
+type StubVerifier struct {
+	Answer string
+}
+
+// Verify accepts the response only when it equals the configured answer
+func (v StubVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	if response == "" || response != v.Answer {
+		return ErrCaptchaFailed
+	}
+	return nil
+}
+
+// NewVerifierFromEnv creates the Verifier chosen by CAPTCHA_DRIVER, or nil when CAPTCHAs are off.
+//
+//   - none (default): no CAPTCHA
+//   - stub: accepts CAPTCHA_STUB_ANSWER (default "pass")
+func NewVerifierFromEnv() (Verifier, error) {
+	switch driver := os.Getenv("CAPTCHA_DRIVER"); driver {
+	case "", CaptchaNone:
+		return nil, nil
+	case CaptchaStub:
+		answer := os.Getenv("CAPTCHA_STUB_ANSWER")
+		if answer == "" {
+			answer = "pass"
+		}
+		return StubVerifier{Answer: answer}, nil
+	default:
+		return nil, fmt.Errorf("unknown CAPTCHA_DRIVER %q (use none or stub)", driver)
+	}
+}
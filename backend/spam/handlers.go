@@ -0,0 +1,33 @@
+package spam
+
+import (
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the public form token endpoint
+type Handler struct {
+	checker *Checker
+}
+
+// NewHandler creates spam handlers for the given checker
+func NewHandler(checker *Checker) *Handler {
+	return &Handler{checker: checker}
+}
+
+// GetFormToken returns a signed token for the contact form to send back as "form_token" from
+// the same IP address, and whether a CAPTCHA response is expected
+func (h *Handler) GetFormToken(c *gin.Context) {
+	token, err := h.checker.IssueToken(c.ClientIP())
+	if err != nil {
+		fmt.Println("Form token error:", err)
+		problem.Respond(c, problem.Internal("Token could not be created"))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"token": token, "captcha": h.checker.CaptchaEnabled()})
+}
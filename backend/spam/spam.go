@@ -0,0 +1,215 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reasons a submission is flagged as spam
+const (
+	ReasonHoneypot     = "honeypot"       // The hidden field was filled in
+	ReasonMissingToken = "missing_token"  // No form token, unless SPAM_REQUIRE_TOKEN is off
+	ReasonInvalidToken = "invalid_token"  // Forged, from another secret or issued to another IP address
+	ReasonExpiredToken = "expired_token"  // Older than SPAM_TOKEN_MAX_AGE
+	ReasonTooFast      = "too_fast"       // Sent sooner than SPAM_MIN_FILL_TIME after the form was loaded
+	ReasonTooManyLinks = "too_many_links" // More than SPAM_MAX_LINKS links, or any link in the name
+	ReasonBlockedWord  = "blocked_word"   // Contains a word from SPAM_BLOCKLIST
+)
+
+// defaultBlocklist is used when SPAM_BLOCKLIST is not set
+var defaultBlocklist = []string{"viagra", "cialis", "casino", "backlinks", "seo services"}
+
+// linkPattern matches URLs and bare www. hosts
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)`)
+
+// Config configures the checks
+type Config struct {
+	MinFillTime  time.Duration // Minimum time between loading the form and sending it
+	TokenMaxAge  time.Duration // How long a form token stays valid
+	TokenSecret  string        // HMAC key for form tokens; random per process when empty
+	RequireToken bool          // Flag submissions without a form token
+	RateLimit    int           // Submissions per IP address and RateWindow
+	RateWindow   time.Duration
+	MaxLinks     int      // Links allowed in a message
+	Blocklist    []string // Case-insensitive words and phrases that mark a message as spam
+}
+
+// ConfigFromEnv reads SPAM_MIN_FILL_TIME (default 3s), SPAM_TOKEN_MAX_AGE (default 2h),
+// SPAM_TOKEN_SECRET, SPAM_REQUIRE_TOKEN (default true), SPAM_RATE_LIMIT (default 5) per
+// SPAM_RATE_WINDOW (default 1h), SPAM_MAX_LINKS (default 2) and SPAM_BLOCKLIST (comma-separated)
+func ConfigFromEnv() Config {
+	c := Config{
+		MinFillTime:  3 * time.Second,
+		TokenMaxAge:  2 * time.Hour,
+		TokenSecret:  os.Getenv("SPAM_TOKEN_SECRET"),
+		RequireToken: true,
+		RateLimit:    5,
+		RateWindow:   time.Hour,
+		MaxLinks:     2,
+		Blocklist:    defaultBlocklist,
+	}
+	if d, err := time.ParseDuration(os.Getenv("SPAM_MIN_FILL_TIME")); err == nil && d >= 0 {
+		c.MinFillTime = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("SPAM_TOKEN_MAX_AGE")); err == nil && d > 0 {
+		c.TokenMaxAge = d
+	}
+	if b, err := strconv.ParseBool(os.Getenv("SPAM_REQUIRE_TOKEN")); err == nil {
+		c.RequireToken = b
+	}
+	if n, err := strconv.Atoi(os.Getenv("SPAM_RATE_LIMIT")); err == nil && n > 0 {
+		c.RateLimit = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("SPAM_RATE_WINDOW")); err == nil && d > 0 {
+		c.RateWindow = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("SPAM_MAX_LINKS")); err == nil && n >= 0 {
+		c.MaxLinks = n
+	}
+	if list, ok := os.LookupEnv("SPAM_BLOCKLIST"); ok {
+		c.Blocklist = nil
+		for _, word := range strings.Split(list, ",") {
+			if word = strings.TrimSpace(word); word != "" {
+				c.Blocklist = append(c.Blocklist, word)
+			}
+		}
+	}
+	return c
+}
+
+// History returns the receive times of the latest submissions from an IP address, newest first.
+// The contact store implements it, so the limit holds across server instances.
+type History interface {
+	RecentSubmissions(ctx context.Context, ip string, limit int) ([]time.Time, error)
+}
+
+// Submission is what the checks look at in a contact form post
+type Submission struct {
+	IP        string
+	Honeypot  string // Value of the hidden field; people never see it, bots fill it in
+	FormToken string
+	Captcha   string // CAPTCHA response, checked only when a Verifier is configured
+	Name      string
+	Message   string
+}
+
+// Checker runs the spam and abuse checks on contact form submissions
+type Checker struct {
+	config    Config
+	tokens    *Tokens
+	history   History
+	verifier  Verifier // Nil when CAPTCHAs are off
+	blocklist *regexp.Regexp
+}
+
+// NewChecker creates a checker; verifier may be nil
+func NewChecker(config Config, history History, verifier Verifier) (*Checker, error) {
+	if config.TokenSecret == "" {
+		log.Println("SPAM_TOKEN_SECRET is not set, using a temporary secret for contact form tokens")
+	}
+	tokens, err := NewTokens([]byte(config.TokenSecret), config.TokenMaxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := &Checker{config: config, tokens: tokens, history: history, verifier: verifier}
+	if len(config.Blocklist) > 0 {
+		words := make([]string, len(config.Blocklist))
+		for i, word := range config.Blocklist {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		ch.blocklist = regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+	}
+	return ch, nil
+}
+
+// NewCheckerFromEnv creates a checker configured by ConfigFromEnv and NewVerifierFromEnv
+func NewCheckerFromEnv(history History) (*Checker, error) {
+	verifier, err := NewVerifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewChecker(ConfigFromEnv(), history, verifier)
+}
+
+// IssueToken returns a form token for a form loaded now by the client at ip
+func (ch *Checker) IssueToken(ip string) (string, error) {
+	return ch.tokens.Issue(ip, time.Now().UTC())
+}
+
+// CaptchaEnabled reports whether submissions must carry a CAPTCHA response
+func (ch *Checker) CaptchaEnabled() bool {
+	return ch.verifier != nil
+}
+
+// Allow returns how long the IP address must wait before it may submit the form again, or 0
+func (ch *Checker) Allow(ctx context.Context, ip string) (time.Duration, error) {
+	recent, err := ch.history.RecentSubmissions(ctx, ip, ch.config.RateLimit)
+	if err != nil {
+		return 0, err
+	}
+	if len(recent) < ch.config.RateLimit {
+		return 0, nil
+	}
+	// The oldest of the last RateLimit submissions has to leave the window first
+	wait := recent[len(recent)-1].Add(ch.config.RateWindow).Sub(time.Now().UTC())
+	return max(wait, 0), nil
+}
+
+// VerifyCaptcha checks the CAPTCHA response when a Verifier is configured
+func (ch *Checker) VerifyCaptcha(ctx context.Context, s Submission) error {
+	if ch.verifier == nil {
+		return nil
+	}
+	return ch.verifier.Verify(ctx, s.Captcha, s.IP)
+}
+
+// Check returns the reasons the submission looks like spam; none means it looks genuine
+func (ch *Checker) Check(s Submission) []string {
+	var reasons []string
+
+	if strings.TrimSpace(s.Honeypot) != "" {
+		reasons = append(reasons, ReasonHoneypot)
+	}
+
+	if reason := ch.checkToken(s.FormToken, s.IP); reason != "" {
+		reasons = append(reasons, reason)
+	}
+
+	if linkPattern.MatchString(s.Name) || len(linkPattern.FindAllStringIndex(s.Message, -1)) > ch.config.MaxLinks {
+		reasons = append(reasons, ReasonTooManyLinks)
+	}
+
+	if ch.blocklist != nil && ch.blocklist.MatchString(s.Name+"\n"+s.Message) {
+		reasons = append(reasons, ReasonBlockedWord)
+	}
+	return reasons
+}
+
+// checkToken returns the reason a form token sent from ip fails, or "" when it passes
+func (ch *Checker) checkToken(token, ip string) string {
+	if token == "" {
+		if ch.config.RequireToken {
+			return ReasonMissingToken
+		}
+		return ""
+	}
+
+	now := time.Now().UTC()
+	issued, err := ch.tokens.Verify(token, ip, now)
+	switch {
+	case errors.Is(err, ErrExpiredToken):
+		return ReasonExpiredToken
+	case err != nil:
+		return ReasonInvalidToken
+	case now.Sub(issued) < ch.config.MinFillTime:
+		return ReasonTooFast
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// history is a History of fixed submission times, newest first
+type history []time.Time
+
+func (h history) RecentSubmissions(ctx context.Context, ip string, limit int) ([]time.Time, error) {
+	return h[:min(limit, len(h))], nil
+}
+
+var testConfig = Config{
+	MinFillTime:  3 * time.Second,
+	TokenMaxAge:  time.Hour,
+	TokenSecret:  "test",
+	RequireToken: true,
+	RateLimit:    3,
+	RateWindow:   time.Hour,
+	MaxLinks:     2,
+	Blocklist:    []string{"casino", "seo services"},
+}
+
+// testIP is the address the test submissions come from
+const testIP = "192.0.2.1"
+
+// issuedAgo returns a token of ch for a form loaded d ago from testIP
+func issuedAgo(t *testing.T, ch *Checker, d time.Duration) string {
+	t.Helper()
+	token, err := ch.tokens.Issue(testIP, time.Now().UTC().Add(-d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestCheck(t *testing.T) {
+	ch, err := NewChecker(testConfig, history{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewChecker(Config{TokenSecret: "other", TokenMaxAge: time.Hour}, history{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := issuedAgo(t, ch, time.Minute)
+
+	tests := []struct {
+		name string
+		s    Submission
+		want []string
+	}{
+		{"genuine", Submission{IP: testIP, FormToken: valid, Name: "Ann", Message: "See https://example.com and www.example.org"}, nil},
+		{"honeypot", Submission{IP: testIP, FormToken: valid, Honeypot: "http://spam.example", Name: "Ann", Message: "Hi"}, []string{ReasonHoneypot}},
+		{"missing token", Submission{IP: testIP, Name: "Ann", Message: "Hi"}, []string{ReasonMissingToken}},
+		{"forged token", Submission{IP: testIP, FormToken: valid + "x", Name: "Ann", Message: "Hi"}, []string{ReasonInvalidToken}},
+		{"token from another IP", Submission{IP: "203.0.113.9", FormToken: valid, Name: "Ann", Message: "Hi"}, []string{ReasonInvalidToken}},
+		{"token of another secret", Submission{IP: testIP, FormToken: issuedAgo(t, other, time.Minute), Name: "Ann", Message: "Hi"}, []string{ReasonInvalidToken}},
+		{"expired token", Submission{IP: testIP, FormToken: issuedAgo(t, ch, 2*time.Hour), Name: "Ann", Message: "Hi"}, []string{ReasonExpiredToken}},
+		{"too fast", Submission{IP: testIP, FormToken: issuedAgo(t, ch, time.Second), Name: "Ann", Message: "Hi"}, []string{ReasonTooFast}},
+		{"too many links", Submission{IP: testIP, FormToken: valid, Name: "Ann", Message: "http://a.example https://b.example www.c.example"}, []string{ReasonTooManyLinks}},
+		{"link in name", Submission{IP: testIP, FormToken: valid, Name: "www.example.com", Message: "Hi"}, []string{ReasonTooManyLinks}},
+		{"blocked word", Submission{IP: testIP, FormToken: valid, Name: "Ann", Message: "Best CASINO in town"}, []string{ReasonBlockedWord}},
+		{"blocked phrase", Submission{IP: testIP, FormToken: valid, Name: "Ann", Message: "We offer SEO services"}, []string{ReasonBlockedWord}},
+		{"blocked word inside another word", Submission{IP: testIP, FormToken: valid, Name: "Ann", Message: "On occasion, casinos"}, nil},
+		{"several reasons", Submission{IP: testIP, Honeypot: "x", Name: "Ann", Message: "casino"}, []string{ReasonHoneypot, ReasonMissingToken, ReasonBlockedWord}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ch.Check(tt.s); !slices.Equal(got, tt.want) {
+				t.Errorf("Check = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWithoutRequiredToken(t *testing.T) {
+	config := testConfig
+	config.RequireToken = false
+	ch, err := NewChecker(config, history{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ch.Check(Submission{Name: "Ann", Message: "Hi"}); len(got) != 0 {
+		t.Errorf("Check without token = %v, want nothing", got)
+	}
+	// A token that is sent is still checked
+	if got := ch.Check(Submission{FormToken: "forged", Name: "Ann", Message: "Hi"}); !slices.Equal(got, []string{ReasonInvalidToken}) {
+		t.Errorf("Check with a forged token = %v, want %s", got, ReasonInvalidToken)
+	}
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		history history
+		want    time.Duration // Upper bound, the check runs a moment after now
+	}{
+		{"no submissions", history{}, 0},
+		{"below the limit", history{now.Add(-time.Minute), now.Add(-2 * time.Minute)}, 0},
+		{"at the limit", history{now.Add(-time.Minute), now.Add(-2 * time.Minute), now.Add(-10 * time.Minute)}, 50 * time.Minute},
+		{"oldest outside the window", history{now.Add(-time.Minute), now.Add(-2 * time.Minute), now.Add(-2 * time.Hour)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, err := NewChecker(testConfig, tt.history, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wait, err := ch.Allow(context.Background(), "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wait > tt.want || wait < tt.want-time.Second {
+				t.Errorf("Allow = %v, want %v", wait, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	if c := ConfigFromEnv(); !c.RequireToken || c.TokenMaxAge != 2*time.Hour || !slices.Equal(c.Blocklist, defaultBlocklist) {
+		t.Errorf("default config = %+v, want the token required for 2h and the default blocklist", c)
+	}
+
+	t.Setenv("SPAM_REQUIRE_TOKEN", "false")
+	t.Setenv("SPAM_BLOCKLIST", " crypto , ,loans")
+	t.Setenv("SPAM_MIN_FILL_TIME", "0s")
+	c := ConfigFromEnv()
+	if c.RequireToken || c.MinFillTime != 0 || !slices.Equal(c.Blocklist, []string{"crypto", "loans"}) {
+		t.Errorf("config = %+v, want no token required, no fill time and blocklist [crypto loans]", c)
+	}
+}
+
+func TestVerifyCaptcha(t *testing.T) {
+	ch, err := NewChecker(testConfig, history{}, StubVerifier{Answer: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, answer := range []string{"", "fail"} {
+		if err := ch.VerifyCaptcha(context.Background(), Submission{Captcha: answer}); !errors.Is(err, ErrCaptchaFailed) {
+			t.Errorf("answer %q = %v, want ErrCaptchaFailed", answer, err)
+		}
+	}
+	if err := ch.VerifyCaptcha(context.Background(), Submission{Captcha: "pass"}); err != nil {
+		t.Errorf("right answer = %v", err)
+	}
+}
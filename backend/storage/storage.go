@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"os"
+	"portfolio/about"
+	"portfolio/auth"
+	"portfolio/autoreply"
+	"portfolio/contact"
+	"portfolio/db"
+	"portfolio/home"
+	"portfolio/invite"
+	"portfolio/listing"
+	"portfolio/lockout"
+	"portfolio/outbox"
+	"portfolio/projects"
+	"portfolio/rbac"
+	"portfolio/tags"
+	"portfolio/twofactor"
+	"portfolio/user"
+)
+
+// Stores bundles the persistence implementations used by the handlers
+type Stores struct {
+	Home        home.Store
+	About       about.Store
+	Projects    projects.Store
+	Tags        tags.Store
+	Contact     contact.Store
+	Users       user.Store
+	ResetTokens user.ResetTokenStore
+	Sessions    auth.SessionStore
+	Roles       rbac.Store
+	TwoFactor   twofactor.Store
+	Lockout     lockout.Store
+	Invites     invite.Store
+	Outbox      outbox.Store
+	AutoReply   autoreply.Store
+}
+
+// NewSQL creates stores backed by a PostgreSQL or SQLite database
+func NewSQL(conn *db.DB) *Stores {
+	return &Stores{
+		Home:        home.NewSQLStore(conn),
+		About:       about.NewSQLStore(conn),
+		Projects:    projects.NewSQLStore(conn),
+		Tags:        tags.NewSQLStore(conn),
+		Contact:     contact.NewSQLStore(conn),
+		Users:       user.NewSQLStore(conn),
+		ResetTokens: user.NewSQLResetTokenStore(conn),
+		Sessions:    auth.NewSQLSessionStore(conn),
+		Roles:       rbac.NewSQLStore(conn),
+		TwoFactor:   twofactor.NewSQLStore(conn),
+		Lockout:     lockout.NewSQLStore(conn),
+		Invites:     invite.NewSQLStore(conn),
+		Outbox:      outbox.NewSQLStore(conn),
+		AutoReply:   autoreply.NewSQLStore(conn),
+	}
+}
+
+// NewMemory creates empty stores that live in process memory
+func NewMemory() *Stores {
+	mailOutbox := outbox.NewMemoryStore()
+	return &Stores{
+		Home:        home.NewMemoryStore(),
+		About:       about.NewMemoryStore(),
+		Projects:    projects.NewMemoryStore(),
+		Tags:        tags.NewMemoryStore(),
+		Contact:     contact.NewMemoryStore(mailOutbox),
+		Users:       user.NewMemoryStore(),
+		ResetTokens: user.NewMemoryResetTokenStore(),
+		Sessions:    auth.NewMemorySessionStore(),
+		Roles:       rbac.NewMemoryStore(),
+		TwoFactor:   twofactor.NewMemoryStore(),
+		Lockout:     lockout.NewMemoryStore(),
+		Invites:     invite.NewMemoryStore(),
+		Outbox:      mailOutbox,
+		AutoReply:   autoreply.NewMemoryStore(),
+	}
+}
+
+// Open creates the stores for the backend selected by DB_DRIVER.
+// For SQL backends the schema is migrated unless DB_AUTO_MIGRATE is "false".
+// The returned function releases the database connection.
+func Open(ctx context.Context) (*Stores, func()) {
+	var stores *Stores
+	closeFn := func() {}
+
+	if db.Driver() == db.DriverMemory {
+		log.Println("Using in-memory storage, data will be lost on restart")
+		stores = NewMemory()
+	} else {
+		conn := db.ConnectDB()
+		closeFn = func() { conn.Close() }
+
+		// Bring the schema up to date unless it is managed separately
+		if os.Getenv("DB_AUTO_MIGRATE") != "false" {
+			if err := db.MigrateUp(ctx, conn); err != nil {
+				log.Fatalf("Migration error: %v", err)
+			}
+		}
+		stores = NewSQL(conn)
+
+		// Give projects saved before slugs existed a slug
+		if n, err := projects.NewSQLStore(conn).BackfillSlugs(ctx); err != nil {
+			log.Printf("Could not generate project slugs: %v", err)
+		} else if n > 0 {
+			log.Printf("Generated slugs for %d projects", n)
+		}
+
+		// Turn technologies left by the tags migration into tags
+		if n, err := tags.NewSQLStore(conn).ImportTechnologies(ctx); err != nil {
+			log.Printf("Could not import project technologies as tags: %v", err)
+		} else if n > 0 {
+			log.Printf("Imported the technologies of %d projects as tags", n)
+		}
+	}
+
+	seedAdmin(ctx, stores)
+	return stores, closeFn
+}
+
+// seedAdmin creates the "admin" super admin account from ADMIN_PASSWORD when there are
+// no users yet, so a fresh database (or the memory backend) can be logged into right away
+func seedAdmin(ctx context.Context, stores *Stores) {
+	users := stores.Users
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		return
+	}
+
+	_, total, err := users.List(ctx, user.Filter{Params: listing.Params{PerPage: 1}})
+	if err != nil {
+		log.Printf("Could not check for existing users: %v", err)
+		return
+	}
+	if total > 0 {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		log.Printf("Could not hash admin password: %v", err)
+		return
+	}
+
+	admin := user.User{Username: "admin", Password: hashedPassword, Email: os.Getenv("ADMIN_EMAIL")}
+	if err := users.Create(ctx, &admin); err != nil {
+		log.Printf("Could not create admin user: %v", err)
+		return
+	}
+	if err := stores.Roles.SetUserRoles(ctx, admin.ID, []string{rbac.SuperAdminRole}); err != nil {
+		log.Printf("Could not assign the superadmin role: %v", err)
+		return
+	}
+	log.Println("Created initial admin user")
+}
@@ -0,0 +1,618 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"portfolio/auth"
+	"portfolio/contact"
+	"portfolio/db"
+	"portfolio/listing"
+	"portfolio/mail"
+	"portfolio/outbox"
+	"portfolio/projects"
+	"portfolio/rbac"
+	"portfolio/tags"
+	"portfolio/user"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backends opens each storage backend for a test: memory, and the SQL databases of
+// sqlBackends. Every backend runs the same checks, so the implementations cannot drift apart.
+func backends(t *testing.T, check func(t *testing.T, stores *Stores)) {
+	t.Run("memory", func(t *testing.T) {
+		check(t, NewMemory())
+	})
+	sqlBackends(t, func(t *testing.T, conn *db.DB) {
+		check(t, NewSQL(conn))
+	})
+}
+
+// sqlBackends opens each SQL database for a test, migrated up: SQLite in a temporary file
+// and, when TEST_DATABASE_URL names an empty database the test may write to, PostgreSQL
+func sqlBackends(t *testing.T, check func(t *testing.T, conn *db.DB)) {
+	t.Run("sqlite", func(t *testing.T) {
+		t.Setenv("DB_DRIVER", db.DriverSQLite)
+		t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+		conn := db.ConnectDB()
+		t.Cleanup(func() { conn.Close() })
+		if err := db.MigrateUp(context.Background(), conn); err != nil {
+			t.Fatal(err)
+		}
+		check(t, conn)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		url := os.Getenv("TEST_DATABASE_URL")
+		if url == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		t.Setenv("DB_DRIVER", db.DriverPostgres)
+		t.Setenv("DATABASE_URL", url)
+		conn := db.ConnectDB()
+		t.Cleanup(func() {
+			// Leave the database empty for the next check
+			migrations, err := db.LoadMigrations(conn.Dialect)
+			if err == nil {
+				err = db.MigrateDown(context.Background(), conn, len(migrations))
+			}
+			if err != nil {
+				t.Errorf("migrating down: %v", err)
+			}
+			conn.Close()
+		})
+		if err := db.MigrateUp(context.Background(), conn); err != nil {
+			t.Fatal(err)
+		}
+		check(t, conn)
+	})
+}
+
+func TestUserStore(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		users := stores.Users
+
+		u := user.User{Username: "ann", Password: "hash1", Email: "Ann@Example.com"}
+		if err := users.Create(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+		if u.ID == 0 {
+			t.Fatal("Create did not set the ID")
+		}
+		if err := users.Create(ctx, &user.User{Username: "ann", Password: "hash2"}); !errors.Is(err, user.ErrDuplicate) {
+			t.Errorf("Create with a taken username = %v, want ErrDuplicate", err)
+		}
+		bob := user.User{Username: "bob", Password: "hash2", Email: "bob@example.com"}
+		if err := users.Create(ctx, &bob); err != nil {
+			t.Fatal(err)
+		}
+		if err := users.Update(ctx, user.User{ID: bob.ID, Username: "bob", Email: "Ann@Example.com"}); !errors.Is(err, user.ErrDuplicate) {
+			t.Errorf("Update to a taken email = %v, want ErrDuplicate", err)
+		}
+
+		got, err := users.Get(ctx, u.ID)
+		if err != nil || got.Username != "ann" || got.Password != "" {
+			t.Errorf("Get = %+v, %v; want ann without the password", got, err)
+		}
+		if got, err := users.GetByUsername(ctx, "ann"); err != nil || got.Password != "hash1" {
+			t.Errorf("GetByUsername = %+v, %v; want the password hash", got, err)
+		}
+		if got, err := users.GetByEmail(ctx, "ann@example.com"); err != nil || got.ID != u.ID || got.Password != "" {
+			t.Errorf("GetByEmail = %+v, %v; want ann without the password, ignoring case", got, err)
+		}
+
+		// An update without a password keeps the current one
+		if err := users.Update(ctx, user.User{ID: u.ID, Username: "anna", Email: "anna@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := users.GetByUsername(ctx, "anna"); got.Password != "hash1" {
+			t.Errorf("password after update = %q, want hash1", got.Password)
+		}
+		if err := users.SetPassword(ctx, u.ID, "hash3"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := users.GetByUsername(ctx, "anna"); got.Password != "hash3" {
+			t.Errorf("password after SetPassword = %q, want hash3", got.Password)
+		}
+
+		if _, total, err := users.List(ctx, user.Filter{Search: "ANNA"}); err != nil || total != 1 {
+			t.Errorf("List found %d, %v; want 1", total, err)
+		}
+
+		for name, err := range map[string]error{
+			"Get":         second(users.Get(ctx, 999)),
+			"Update":      users.Update(ctx, user.User{ID: 999, Username: "x"}),
+			"SetPassword": users.SetPassword(ctx, 999, "x"),
+			"Delete":      users.Delete(ctx, 999),
+		} {
+			if !errors.Is(err, user.ErrNotFound) {
+				t.Errorf("%s of a missing user = %v, want ErrNotFound", name, err)
+			}
+		}
+		if err := users.Delete(ctx, u.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := users.Get(ctx, u.ID); !errors.Is(err, user.ErrNotFound) {
+			t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestSessionStore(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		u := createUser(t, stores, "ann")
+		now := time.Now().UTC()
+
+		for _, id := range []string{"s1", "s2"} {
+			if err := stores.Sessions.CreateSession(ctx, auth.Session{ID: id, UserID: u.ID, CreatedAt: now}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		token := auth.RefreshToken{Hash: "h1", SessionID: "s1", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+		if err := stores.Sessions.SaveRefreshToken(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := stores.Sessions.GetRefreshToken(ctx, "h1"); err != nil || got.SessionID != "s1" || got.UsedAt != nil {
+			t.Errorf("GetRefreshToken = %+v, %v; want an unused token of s1", got, err)
+		}
+
+		// Only the first use of a token counts
+		if marked, err := stores.Sessions.MarkRefreshTokenUsed(ctx, "h1", now); err != nil || !marked {
+			t.Errorf("first MarkRefreshTokenUsed = %v, %v; want true", marked, err)
+		}
+		if marked, err := stores.Sessions.MarkRefreshTokenUsed(ctx, "h1", now); err != nil || marked {
+			t.Errorf("second MarkRefreshTokenUsed = %v, %v; want false", marked, err)
+		}
+
+		if err := stores.Sessions.RevokeUserSessions(ctx, u.ID, now); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"s1", "s2"} {
+			if s, err := stores.Sessions.GetSession(ctx, id); err != nil || s.RevokedAt == nil {
+				t.Errorf("session %s = %+v, %v; want revoked", id, s, err)
+			}
+		}
+	})
+}
+
+func TestProjectStore(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		store := stores.Projects
+
+		chat := createProject(t, stores, projects.Project{Name: "Chat App", Description: "Realtime chat"})
+		other := createProject(t, stores, projects.Project{Name: "Chat App", Description: "Another one"})
+		draft := createProject(t, stores, projects.Project{Name: "Draft", Status: projects.StatusDraft})
+		if chat.Slug != "chat-app" || other.Slug != "chat-app-2" {
+			t.Errorf("slugs = %q, %q; want chat-app, chat-app-2", chat.Slug, other.Slug)
+		}
+		if err := store.Create(ctx, &projects.Project{Name: "X", Slug: "chat-app", Status: projects.StatusPublished}); !errors.Is(err, projects.ErrSlugTaken) {
+			t.Errorf("Create with a taken slug = %v, want ErrSlugTaken", err)
+		}
+
+		// New projects come first in the order
+		if got := listIDs(t, store, projects.Filter{Params: sortBy("position")}); !slices.Equal(got, []int{draft.ID, other.ID, chat.ID}) {
+			t.Errorf("order = %v, want newest first", got)
+		}
+		if err := store.Reorder(ctx, []int{chat.ID, draft.ID, other.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if got := listIDs(t, store, projects.Filter{Params: sortBy("position")}); !slices.Equal(got, []int{chat.ID, draft.ID, other.ID}) {
+			t.Errorf("order after Reorder = %v, want %d first", got, chat.ID)
+		}
+		if err := store.Reorder(ctx, []int{other.ID, draft.ID, chat.ID, 999}); !errors.Is(err, projects.ErrNotFound) {
+			t.Errorf("Reorder with a missing ID = %v, want ErrNotFound", err)
+		}
+		if err := store.Reorder(ctx, []int{other.ID, draft.ID}); !errors.Is(err, projects.ErrIncompleteOrder) {
+			t.Errorf("Reorder leaving out a project = %v, want ErrIncompleteOrder", err)
+		}
+		if err := store.Reorder(ctx, []int{other.ID, other.ID, draft.ID}); !errors.Is(err, projects.ErrIncompleteOrder) {
+			t.Errorf("Reorder listing a project twice = %v, want ErrIncompleteOrder", err)
+		}
+		if got := listIDs(t, store, projects.Filter{Params: sortBy("position")}); !slices.Equal(got, []int{chat.ID, draft.ID, other.ID}) {
+			t.Errorf("order after rejected Reorders = %v, want it unchanged", got)
+		}
+
+		now := time.Now().UTC()
+		if got := listIDs(t, store, projects.Filter{LiveAt: &now}); !slices.Equal(got, []int{chat.ID, other.ID}) {
+			t.Errorf("live projects = %v, want the published ones", got)
+		}
+		if got := listIDs(t, store, projects.Filter{Search: "realtime"}); !slices.Equal(got, []int{chat.ID}) {
+			t.Errorf("search = %v, want %d", got, chat.ID)
+		}
+
+		// Snippets escape the project text, on every backend, and mark only the matches
+		widget := createProject(t, stores, projects.Project{Name: "Widget", Description: `An embeddable widget <script> alert("widget") </script>`})
+		found, _, err := store.List(ctx, projects.Filter{Search: "alert"})
+		if err != nil || len(found) != 1 || found[0].ID != widget.ID {
+			t.Fatalf("search for alert = %+v, %v; want the widget", found, err)
+		}
+		snippet := found[0].Snippet
+		if strings.Contains(snippet, "<script>") || !strings.Contains(snippet, "&lt;script&gt;") {
+			t.Errorf("snippet = %q, want the script tag escaped", snippet)
+		}
+		if !strings.Contains(snippet, "<mark>alert") || strings.Count(snippet, "<mark>") != strings.Count(snippet, "</mark>") {
+			t.Errorf("snippet = %q, want the match in <mark>", snippet)
+		}
+		if err := store.Delete(ctx, widget.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// A new slug keeps the old one as a redirect, and frees nothing for other projects
+		chat.Slug = "chat"
+		if err := store.Update(ctx, chat); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := store.GetBySlug(ctx, "chat"); err != nil || p.ID != chat.ID {
+			t.Errorf("GetBySlug = %+v, %v; want %d", p, err, chat.ID)
+		}
+		if p, err := store.GetByOldSlug(ctx, "chat-app"); err != nil || p.ID != chat.ID {
+			t.Errorf("GetByOldSlug = %+v, %v; want %d", p, err, chat.ID)
+		}
+		other.Slug = "chat"
+		if err := store.Update(ctx, other); !errors.Is(err, projects.ErrSlugTaken) {
+			t.Errorf("Update to a taken slug = %v, want ErrSlugTaken", err)
+		}
+
+		// At most two featured projects
+		for _, id := range []int{chat.ID, other.ID, chat.ID} {
+			if err := store.SetFeatured(ctx, id, true, 2); err != nil {
+				t.Errorf("SetFeatured(%d) = %v", id, err)
+			}
+		}
+		if err := store.SetFeatured(ctx, draft.ID, true, 2); !errors.Is(err, projects.ErrFeaturedLimit) {
+			t.Errorf("SetFeatured over the limit = %v, want ErrFeaturedLimit", err)
+		}
+		if err := store.SetFeatured(ctx, 999, true, 2); !errors.Is(err, projects.ErrNotFound) {
+			t.Errorf("SetFeatured of a missing project = %v, want ErrNotFound", err)
+		}
+		featured := true
+		if got := listIDs(t, store, projects.Filter{Featured: &featured}); len(got) != 2 {
+			t.Errorf("featured = %v, want 2 projects", got)
+		}
+
+		// The scheduler publishes the draft once its time has passed
+		publishAt := now.Add(-time.Minute)
+		draft.PublishAt = &publishAt
+		if err := store.Update(ctx, draft); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := store.ApplySchedule(ctx, now); err != nil || n != 1 {
+			t.Errorf("ApplySchedule = %d, %v; want 1", n, err)
+		}
+		if p, _ := store.Get(ctx, draft.ID); p.Status != projects.StatusPublished || p.PublishAt != nil {
+			t.Errorf("scheduled draft = %+v, want published without publish_at", p)
+		}
+
+		if err := store.Delete(ctx, other.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Delete(ctx, other.ID); !errors.Is(err, projects.ErrNotFound) {
+			t.Errorf("second Delete = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestTagStore(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		store := stores.Tags
+
+		refs, err := store.Resolve(ctx, []string{"Go", "go", "React"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refs) != 2 || refs[0].Name != "Go" || refs[1].Name != "React" {
+			t.Fatalf("Resolve = %+v, want Go and React once each", refs)
+		}
+		if tag, err := store.Lookup(ctx, "GO"); err != nil || tag.ID != refs[0].ID {
+			t.Errorf("Lookup = %+v, %v; want Go ignoring case", tag, err)
+		}
+
+		p := createProject(t, stores, projects.Project{Name: "Chat"})
+		if err := store.SetProjectTags(ctx, p.ID, []int{refs[1].ID, refs[0].ID}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.ProjectTags(ctx, []int{p.ID})
+		if err != nil || len(got[p.ID]) != 2 || got[p.ID][0].Name != "React" {
+			t.Errorf("ProjectTags = %+v, %v; want React, Go in that order", got, err)
+		}
+
+		// Merging makes React an alias of Go
+		if err := store.Merge(ctx, refs[0].ID, []int{refs[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+		if tag, err := store.Lookup(ctx, "react"); err != nil || tag.ID != refs[0].ID {
+			t.Errorf("Lookup of a merged name = %+v, %v; want Go", tag, err)
+		}
+		if ids, err := store.ProjectIDs(ctx, refs[0].ID); err != nil || !slices.Equal(ids, []int{p.ID}) {
+			t.Errorf("ProjectIDs = %v, %v; want [%d]", ids, err, p.ID)
+		}
+		if err := store.Delete(ctx, refs[1].ID); !errors.Is(err, tags.ErrNotFound) {
+			t.Errorf("Delete of a merged tag = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestImportTechnologies(t *testing.T) {
+	sqlBackends(t, func(t *testing.T, conn *db.DB) {
+		ctx := context.Background()
+		stores := NewSQL(conn)
+		store := tags.NewSQLStore(conn)
+
+		// ReactJS was merged into React before the import, so it is an alias
+		refs, err := store.Resolve(ctx, []string{"React", "ReactJS"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Merge(ctx, refs[0].ID, []int{refs[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		// The technologies the tags migration split off two older projects
+		chat := createProject(t, stores, projects.Project{Name: "Chat"})
+		shop := createProject(t, stores, projects.Project{Name: "Shop"})
+		rows := []struct {
+			projectID int
+			name      string
+		}{
+			{chat.ID, "go"}, {chat.ID, "reactjs"}, {chat.ID, "GO"}, {chat.ID, "PostgreSQL"},
+			{shop.ID, "Go"}, {shop.ID, "React"},
+		}
+		for i, row := range rows {
+			if _, err := conn.ExecContext(ctx, "INSERT INTO project_tag_imports (project_id, position, name) VALUES ($1, $2, $3)", row.projectID, i, row.name); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		n, err := store.ImportTechnologies(ctx)
+		if err != nil || n != 2 {
+			t.Fatalf("ImportTechnologies = %d, %v; want 2 projects", n, err)
+		}
+
+		got, err := store.ProjectTags(ctx, []int{chat.ID, shop.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := func(refs []tags.Ref) []string {
+			var out []string
+			for _, r := range refs {
+				out = append(out, r.Name)
+			}
+			return out
+		}
+		// Names differing in case are one tag, named as first written; aliases resolve to
+		// their tag
+		if want := []string{"go", "React", "PostgreSQL"}; !slices.Equal(names(got[chat.ID]), want) {
+			t.Errorf("Chat tags = %v, want %v", names(got[chat.ID]), want)
+		}
+		if want := []string{"go", "React"}; !slices.Equal(names(got[shop.ID]), want) {
+			t.Errorf("Shop tags = %v, want %v", names(got[shop.ID]), want)
+		}
+		if got[chat.ID][0].ID != got[shop.ID][0].ID || got[chat.ID][1].ID != refs[0].ID {
+			t.Errorf("tags = %+v, want Go shared and React the merged tag %d", got, refs[0].ID)
+		}
+
+		// The rows are consumed, so a second run imports nothing
+		if n, err := store.ImportTechnologies(ctx); err != nil || n != 0 {
+			t.Errorf("second ImportTechnologies = %d, %v; want 0", n, err)
+		}
+	})
+}
+
+func TestContactStore(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		store := stores.Contact
+		admin := createUser(t, stores, "admin")
+
+		msg := mail.Message{From: "from@example.com", To: []string{"to@example.com"}, Subject: "New message"}
+		open := contact.Contact{Name: "Ann", Email: "ann@example.com", Message: "Hello", IP: "10.0.0.1"}
+		if err := store.Create(ctx, &open, msg); err != nil {
+			t.Fatal(err)
+		}
+		archived := contact.Contact{Name: "Bob", Email: "bob@example.com", Message: "Hi", IP: "10.0.0.1", Status: contact.StatusArchived}
+		if err := store.Create(ctx, &archived); err != nil {
+			t.Fatal(err)
+		}
+		if open.Status != contact.StatusNew || open.CreatedAt.IsZero() {
+			t.Errorf("created contact = %+v, want new with a creation time", open)
+		}
+		if entries, err := stores.Outbox.List(ctx, outbox.StatusPending, 10); err != nil || len(entries) != 1 {
+			t.Errorf("outbox = %+v, %v; want the one notification", entries, err)
+		}
+
+		if _, total, err := store.List(ctx, contact.Filter{Status: contact.StatusNew}); err != nil || total != 1 {
+			t.Errorf("List of new messages found %d, %v; want 1", total, err)
+		}
+		if times, err := store.RecentSubmissions(ctx, "10.0.0.1", 5); err != nil || len(times) != 2 {
+			t.Errorf("RecentSubmissions = %v, %v; want 2", times, err)
+		}
+
+		// A reply moves a new message to replied, and leaves an archived one alone
+		for _, c := range []contact.Contact{open, archived} {
+			r := contact.Reply{ContactID: c.ID, AuthorID: &admin.ID, AuthorName: "admin", To: c.Email, Subject: "Re", Body: "Thanks", MessageID: "<" + c.Name + "@example.com>"}
+			if err := store.AddReply(ctx, &r, msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		counts, err := store.CountByStatus(ctx)
+		if err != nil || counts[contact.StatusReplied] != 1 || counts[contact.StatusArchived] != 1 {
+			t.Errorf("CountByStatus = %v, %v; want one replied and one archived", counts, err)
+		}
+		if replies, err := store.ListReplies(ctx, open.ID); err != nil || len(replies) != 1 {
+			t.Errorf("ListReplies = %+v, %v; want 1", replies, err)
+		}
+
+		note := contact.Note{ContactID: open.ID, AuthorID: &admin.ID, AuthorName: "admin", Body: "Call back"}
+		if err := store.AddNote(ctx, &note); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteNote(ctx, archived.ID, note.ID); !errors.Is(err, contact.ErrNotFound) {
+			t.Errorf("DeleteNote of another message = %v, want ErrNotFound", err)
+		}
+		if err := store.DeleteNote(ctx, open.ID, note.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.Assign(ctx, open.ID, &admin.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, total, _ := store.List(ctx, contact.Filter{AssignedTo: &admin.ID}); total != 1 {
+			t.Errorf("List of assigned messages found %d, want 1", total)
+		}
+
+		for name, err := range map[string]error{
+			"Get":       second(store.Get(ctx, 999)),
+			"SetStatus": store.SetStatus(ctx, 999, contact.StatusRead),
+			"Delete":    store.Delete(ctx, 999),
+		} {
+			if !errors.Is(err, contact.ErrNotFound) {
+				t.Errorf("%s of a missing message = %v, want ErrNotFound", name, err)
+			}
+		}
+		if err := store.Delete(ctx, open.ID); err != nil {
+			t.Fatal(err)
+		}
+		if replies, err := store.ListReplies(ctx, open.ID); err != nil || len(replies) != 0 {
+			t.Errorf("replies after Delete = %+v, %v; want none", replies, err)
+		}
+	})
+}
+
+func TestRoleStore(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		roles := stores.Roles
+
+		// Each default role grants exactly its permissions
+		for _, role := range rbac.DefaultRoles {
+			u := createUser(t, stores, role.Name+"-user")
+			if err := roles.SetUserRoles(ctx, u.ID, []string{role.Name}); err != nil {
+				t.Fatal(err)
+			}
+			granted, err := roles.UserPermissions(ctx, u.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range rbac.AllPermissions {
+				if want := rbac.HasAll(role.Permissions, p); rbac.HasAll(granted, p) != want {
+					t.Errorf("%s has %s = %v, want %v", role.Name, p, !want, want)
+				}
+			}
+		}
+
+		// Permissions of several roles are merged, and a changed role applies at once
+		writer := rbac.Role{Name: "writer", Permissions: []rbac.Permission{rbac.HomeWrite}}
+		if err := roles.CreateRole(ctx, &writer); err != nil {
+			t.Fatal(err)
+		}
+		if err := roles.CreateRole(ctx, &rbac.Role{Name: "writer"}); !errors.Is(err, rbac.ErrRoleExists) {
+			t.Errorf("CreateRole with a taken name = %v, want ErrRoleExists", err)
+		}
+		u := createUser(t, stores, "ann")
+		if err := roles.SetUserRoles(ctx, u.ID, []string{"writer", "editor"}); err != nil {
+			t.Fatal(err)
+		}
+		writer.Permissions = []rbac.Permission{rbac.ContactsRead}
+		if err := roles.UpdateRole(ctx, writer); err != nil {
+			t.Fatal(err)
+		}
+		want := []rbac.Permission{rbac.AboutWrite, rbac.ContactsRead, rbac.HomeWrite, rbac.ProjectsWrite}
+		if granted, err := roles.UserPermissions(ctx, u.ID); err != nil || !slices.Equal(granted, want) {
+			t.Errorf("UserPermissions = %v, %v; want %v", granted, err, want)
+		}
+
+		if err := roles.SetUserRoles(ctx, u.ID, []string{"writer", "owner"}); !errors.Is(err, rbac.ErrRoleNotFound) {
+			t.Errorf("SetUserRoles with an unknown role = %v, want ErrRoleNotFound", err)
+		}
+		if err := roles.DeleteRole(ctx, writer.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := roles.UserRoles(ctx, u.ID); err != nil || len(got) != 1 || got[0].Name != "editor" {
+			t.Errorf("UserRoles after DeleteRole = %+v, %v; want editor", got, err)
+		}
+		if err := roles.DeleteRole(ctx, writer.ID); !errors.Is(err, rbac.ErrRoleNotFound) {
+			t.Errorf("DeleteRole of a missing role = %v, want ErrRoleNotFound", err)
+		}
+	})
+}
+
+func TestRoleStoreKeepsLastSuperAdmin(t *testing.T) {
+	backends(t, func(t *testing.T, stores *Stores) {
+		ctx := context.Background()
+		roles := stores.Roles
+		ann := createUser(t, stores, "ann")
+		bob := createUser(t, stores, "bob")
+
+		if err := roles.SetUserRoles(ctx, ann.ID, []string{rbac.SuperAdminRole}); err != nil {
+			t.Fatal(err)
+		}
+		if err := roles.SetUserRoles(ctx, ann.ID, []string{"admin"}); !errors.Is(err, rbac.ErrLastSuperAdmin) {
+			t.Errorf("demoting the last superadmin = %v, want ErrLastSuperAdmin", err)
+		}
+		if got, _ := roles.UserRoles(ctx, ann.ID); len(got) != 1 || got[0].Name != rbac.SuperAdminRole {
+			t.Errorf("roles after the refused change = %+v, want superadmin", got)
+		}
+
+		// With a second superadmin either one may step down
+		if err := roles.SetUserRoles(ctx, bob.ID, []string{rbac.SuperAdminRole}); err != nil {
+			t.Fatal(err)
+		}
+		if err := roles.SetUserRoles(ctx, ann.ID, nil); err != nil {
+			t.Errorf("demoting one of two superadmins = %v", err)
+		}
+		if err := roles.SetUserRoles(ctx, bob.ID, []string{rbac.SuperAdminRole, "admin"}); err != nil {
+			t.Errorf("keeping the role while adding another = %v", err)
+		}
+	})
+}
+
+func createUser(t *testing.T, stores *Stores, username string) user.User {
+	t.Helper()
+	u := user.User{Username: username, Password: "hash", Email: username + "@example.com"}
+	if err := stores.Users.Create(context.Background(), &u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func createProject(t *testing.T, stores *Stores, p projects.Project) projects.Project {
+	t.Helper()
+	if p.Status == "" {
+		p.Status = projects.StatusPublished
+	}
+	if err := stores.Projects.Create(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// listIDs returns the IDs of the projects matching filter, in order
+func listIDs(t *testing.T, store projects.Store, filter projects.Filter) []int {
+	t.Helper()
+	list, _, err := store.List(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, p := range list {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func sortBy(field string) listing.Params {
+	return listing.Params{Sort: field}
+}
+
+// second returns the error of a (value, error) pair
+func second[T any](_ T, err error) error {
+	return err
+}
@@ -0,0 +1,269 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"portfolio/validation"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the tag endpoints using a Store
+type Handler struct {
+	store        Store
+	liveProjects func(ctx context.Context) ([]int, error) // IDs of the projects on the public portfolio
+}
+
+// NewHandler creates tag handlers backed by the given store; liveProjects lists the projects
+// counted by GetLiveTags
+func NewHandler(store Store, liveProjects func(ctx context.Context) ([]int, error)) *Handler {
+	return &Handler{store: store, liveProjects: liveProjects}
+}
+
+// GetTags returns all tags with their aliases and project counts; ?category= narrows the list
+func (h *Handler) GetTags(c *gin.Context) {
+	h.list(c, false)
+}
+
+// GetLiveTags is GetTags for the public portfolio: only live projects are counted
+func (h *Handler) GetLiveTags(c *gin.Context) {
+	h.list(c, true)
+}
+
+// list responds with the tags in ?category=, counting only live projects when liveOnly is set
+func (h *Handler) list(c *gin.Context, liveOnly bool) {
+	category := c.Query("category")
+	if category != "" && !validCategory(category) {
+		problem.Respond(c, problem.BadRequest(fmt.Sprintf("invalid category %q", category)))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tags, err := h.store.List(ctx, category)
+	if err == nil && liveOnly {
+		err = h.countLive(ctx, tags)
+	}
+	if err != nil {
+		fmt.Println("Tag list error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// countLive replaces the project counts of tags with the number of live projects
+func (h *Handler) countLive(ctx context.Context, tags []Tag) error {
+	ids, err := h.liveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	refs, err := h.store.ProjectTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+	counts := map[int]int{}
+	for _, projectRefs := range refs {
+		for _, r := range projectRefs {
+			counts[r.ID]++
+		}
+	}
+	for i := range tags {
+		tags[i].ProjectCount = counts[tags[i].ID]
+	}
+	return nil
+}
+
+// GetTag returns a single tag with its aliases and project count
+func (h *Handler) GetTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	t, err := h.store.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Tag not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Tag fetch error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// CreateTag adds a tag with its aliases
+func (h *Handler) CreateTag(c *gin.Context) {
+	var req TagRequest
+	if !validation.Bind(c, &req) || !validNames(c, req) {
+		return
+	}
+
+	t := Tag{Name: strings.TrimSpace(req.Name), Category: req.Category, Aliases: req.Aliases}
+	if err := h.store.Create(c.Request.Context(), &t); err != nil {
+		if errors.Is(err, ErrDuplicate) {
+			problem.Respond(c, problem.Conflict("The name or an alias is already used by another tag"))
+			return
+		}
+		fmt.Println("Tag create error:", err)
+		problem.Respond(c, problem.Internal("Tag could not be created"))
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/admin/tags/%d", t.ID))
+	c.JSON(http.StatusCreated, t)
+}
+
+// UpdateTag replaces the name, category and aliases of a tag; an empty category keeps the current one
+func (h *Handler) UpdateTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	var req TagRequest
+	if !validation.Bind(c, &req) || !validNames(c, req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	t, err := h.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Tag not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Tag fetch error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	t.Name = strings.TrimSpace(req.Name)
+	t.Aliases = req.Aliases
+	if req.Category != "" {
+		t.Category = req.Category
+	}
+	err = h.store.Update(ctx, t)
+	if errors.Is(err, ErrDuplicate) {
+		problem.Respond(c, problem.Conflict("The name or an alias is already used by another tag"))
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Tag not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Tag update error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	t, err = h.store.Get(ctx, id)
+	if err != nil {
+		fmt.Println("Tag fetch error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// DeleteTag removes a tag from the taxonomy and from every project
+func (h *Handler) DeleteTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	err = h.store.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Tag not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Tag delete error:", err)
+		problem.Respond(c, problem.Internal("Delete operation failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Tag ID %d deleted successfully", id)})
+}
+
+// MergeTags folds the tags in source_ids into the tag in the URL and returns the merged tag
+func (h *Handler) MergeTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	var req MergeRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+	if slices.Contains(req.SourceIDs, id) {
+		validation.Fail(c, validation.FieldError{Field: "source_ids", Reason: "must not include the target tag"})
+		return
+	}
+	slices.Sort(req.SourceIDs)
+	sources := slices.Compact(req.SourceIDs)
+
+	ctx := c.Request.Context()
+	err = h.store.Merge(ctx, id, sources)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("Tag not found"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Tag merge error:", err)
+		problem.Respond(c, problem.Internal("Merge failed"))
+		return
+	}
+
+	t, err := h.store.Get(ctx, id)
+	if err != nil {
+		fmt.Println("Tag fetch error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// validCategory reports whether category is one of the tag categories
+func validCategory(category string) bool {
+	switch category {
+	case CategoryLanguage, CategoryFramework, CategoryCloud, CategoryDatabase, CategoryTool, CategoryOther:
+		return true
+	}
+	return false
+}
+
+// validNames checks that the name and aliases of req contain letters or digits, writing a
+// validation problem and returning false when they do not
+func validNames(c *gin.Context, req TagRequest) bool {
+	var errs validation.Errors
+	if MatchKey(req.Name) == "" {
+		errs = append(errs, validation.FieldError{Field: "name", Reason: "must contain a letter or digit"})
+	}
+	for i, alias := range req.Aliases {
+		if MatchKey(alias) == "" {
+			errs = append(errs, validation.FieldError{Field: fmt.Sprintf("aliases[%d]", i), Reason: "must contain a letter or digit"})
+		}
+	}
+	if len(errs) > 0 {
+		validation.Fail(c, errs...)
+		return false
+	}
+	return true
+}
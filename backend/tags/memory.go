@@ -0,0 +1,290 @@
+package tags
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryStore implements Store in process memory, for local development and tests
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tags   []Tag // Kept in ID order; ProjectCount is filled in by List
+	nextID int
+	links  map[int][]int // Tag IDs of each project, in order
+}
+
+// NewMemoryStore creates an empty in-memory tag store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1, links: map[int][]int{}}
+}
+
+// List returns the tags in category ("" for all) with their project counts, by name
+func (s *MemoryStore) List(ctx context.Context, category string) ([]Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[int]int{}
+	for _, ids := range s.links {
+		for _, id := range ids {
+			counts[id]++
+		}
+	}
+
+	tags := []Tag{}
+	for _, t := range s.tags {
+		if category != "" && t.Category != category {
+			continue
+		}
+		t.Aliases = slices.Clone(t.Aliases)
+		t.ProjectCount = counts[t.ID]
+		tags = append(tags, t)
+	}
+	slices.SortFunc(tags, func(a, b Tag) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return tags, nil
+}
+
+// Get returns a single tag by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return Tag{}, ErrNotFound
+	}
+	return s.withCount(s.tags[i]), nil
+}
+
+// Lookup returns the tag whose name or an alias matches name
+func (s *MemoryStore) Lookup(ctx context.Context, name string) (Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.owner(MatchKey(name))
+	if i < 0 {
+		return Tag{}, ErrNotFound
+	}
+	return s.withCount(s.tags[i]), nil
+}
+
+// Create inserts a tag with its aliases and stores the generated ID and slug in t
+func (s *MemoryStore) Create(ctx context.Context, t *Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.Aliases = cleanAliases(t.Name, t.Aliases)
+	if s.taken(*t) {
+		return ErrDuplicate
+	}
+	s.insert(t)
+	return nil
+}
+
+// Update replaces the name, category and aliases of the tag with t.ID
+func (s *MemoryStore) Update(ctx context.Context, t Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(t.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	t.Aliases = cleanAliases(t.Name, t.Aliases)
+	if s.taken(t) {
+		return ErrDuplicate
+	}
+	t.Slug = Slug(t.Name)
+	t.ProjectCount = 0 // Counted on read
+	s.tags[i] = t
+	return nil
+}
+
+// Delete removes a tag and takes it off every project
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.tags = append(s.tags[:i], s.tags[i+1:]...)
+	s.replace(id, 0)
+	return nil
+}
+
+// Merge folds the source tags into the target tag
+func (s *MemoryStore) Merge(ctx context.Context, targetID int, sourceIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index(targetID) < 0 {
+		return ErrNotFound
+	}
+	for _, id := range sourceIDs {
+		if s.index(id) < 0 {
+			return ErrNotFound
+		}
+	}
+
+	for _, id := range sourceIDs {
+		i := s.index(id)
+		if id == targetID || i < 0 { // Listed twice
+			continue
+		}
+		source := s.tags[i]
+		s.tags = append(s.tags[:i], s.tags[i+1:]...)
+		s.replace(id, targetID)
+
+		target := &s.tags[s.index(targetID)]
+		target.Aliases = cleanAliases(target.Name, append(append(target.Aliases, source.Name), source.Aliases...))
+	}
+	return nil
+}
+
+// Resolve returns the tags for names, creating the missing ones
+func (s *MemoryStore) Resolve(ctx context.Context, names []string) ([]Ref, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refs := []Ref{}
+	for _, name := range names {
+		key := MatchKey(name)
+		if key == "" {
+			continue
+		}
+		var t Tag
+		if i := s.owner(key); i >= 0 {
+			t = s.tags[i]
+		} else {
+			t = Tag{Name: strings.TrimSpace(name), Category: CategoryOther, Aliases: []string{}}
+			s.insert(&t)
+		}
+		if !slices.ContainsFunc(refs, func(r Ref) bool { return r.ID == t.ID }) {
+			refs = append(refs, t.Ref())
+		}
+	}
+	return refs, nil
+}
+
+// SetProjectTags replaces the tags of a project
+func (s *MemoryStore) SetProjectTags(ctx context.Context, projectID int, tagIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(tagIDs) == 0 {
+		delete(s.links, projectID)
+		return nil
+	}
+	s.links[projectID] = slices.Clone(tagIDs)
+	return nil
+}
+
+// ProjectTags returns the tags of each of the given projects, in order
+func (s *MemoryStore) ProjectTags(ctx context.Context, projectIDs []int) (map[int][]Ref, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	refs := map[int][]Ref{}
+	for _, projectID := range projectIDs {
+		for _, id := range s.links[projectID] {
+			if i := s.index(id); i >= 0 {
+				refs[projectID] = append(refs[projectID], s.tags[i].Ref())
+			}
+		}
+	}
+	return refs, nil
+}
+
+// ProjectIDs returns the projects carrying a tag
+func (s *MemoryStore) ProjectIDs(ctx context.Context, tagID int) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := []int{}
+	for projectID, tagIDs := range s.links {
+		if slices.Contains(tagIDs, tagID) {
+			ids = append(ids, projectID)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// insert appends t, setting its ID and slug
+func (s *MemoryStore) insert(t *Tag) {
+	t.ID = s.nextID
+	t.Slug = Slug(t.Name)
+	t.ProjectCount = 0
+	if t.Category == "" {
+		t.Category = CategoryOther
+	}
+	s.nextID++
+	s.tags = append(s.tags, *t)
+}
+
+// replace swaps the tag oldID for newID on every project, or removes it when newID is 0
+func (s *MemoryStore) replace(oldID, newID int) {
+	for projectID, ids := range s.links {
+		i := slices.Index(ids, oldID)
+		if i < 0 {
+			continue
+		}
+		if newID == 0 || slices.Contains(ids, newID) {
+			ids = slices.Delete(ids, i, i+1)
+		} else {
+			ids[i] = newID
+		}
+		s.links[projectID] = ids
+	}
+}
+
+// taken reports whether the name or an alias of t matches another tag
+func (s *MemoryStore) taken(t Tag) bool {
+	for _, name := range append([]string{t.Name}, t.Aliases...) {
+		if i := s.owner(MatchKey(name)); i >= 0 && s.tags[i].ID != t.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// owner returns the slice position of the tag whose name or an alias has key, or -1
+func (s *MemoryStore) owner(key string) int {
+	for i, t := range s.tags {
+		if MatchKey(t.Name) == key {
+			return i
+		}
+		for _, alias := range t.Aliases {
+			if MatchKey(alias) == key {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// withCount returns a copy of t with its project count
+func (s *MemoryStore) withCount(t Tag) Tag {
+	t.Aliases = slices.Clone(t.Aliases)
+	for _, ids := range s.links {
+		if slices.Contains(ids, t.ID) {
+			t.ProjectCount++
+		}
+	}
+	return t
+}
+
+// index returns the slice position of the tag with the given ID, or -1
+func (s *MemoryStore) index(id int) int {
+	for i, t := range s.tags {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
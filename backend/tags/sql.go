@@ -0,0 +1,443 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a tag store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// querier is satisfied by both *db.DB and *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+const tagColumns = "t.id, t.name, t.slug, t.category, (SELECT COUNT(*) FROM project_tags pt WHERE pt.tag_id = t.id)"
+
+// List returns the tags in category ("" for all) with their aliases and project counts, by name
+func (s *SQLStore) List(ctx context.Context, category string) ([]Tag, error) {
+	query := "SELECT " + tagColumns + " FROM tags t"
+	var args []any
+	if category != "" {
+		query += " WHERE t.category=$1"
+		args = append(args, category)
+	}
+	rows, err := s.conn.QueryContext(ctx, query+" ORDER BY LOWER(t.name)", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		t := Tag{Aliases: []string{}}
+		if err := rows.Scan(&t.ID, &t.Name, &t.Slug, &t.Category, &t.ProjectCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.aliases(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tags {
+		if a := aliases[tags[i].ID]; a != nil {
+			tags[i].Aliases = a
+		}
+	}
+	return tags, nil
+}
+
+// Get returns a single tag by ID
+func (s *SQLStore) Get(ctx context.Context, id int) (Tag, error) {
+	t := Tag{Aliases: []string{}}
+	err := s.conn.QueryRowContext(ctx, "SELECT "+tagColumns+" FROM tags t WHERE t.id=$1", id).
+		Scan(&t.ID, &t.Name, &t.Slug, &t.Category, &t.ProjectCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Tag{}, ErrNotFound
+	}
+	if err != nil {
+		return Tag{}, err
+	}
+
+	aliases, err := s.aliases(ctx, id)
+	if err != nil {
+		return Tag{}, err
+	}
+	if a := aliases[id]; a != nil {
+		t.Aliases = a
+	}
+	return t, nil
+}
+
+// aliases returns the alias names of one tag, or of every tag when id is 0, by tag ID
+func (s *SQLStore) aliases(ctx context.Context, id int) (map[int][]string, error) {
+	query := "SELECT tag_id, name FROM tag_aliases"
+	var args []any
+	if id != 0 {
+		query += " WHERE tag_id=$1"
+		args = append(args, id)
+	}
+	rows, err := s.conn.QueryContext(ctx, query+" ORDER BY LOWER(name)", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := map[int][]string{}
+	for rows.Next() {
+		var tagID int
+		var name string
+		if err := rows.Scan(&tagID, &name); err != nil {
+			return nil, err
+		}
+		aliases[tagID] = append(aliases[tagID], name)
+	}
+	return aliases, rows.Err()
+}
+
+// Lookup returns the tag whose name or an alias matches name
+func (s *SQLStore) Lookup(ctx context.Context, name string) (Tag, error) {
+	id, err := owner(ctx, s.conn, MatchKey(name))
+	if err != nil {
+		return Tag{}, err
+	}
+	if id == 0 {
+		return Tag{}, ErrNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+// owner returns the ID of the tag whose name or an alias has key, or 0
+func owner(ctx context.Context, q querier, key string) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx,
+		"SELECT id FROM tags WHERE match_key=$1 UNION SELECT tag_id FROM tag_aliases WHERE match_key=$1", key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// taken reports whether the name or an alias of t matches another tag
+func taken(ctx context.Context, q querier, t Tag) (bool, error) {
+	for _, name := range append([]string{t.Name}, t.Aliases...) {
+		id, err := owner(ctx, q, MatchKey(name))
+		if err != nil {
+			return false, err
+		}
+		if id != 0 && id != t.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// insertTag inserts t without aliases and sets its ID and slug
+func insertTag(ctx context.Context, q querier, t *Tag) error {
+	t.Slug = Slug(t.Name)
+	if t.Category == "" {
+		t.Category = CategoryOther
+	}
+	return q.QueryRowContext(ctx,
+		"INSERT INTO tags (name, slug, match_key, category, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		t.Name, t.Slug, MatchKey(t.Name), t.Category, time.Now().UTC()).Scan(&t.ID)
+}
+
+// insertAliases adds aliases to the tag with the given ID
+func insertAliases(ctx context.Context, q querier, id int, aliases []string) error {
+	for _, alias := range aliases {
+		if _, err := q.ExecContext(ctx, "INSERT INTO tag_aliases (match_key, name, tag_id) VALUES ($1, $2, $3)",
+			MatchKey(alias), alias, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create inserts a tag with its aliases and stores the generated ID and slug in t
+func (s *SQLStore) Create(ctx context.Context, t *Tag) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t.Aliases = cleanAliases(t.Name, t.Aliases)
+	if dup, err := taken(ctx, tx, *t); err != nil {
+		return err
+	} else if dup {
+		return ErrDuplicate
+	}
+
+	if err := insertTag(ctx, tx, t); err != nil {
+		return err
+	}
+	if err := insertAliases(ctx, tx, t.ID, t.Aliases); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update replaces the name, category and aliases of the tag with t.ID
+func (s *SQLStore) Update(ctx context.Context, t Tag) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t.Aliases = cleanAliases(t.Name, t.Aliases)
+	if dup, err := taken(ctx, tx, t); err != nil {
+		return err
+	} else if dup {
+		return ErrDuplicate
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE tags SET name=$1, slug=$2, match_key=$3, category=$4 WHERE id=$5",
+		t.Name, Slug(t.Name), MatchKey(t.Name), t.Category, t.ID)
+	if err := db.RequireRows(result, err, ErrNotFound); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tag_aliases WHERE tag_id=$1", t.ID); err != nil {
+		return err
+	}
+	if err := insertAliases(ctx, tx, t.ID, t.Aliases); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a tag; its aliases and project links are removed by cascade
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM tags WHERE id=$1", id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Merge folds the source tags into the target tag in one transaction
+func (s *SQLStore) Merge(ctx context.Context, targetID int, sourceIDs []int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tags WHERE id=$1)", targetID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	for _, id := range sourceIDs {
+		if id == targetID {
+			continue
+		}
+		var name string
+		err := tx.QueryRowContext(ctx, "SELECT name FROM tags WHERE id=$1", id).Scan(&name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Projects that already carry the target keep their own position for it
+		if _, err := tx.ExecContext(ctx, `INSERT INTO project_tags (project_id, tag_id, position)
+			SELECT project_id, $1, position FROM project_tags
+			WHERE tag_id=$2 AND project_id NOT IN (SELECT project_id FROM project_tags WHERE tag_id=$1)`,
+			targetID, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE tag_aliases SET tag_id=$1 WHERE tag_id=$2", targetID, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id=$1", id); err != nil {
+			return err
+		}
+		if err := insertAliases(ctx, tx, targetID, []string{name}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Resolve returns the tags for names, creating the missing ones in one transaction
+func (s *SQLStore) Resolve(ctx context.Context, names []string) ([]Ref, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	refs := []Ref{}
+	seen := map[int]bool{}
+	for _, name := range names {
+		key := MatchKey(name)
+		if key == "" {
+			continue
+		}
+		id, err := owner(ctx, tx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		var ref Ref
+		if id == 0 {
+			t := Tag{Name: strings.TrimSpace(name), Category: CategoryOther}
+			if err := insertTag(ctx, tx, &t); err != nil {
+				return nil, err
+			}
+			ref = t.Ref()
+		} else {
+			ref.ID = id
+			if err := tx.QueryRowContext(ctx, "SELECT name, slug, category FROM tags WHERE id=$1", id).
+				Scan(&ref.Name, &ref.Slug, &ref.Category); err != nil {
+				return nil, err
+			}
+		}
+		if !seen[ref.ID] {
+			seen[ref.ID] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs, tx.Commit()
+}
+
+// SetProjectTags replaces the tags of a project in one transaction
+func (s *SQLStore) SetProjectTags(ctx context.Context, projectID int, tagIDs []int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM project_tags WHERE project_id=$1", projectID); err != nil {
+		return err
+	}
+	for i, id := range tagIDs {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO project_tags (project_id, tag_id, position) VALUES ($1, $2, $3)",
+			projectID, id, i); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ProjectTags returns the tags of each of the given projects, in order
+func (s *SQLStore) ProjectTags(ctx context.Context, projectIDs []int) (map[int][]Ref, error) {
+	refs := map[int][]Ref{}
+	if len(projectIDs) == 0 {
+		return refs, nil
+	}
+
+	placeholders := make([]string, len(projectIDs))
+	args := make([]any, len(projectIDs))
+	for i, id := range projectIDs {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+	rows, err := s.conn.QueryContext(ctx, `SELECT pt.project_id, t.id, t.name, t.slug, t.category
+		FROM project_tags pt JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.project_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY pt.project_id, pt.position`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID int
+		var r Ref
+		if err := rows.Scan(&projectID, &r.ID, &r.Name, &r.Slug, &r.Category); err != nil {
+			return nil, err
+		}
+		refs[projectID] = append(refs[projectID], r)
+	}
+	return refs, rows.Err()
+}
+
+// ProjectIDs returns the projects carrying a tag
+func (s *SQLStore) ProjectIDs(ctx context.Context, tagID int) ([]int, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT project_id FROM project_tags WHERE tag_id=$1 ORDER BY project_id", tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ImportTechnologies turns the technologies of projects saved before tags existed, split into
+// project_tag_imports by the migration, into tags. It returns the number of projects imported.
+func (s *SQLStore) ImportTechnologies(ctx context.Context) (int, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT project_id, name FROM project_tag_imports ORDER BY project_id, position")
+	if err != nil {
+		return 0, err
+	}
+	var order []int
+	names := map[int][]string{}
+	for rows.Next() {
+		var projectID int
+		var name string
+		if err := rows.Scan(&projectID, &name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if r := []rune(name); len(r) > MaxNameLength { // Free-form lists had no limit per name
+			name = string(r[:MaxNameLength])
+		}
+		if names[projectID] == nil {
+			order = append(order, projectID)
+		}
+		names[projectID] = append(names[projectID], name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, projectID := range order {
+		refs, err := s.Resolve(ctx, names[projectID])
+		if err != nil {
+			return 0, err
+		}
+		ids := make([]int, len(refs))
+		for i, r := range refs {
+			ids[i] = r.ID
+		}
+		if err := s.SetProjectTags(ctx, projectID, ids); err != nil {
+			return 0, err
+		}
+		if _, err := s.conn.ExecContext(ctx, "DELETE FROM project_tag_imports WHERE project_id=$1", projectID); err != nil {
+			return 0, err
+		}
+	}
+	return len(order), nil
+}
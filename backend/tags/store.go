@@ -0,0 +1,32 @@
+package tags
+
+import (
+	"context"
+	"errors"
+)
+
+// Errors returned by a Store
+var (
+	ErrNotFound  = errors.New("tag not found")
+	ErrDuplicate = errors.New("tag name or alias already in use") // Another tag has the same match key
+)
+
+// Store is the persistence interface the tag and project handlers depend on
+type Store interface {
+	List(ctx context.Context, category string) ([]Tag, error) // Tags with aliases and project counts by name; "" lists all
+	Get(ctx context.Context, id int) (Tag, error)             // Single tag by ID
+	Lookup(ctx context.Context, name string) (Tag, error)     // Tag whose name or alias has the match key of name, or ErrNotFound
+	Create(ctx context.Context, t *Tag) error                 // Inserts t with its aliases and sets its ID and slug, or ErrDuplicate
+	Update(ctx context.Context, t Tag) error                  // Replaces name, category and aliases, or ErrNotFound / ErrDuplicate
+	Delete(ctx context.Context, id int) error                 // Removes the tag from every project, or ErrNotFound
+	// Merge folds the source tags into target: their projects get target, their names and
+	// aliases become aliases of target, and they are deleted. ErrNotFound if any tag is missing.
+	Merge(ctx context.Context, targetID int, sourceIDs []int) error
+
+	// Resolve returns the tags for a list of names in order, without repeats, creating tags in
+	// CategoryOther for names that match none. Names without letters or digits are skipped.
+	Resolve(ctx context.Context, names []string) ([]Ref, error)
+	SetProjectTags(ctx context.Context, projectID int, tagIDs []int) error // Replaces the tags of a project, in order
+	ProjectTags(ctx context.Context, projectIDs []int) (map[int][]Ref, error)
+	ProjectIDs(ctx context.Context, tagID int) ([]int, error) // Projects carrying the tag
+}
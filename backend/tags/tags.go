@@ -0,0 +1,126 @@
+// Package tags keeps the technology taxonomy of projects: canonical tags with a category,
+// aliases that resolve other spellings to them, and the tags of each project.
+package tags
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Categories of a tag
+const (
+	CategoryLanguage  = "language"
+	CategoryFramework = "framework"
+	CategoryCloud     = "cloud"
+	CategoryDatabase  = "database"
+	CategoryTool      = "tool"
+	CategoryOther     = "other" // Tags created from project technologies start here
+)
+
+// MaxNameLength is the longest tag name or alias
+const MaxNameLength = 50
+
+// Tag is a canonical technology tag
+type Tag struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Slug         string   `json:"slug"`
+	Category     string   `json:"category"`
+	Aliases      []string `json:"aliases"`
+	ProjectCount int      `json:"project_count"`
+}
+
+// Ref is a tag as listed on a project
+type Ref struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Category string `json:"category"`
+}
+
+// Ref returns the short form of t
+func (t Tag) Ref() Ref {
+	return Ref{ID: t.ID, Name: t.Name, Slug: t.Slug, Category: t.Category}
+}
+
+// TagRequest is the body of POST /api/admin/tags and PUT /api/admin/tags/:id
+type TagRequest struct {
+	Name     string   `json:"name" binding:"notblank,max=50"`
+	Category string   `json:"category" binding:"omitempty,oneof=language framework cloud database tool other"`
+	Aliases  []string `json:"aliases" binding:"max=20,dive,notblank,max=50"`
+}
+
+// MergeRequest is the body of POST /api/admin/tags/:id/merge
+type MergeRequest struct {
+	SourceIDs []int `json:"source_ids" binding:"required,min=1,max=50"`
+}
+
+// Slug turns a name into its URL form: lower case letters and digits joined by hyphens,
+// with "+" and "#" spelled out so "C++" and "C#" stay apart from "C"
+func Slug(name string) string {
+	var b strings.Builder
+	hyphen := false
+	write := func(s string) {
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteString(s)
+	}
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			write(string(r))
+		case r == '+':
+			write("plus")
+		case r == '#':
+			write("sharp")
+		default:
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// MatchKey folds a name for comparison, so "Node.js", "node js" and "NodeJS" are the same;
+// it is empty for names without letters or digits
+func MatchKey(name string) string {
+	return strings.ReplaceAll(Slug(name), "-", "")
+}
+
+// SplitNames splits a comma-separated technologies list into trimmed, non-empty names
+func SplitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// JoinNames returns the names of refs as a comma-separated technologies list
+func JoinNames(refs []Ref) string {
+	names := make([]string, len(refs))
+	for i, r := range refs {
+		names[i] = r.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// cleanAliases trims the aliases and drops those that fold to the same key as the name or an
+// earlier alias
+func cleanAliases(name string, aliases []string) []string {
+	seen := map[string]bool{MatchKey(name): true}
+	out := []string{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := MatchKey(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, alias)
+	}
+	return out
+}
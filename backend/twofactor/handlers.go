@@ -0,0 +1,240 @@
+package twofactor
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/middleware"
+	"portfolio/problem"
+	"portfolio/validation"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// CodeRequest struct for endpoints that need a TOTP or recovery code
+type CodeRequest struct {
+	Code string `json:"code" binding:"notblank,max=64"`
+}
+
+// EnrollResponse struct returned when enrollment starts
+type EnrollResponse struct {
+	Secret          string `json:"secret"`           // For manual entry in the authenticator app
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI encoded in the QR code
+	QRCode          string `json:"qr_code"`          // PNG as a data: URL, ready for an <img> tag
+}
+
+// Handler serves the two-factor endpoints of the logged-in user using a Store
+type Handler struct {
+	store Store
+}
+
+// NewHandler creates two-factor handlers backed by the given store
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// Status reports whether two-factor authentication is enabled for the current user
+func (h *Handler) Status(c *gin.Context) {
+	userID, _, _ := middleware.GetCurrentUser(c)
+
+	enrollment, err := h.store.GetTOTP(c.Request.Context(), userID)
+	if errors.Is(err, ErrNotEnrolled) {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "pending": false, "recovery_codes_remaining": 0})
+		return
+	}
+	if err != nil {
+		fmt.Println("2FA status error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	remaining, err := h.store.CountRecoveryCodes(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Println("2FA status error:", err)
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  enrollment.Enabled(),
+		"pending":                  !enrollment.Enabled(),
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// Enroll creates a new TOTP secret for the current user. It only takes effect after Confirm.
+func (h *Handler) Enroll(c *gin.Context) {
+	userID, username, _ := middleware.GetCurrentUser(c)
+
+	// An active setup must be disabled first, so a stolen access token cannot swap the secret
+	enrollment, err := h.store.GetTOTP(c.Request.Context(), userID)
+	if err == nil && enrollment.Enabled() {
+		problem.Respond(c, problem.Conflict("Two-factor authentication is already enabled"))
+		return
+	}
+	if err != nil && !errors.Is(err, ErrNotEnrolled) {
+		fmt.Println("2FA enroll error:", err)
+		problem.Respond(c, problem.Internal("Could not start two-factor setup"))
+		return
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		fmt.Println("2FA secret error:", err)
+		problem.Respond(c, problem.Internal("Could not start two-factor setup"))
+		return
+	}
+
+	uri := ProvisioningURI(secret, username)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		fmt.Println("2FA QR code error:", err)
+		problem.Respond(c, problem.Internal("Could not start two-factor setup"))
+		return
+	}
+
+	err = h.store.SaveTOTP(c.Request.Context(), Enrollment{UserID: userID, Secret: secret, CreatedAt: time.Now().UTC()})
+	if err != nil {
+		fmt.Println("2FA enroll error:", err)
+		problem.Respond(c, problem.Internal("Could not start two-factor setup"))
+		return
+	}
+
+	c.JSON(http.StatusOK, EnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// Confirm enables two-factor authentication with the first code from the app
+// and returns the recovery codes; they are not shown again
+func (h *Handler) Confirm(c *gin.Context) {
+	userID, _, _ := middleware.GetCurrentUser(c)
+
+	var req CodeRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	enrollment, err := h.store.GetTOTP(c.Request.Context(), userID)
+	if errors.Is(err, ErrNotEnrolled) {
+		problem.Respond(c, problem.BadRequest("Start two-factor setup first"))
+		return
+	}
+	if err != nil {
+		fmt.Println("2FA confirm error:", err)
+		problem.Respond(c, problem.Internal("Could not confirm two-factor setup"))
+		return
+	}
+	if enrollment.Enabled() {
+		problem.Respond(c, problem.Conflict("Two-factor authentication is already enabled"))
+		return
+	}
+
+	ok, err := verifyTOTP(c.Request.Context(), h.store, enrollment, req.Code)
+	if err != nil {
+		fmt.Println("2FA confirm error:", err)
+		problem.Respond(c, problem.Internal("Could not confirm two-factor setup"))
+		return
+	}
+	if !ok {
+		problem.Respond(c, problem.BadRequest("Invalid code"))
+		return
+	}
+
+	codes, err := newRecoveryCodes(c.Request.Context(), h.store, userID)
+	if err == nil {
+		err = h.store.ConfirmTOTP(c.Request.Context(), userID, time.Now().UTC())
+	}
+	if err != nil {
+		fmt.Println("2FA confirm error:", err)
+		problem.Respond(c, problem.Internal("Could not confirm two-factor setup"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current code
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _, _ := middleware.GetCurrentUser(c)
+
+	var req CodeRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	ok, err := Verify(c.Request.Context(), h.store, userID, req.Code)
+	if err != nil {
+		fmt.Println("2FA recovery code error:", err)
+		problem.Respond(c, problem.Internal("Could not create recovery codes"))
+		return
+	}
+	if !ok {
+		problem.Respond(c, problem.BadRequest("Invalid code"))
+		return
+	}
+
+	codes, err := newRecoveryCodes(c.Request.Context(), h.store, userID)
+	if err != nil {
+		fmt.Println("2FA recovery code error:", err)
+		problem.Respond(c, problem.Internal("Could not create recovery codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Disable turns off two-factor authentication for the current user after checking a code
+func (h *Handler) Disable(c *gin.Context) {
+	userID, _, _ := middleware.GetCurrentUser(c)
+
+	var req CodeRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	ok, err := Verify(c.Request.Context(), h.store, userID, req.Code)
+	if err != nil {
+		fmt.Println("2FA disable error:", err)
+		problem.Respond(c, problem.Internal("Could not disable two-factor authentication"))
+		return
+	}
+	if !ok {
+		problem.Respond(c, problem.BadRequest("Invalid code"))
+		return
+	}
+
+	if err := h.store.DeleteTOTP(c.Request.Context(), userID); err != nil {
+		fmt.Println("2FA disable error:", err)
+		problem.Respond(c, problem.Internal("Could not disable two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// Reset removes two-factor authentication from a user who lost their device and recovery codes
+func (h *Handler) Reset(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	if err := h.store.DeleteTOTP(c.Request.Context(), id); err != nil {
+		fmt.Println("2FA reset error:", err)
+		problem.Respond(c, problem.Internal("Could not reset two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Two-factor authentication of user ID %d reset", id)})
+}
@@ -0,0 +1,129 @@
+package twofactor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// recoveryCode is a stored recovery code hash
+type recoveryCode struct {
+	hash string
+	used bool
+}
+
+// MemoryStore implements Store in process memory
+type MemoryStore struct {
+	mu          sync.Mutex
+	enrollments map[int]Enrollment
+	codes       map[int][]recoveryCode // User ID -> recovery codes
+}
+
+// NewMemoryStore creates an empty in-memory two-factor store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		enrollments: map[int]Enrollment{},
+		codes:       map[int][]recoveryCode{},
+	}
+}
+
+// GetTOTP returns the TOTP enrollment of a user
+func (s *MemoryStore) GetTOTP(ctx context.Context, userID int) (Enrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	if !ok {
+		return Enrollment{}, ErrNotEnrolled
+	}
+	return e, nil
+}
+
+// SaveTOTP replaces the enrollment of a user and drops their old recovery codes
+func (s *MemoryStore) SaveTOTP(ctx context.Context, e Enrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.enrollments[e.UserID] = e
+	delete(s.codes, e.UserID)
+	return nil
+}
+
+// ConfirmTOTP marks the enrollment of a user as confirmed
+func (s *MemoryStore) ConfirmTOTP(ctx context.Context, userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	if !ok {
+		return ErrNotEnrolled
+	}
+	e.ConfirmedAt = &at
+	s.enrollments[userID] = e
+	return nil
+}
+
+// DeleteTOTP removes the enrollment and recovery codes of a user
+func (s *MemoryStore) DeleteTOTP(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.enrollments, userID)
+	delete(s.codes, userID)
+	return nil
+}
+
+// AdvanceStep stores step as the last used step only if it is newer
+func (s *MemoryStore) AdvanceStep(ctx context.Context, userID int, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	if !ok || step <= e.LastUsedStep {
+		return false, nil
+	}
+	e.LastUsedStep = step
+	s.enrollments[userID] = e
+	return true, nil
+}
+
+// ReplaceRecoveryCodes deletes the old recovery codes of a user and stores the new hashes
+func (s *MemoryStore) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make([]recoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, recoveryCode{hash: hash})
+	}
+	s.codes[userID] = codes
+	return nil
+}
+
+// UseRecoveryCode marks a matching unused code as used
+func (s *MemoryStore) UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, code := range s.codes[userID] {
+		if code.hash == hash && !code.used {
+			s.codes[userID][i].used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (s *MemoryStore) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, code := range s.codes[userID] {
+		if !code.used {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,136 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"time"
+)
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a two-factor store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// GetTOTP returns the TOTP enrollment of a user
+func (s *SQLStore) GetTOTP(ctx context.Context, userID int) (Enrollment, error) {
+	var e Enrollment
+	var confirmedAt sql.NullTime
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT user_id, secret, created_at, confirmed_at, last_used_step FROM user_totp WHERE user_id=$1", userID).
+		Scan(&e.UserID, &e.Secret, &e.CreatedAt, &confirmedAt, &e.LastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Enrollment{}, ErrNotEnrolled
+	}
+	if confirmedAt.Valid {
+		e.ConfirmedAt = &confirmedAt.Time
+	}
+	return e, err
+}
+
+// SaveTOTP replaces the enrollment of a user and drops their old recovery codes
+func (s *SQLStore) SaveTOTP(ctx context.Context, e Enrollment) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", e.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id=$1", e.UserID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO user_totp (user_id, secret, created_at, confirmed_at, last_used_step) VALUES ($1, $2, $3, $4, $5)",
+		e.UserID, e.Secret, e.CreatedAt, e.ConfirmedAt, e.LastUsedStep)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConfirmTOTP marks the enrollment of a user as confirmed
+func (s *SQLStore) ConfirmTOTP(ctx context.Context, userID int, at time.Time) error {
+	result, err := s.conn.ExecContext(ctx, "UPDATE user_totp SET confirmed_at=$1 WHERE user_id=$2", at, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotEnrolled
+	}
+	return nil
+}
+
+// DeleteTOTP removes the enrollment and recovery codes of a user
+func (s *SQLStore) DeleteTOTP(ctx context.Context, userID int) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AdvanceStep stores step as the last used step only if it is newer
+func (s *SQLStore) AdvanceStep(ctx context.Context, userID int, step int64) (bool, error) {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE user_totp SET last_used_step=$1 WHERE user_id=$2 AND last_used_step < $1", step, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// ReplaceRecoveryCodes deletes the old recovery codes of a user and stores the new hashes
+func (s *SQLStore) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode sets used_at on a matching unused code
+func (s *SQLStore) UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) (bool, error) {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE recovery_codes SET used_at=$1 WHERE user_id=$2 AND code_hash=$3 AND used_at IS NULL", at, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (s *SQLStore) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL", userID).Scan(&count)
+	return count, err
+}
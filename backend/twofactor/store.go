@@ -0,0 +1,40 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotEnrolled is returned when a user has not started TOTP enrollment
+var ErrNotEnrolled = errors.New("two-factor authentication is not set up")
+
+// Enrollment is the TOTP secret of a user. Two-factor login is required only
+// after the first code has been confirmed.
+type Enrollment struct {
+	UserID       int
+	Secret       string // Base32 secret shared with the authenticator app
+	CreatedAt    time.Time
+	ConfirmedAt  *time.Time
+	LastUsedStep int64 // Newest time step accepted, so a code cannot be replayed
+}
+
+// Enabled reports whether the enrollment has been confirmed
+func (e Enrollment) Enabled() bool {
+	return e.ConfirmedAt != nil
+}
+
+// Store persists TOTP secrets and recovery codes
+type Store interface {
+	GetTOTP(ctx context.Context, userID int) (Enrollment, error)
+	SaveTOTP(ctx context.Context, e Enrollment) error // Replaces any existing enrollment of the user
+	ConfirmTOTP(ctx context.Context, userID int, at time.Time) error
+	DeleteTOTP(ctx context.Context, userID int) error // Also deletes the recovery codes
+	// AdvanceStep records step as used if it is newer than the last used step and
+	// reports whether it did, so the same code cannot log in twice
+	AdvanceStep(ctx context.Context, userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error
+	// UseRecoveryCode marks an unused code as used and reports whether one matched
+	UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error) // Unused codes only
+}
@@ -0,0 +1,74 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 6238 SHA1 test key "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAt(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := codeAt(rfcSecret, step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := step(now)
+
+	for _, s := range []int64{current - 1, current, current + 1} {
+		code, err := codeAt(rfcSecret, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := matchStep(rfcSecret, code, now); !ok || got != s {
+			t.Errorf("code of step %d matched %d, %v", s, got, ok)
+		}
+	}
+
+	old, err := codeAt(rfcSecret, current-2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{old, "", "12345", "1234567", "abcdef"} {
+		if _, ok := matchStep(rfcSecret, code, now); ok {
+			t.Errorf("code %q matched", code)
+		}
+	}
+
+	// Spaces typed between the digit groups are ignored
+	if _, ok := matchStep(rfcSecret, " 081 804 ", now); !ok {
+		t.Error("code with spaces did not match")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codeAt(secret, 1); err != nil {
+		t.Errorf("secret %q is not valid base32: %v", secret, err)
+	}
+	if other, _ := NewSecret(); other == secret {
+		t.Error("NewSecret returned the same secret twice")
+	}
+}
@@ -0,0 +1,85 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"portfolio/auth"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount is how many one-time recovery codes are issued at a time
+const RecoveryCodeCount = 10
+
+// recoveryEncoding produces codes without look-alike padding characters
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Enabled reports whether a user must enter a second factor to log in
+func Enabled(ctx context.Context, store Store, userID int) (bool, error) {
+	enrollment, err := store.GetTOTP(ctx, userID)
+	if errors.Is(err, ErrNotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return enrollment.Enabled(), nil
+}
+
+// Verify checks a TOTP code or, failing that, a recovery code of a user with
+// confirmed two-factor authentication. A matching recovery code is used up.
+func Verify(ctx context.Context, store Store, userID int, code string) (bool, error) {
+	enrollment, err := store.GetTOTP(ctx, userID)
+	if errors.Is(err, ErrNotEnrolled) {
+		return false, nil
+	}
+	if err != nil || !enrollment.Enabled() {
+		return false, err
+	}
+
+	if ok, err := verifyTOTP(ctx, store, enrollment, code); ok || err != nil {
+		return ok, err
+	}
+
+	return store.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), time.Now().UTC())
+}
+
+// verifyTOTP checks a TOTP code against an enrollment and consumes its time step
+func verifyTOTP(ctx context.Context, store Store, enrollment Enrollment, code string) (bool, error) {
+	s, ok := matchStep(enrollment.Secret, code, time.Now())
+	if !ok || s <= enrollment.LastUsedStep {
+		return false, nil
+	}
+	return store.AdvanceStep(ctx, enrollment.UserID, s)
+}
+
+// newRecoveryCodes generates a fresh set of recovery codes and stores their hashes.
+// The plain codes are returned so they can be shown to the user once.
+func newRecoveryCodes(ctx context.Context, store Store, userID int) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := store.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode normalizes a recovery code before hashing, so case, spaces and
+// dashes typed by the user do not matter
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return auth.HashToken(code)
+}
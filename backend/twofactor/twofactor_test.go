@@ -0,0 +1,118 @@
+package twofactor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// enroll stores a confirmed enrollment for user 1 and returns the current code
+func enroll(t *testing.T, store *MemoryStore) string {
+	t.Helper()
+	ctx := context.Background()
+	if err := store.SaveTOTP(ctx, Enrollment{UserID: 1, Secret: rfcSecret, CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ConfirmTOTP(ctx, 1, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	code, err := codeAt(rfcSecret, step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestEnabled(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if enabled, err := Enabled(ctx, store, 1); err != nil || enabled {
+		t.Errorf("without enrollment = %v, %v; want false", enabled, err)
+	}
+
+	// A started setup only counts once the first code is confirmed
+	if err := store.SaveTOTP(ctx, Enrollment{UserID: 1, Secret: rfcSecret, CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	if enabled, err := Enabled(ctx, store, 1); err != nil || enabled {
+		t.Errorf("before confirmation = %v, %v; want false", enabled, err)
+	}
+	if err := store.ConfirmTOTP(ctx, 1, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if enabled, err := Enabled(ctx, store, 1); err != nil || !enabled {
+		t.Errorf("after confirmation = %v, %v; want true", enabled, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	code := enroll(t, store)
+
+	if ok, err := Verify(ctx, store, 1, "000000"); err != nil || ok {
+		t.Errorf("wrong code = %v, %v; want false", ok, err)
+	}
+	if ok, err := Verify(ctx, store, 1, code); err != nil || !ok {
+		t.Fatalf("current code = %v, %v; want true", ok, err)
+	}
+
+	// A code cannot be replayed, not even within its time step
+	if ok, err := Verify(ctx, store, 1, code); err != nil || ok {
+		t.Errorf("replayed code = %v, %v; want false", ok, err)
+	}
+	if ok, err := Verify(ctx, store, 2, code); err != nil || ok {
+		t.Errorf("user without enrollment = %v, %v; want false", ok, err)
+	}
+}
+
+func TestVerifyRejectsUnconfirmedEnrollment(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.SaveTOTP(ctx, Enrollment{UserID: 1, Secret: rfcSecret, CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	code, err := codeAt(rfcSecret, step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := Verify(ctx, store, 1, code); err != nil || ok {
+		t.Errorf("code of a pending setup = %v, %v; want false", ok, err)
+	}
+}
+
+func TestVerifyRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	enroll(t, store)
+	codes, err := newRecoveryCodes(ctx, store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), RecoveryCodeCount)
+	}
+
+	// Case, spaces and dashes do not matter
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if ok, err := Verify(ctx, store, 1, typed); err != nil || !ok {
+		t.Fatalf("recovery code %q = %v, %v; want true", typed, ok, err)
+	}
+	if ok, err := Verify(ctx, store, 1, codes[0]); err != nil || ok {
+		t.Errorf("used recovery code = %v, %v; want false", ok, err)
+	}
+	if n, err := store.CountRecoveryCodes(ctx, 1); err != nil || n != RecoveryCodeCount-1 {
+		t.Errorf("remaining codes = %d, %v; want %d", n, err, RecoveryCodeCount-1)
+	}
+
+	// New codes replace the old ones
+	if _, err := newRecoveryCodes(ctx, store, 1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(ctx, store, 1, codes[1]); err != nil || ok {
+		t.Errorf("replaced recovery code = %v, %v; want false", ok, err)
+	}
+}
@@ -0,0 +1,164 @@
+package user
+
+import (
+	"context"
+	"portfolio/listing"
+	"strings"
+	"sync"
+)
+
+// MemoryStore implements Store in process memory, for local development and tests
+type MemoryStore struct {
+	mu     sync.RWMutex
+	users  []User // Password holds the bcrypt hash
+	nextID int
+}
+
+// NewMemoryStore creates an empty in-memory user store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// compareUsers are the fields a user list can be sorted by
+var compareUsers = map[string]func(a, b User) int{
+	"id":       func(a, b User) int { return 0 }, // listing.Sort breaks ties by ID
+	"username": func(a, b User) int { return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username)) },
+	"email":    func(a, b User) int { return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email)) },
+}
+
+// List returns the page of users matching filter, without their passwords, and the number of matches
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]User, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	search := strings.ToLower(filter.Search)
+	users := []User{}
+	for _, u := range s.users {
+		if search != "" && !strings.Contains(strings.ToLower(u.Username), search) &&
+			!strings.Contains(strings.ToLower(u.Email), search) {
+			continue
+		}
+		u.Password = ""
+		users = append(users, u)
+	}
+	listing.Sort(users, filter.Params, compareUsers, func(u User) int { return u.ID })
+	return listing.Window(users, filter.Params), len(users), nil
+}
+
+// Get returns a single user by ID without the password
+func (s *MemoryStore) Get(ctx context.Context, id int) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == id {
+			u.Password = ""
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+// GetByUsername returns a user including the password hash, for login checks
+func (s *MemoryStore) GetByUsername(ctx context.Context, username string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+// GetByEmail returns a single user by email without the password
+func (s *MemoryStore) GetByEmail(ctx context.Context, email string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email != "" && strings.EqualFold(u.Email, email) {
+			u.Password = ""
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+// Create inserts a new user and stores the generated ID in u
+func (s *MemoryStore) Create(ctx context.Context, u *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(*u) {
+		return ErrDuplicate
+	}
+
+	u.ID = s.nextID
+	s.nextID++
+	s.users = append(s.users, *u)
+	return nil
+}
+
+// Update saves the user with u.ID; the password only changes when u.Password is set
+func (s *MemoryStore) Update(ctx context.Context, u User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(u) {
+		return ErrDuplicate
+	}
+
+	for i, existing := range s.users {
+		if existing.ID == u.ID {
+			if u.Password == "" {
+				u.Password = existing.Password
+			}
+			s.users[i] = u
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// SetPassword replaces the password hash of a user
+func (s *MemoryStore) SetPassword(ctx context.Context, id int, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, u := range s.users {
+		if u.ID == id {
+			s.users[i].Password = hash
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// Delete removes a user by ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, u := range s.users {
+		if u.ID == id {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// taken reports whether another user already has u's username or email
+func (s *MemoryStore) taken(u User) bool {
+	for _, existing := range s.users {
+		if existing.ID == u.ID {
+			continue
+		}
+		if existing.Username == u.Username || (u.Email != "" && existing.Email == u.Email) {
+			return true
+		}
+	}
+	return false
+}
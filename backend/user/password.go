@@ -0,0 +1,233 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"portfolio/auth"
+	"portfolio/lockout"
+	"portfolio/mail"
+	"portfolio/middleware"
+	"portfolio/problem"
+	"portfolio/validation"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPasswordRequest struct for requesting a reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+}
+
+// ResetPasswordRequest struct for choosing a new password with a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=72"` // bcrypt ignores anything past 72 bytes
+}
+
+// ChangePasswordRequest struct for the logged-in user changing their password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// resetTokenTTL is how long a reset link stays valid (PASSWORD_RESET_TTL, default 1h)
+func resetTokenTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+// ForgotPassword emails a single-use reset link. The response is the same whether
+// or not the email belongs to an account, so it cannot be used to find users.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	// Limit the requests per email and IP address before the lookup, so mail cannot be
+	// flooded and the limit applies to unknown addresses alike
+	ctx := c.Request.Context()
+	email := strings.TrimSpace(req.Email)
+	wait, err := h.guard.CheckReset(ctx, c.ClientIP(), email)
+	if err != nil {
+		fmt.Println("Password reset guard error:", err)
+		problem.Respond(c, problem.Internal("Password reset is temporarily unavailable"))
+		return
+	}
+	if wait > 0 {
+		seconds := int((wait + time.Second - 1) / time.Second)
+		problem.Respond(c, problem.TooManyRequests(fmt.Sprintf("Too many reset requests, try again in %d seconds", seconds), wait))
+		return
+	}
+	if err := h.guard.ResetRequested(ctx, c.ClientIP(), email); err != nil {
+		fmt.Println("Password reset guard error:", err)
+	}
+
+	response := gin.H{"message": "If an account with that email exists, a reset link has been sent"}
+
+	u, err := h.store.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			fmt.Println("Password reset lookup error:", err)
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Create and send the link in the background, so the response time does not reveal that
+	// the account exists
+	go h.sendResetLink(u)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// sendResetLink replaces the reset links of u with a new one and mails it; only the newest
+// link works
+func (h *Handler) sendResetLink(u User) {
+	ctx := context.Background()
+	token, err := auth.RandomToken(32)
+	if err != nil {
+		fmt.Println("Password reset token error:", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	ttl := resetTokenTTL()
+	err = h.resetTokens.DeleteUserResetTokens(ctx, u.ID)
+	if err == nil {
+		err = h.resetTokens.SaveResetToken(ctx, ResetToken{
+			Hash:      auth.HashToken(token),
+			UserID:    u.ID,
+			ExpiresAt: now.Add(ttl),
+			CreatedAt: now,
+		})
+	}
+	if err != nil {
+		fmt.Println("Password reset token error:", err)
+		return
+	}
+
+	if err := h.mailer.SendPasswordResetMail(ctx, u.Email, u.Username, mail.AppURL("/admin/reset-password", token), ttl); err != nil {
+		fmt.Printf("Password reset mail failed: %v\n", err)
+	}
+}
+
+// ResetPassword sets a new password with a reset link and logs the user out everywhere
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+	hash := auth.HashToken(req.Token)
+
+	t, err := h.resetTokens.GetResetToken(ctx, hash)
+	if err != nil && !errors.Is(err, ErrResetTokenNotFound) {
+		fmt.Println("Password reset error:", err)
+		problem.Respond(c, problem.Internal("Could not reset password"))
+		return
+	}
+	if err != nil || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		problem.Respond(c, problem.BadRequest("Invalid or expired reset link"))
+		return
+	}
+
+	// Claim the token before changing anything, so two requests cannot both use it
+	marked, err := h.resetTokens.MarkResetTokenUsed(ctx, hash, now)
+	if err != nil {
+		fmt.Println("Password reset error:", err)
+		problem.Respond(c, problem.Internal("Could not reset password"))
+		return
+	}
+	if !marked {
+		problem.Respond(c, problem.BadRequest("Invalid or expired reset link"))
+		return
+	}
+
+	u, err := h.store.Get(ctx, t.UserID)
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid or expired reset link"))
+		return
+	}
+
+	if err := h.setPassword(ctx, u, req.Password); err != nil {
+		fmt.Println("Password reset error:", err)
+		problem.Respond(c, problem.Internal("Could not reset password"))
+		return
+	}
+
+	// The owner of the account proved access to its email, so lift any lockout too
+	if err := h.guard.Unlock(ctx, u.Username); err != nil {
+		fmt.Println("Unlock error:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in with the new password"})
+}
+
+// ChangePassword changes the password of the logged-in user after checking the current one.
+// All sessions are revoked and a new one is started, so the response carries fresh tokens.
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID, username, _ := middleware.GetCurrentUser(c)
+
+	var req ChangePasswordRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	// A stolen access token must not be enough to guess the current password
+	if !h.allowAttempt(c, username) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, err := h.store.Get(ctx, userID)
+	if err == nil {
+		u, err = h.store.GetByUsername(ctx, u.Username)
+	}
+	if err != nil {
+		fmt.Println("Change password lookup error:", err)
+		problem.Respond(c, problem.Internal("Could not change password"))
+		return
+	}
+
+	if err := auth.CheckPassword(u.Password, req.CurrentPassword); err != nil {
+		if err := h.guard.Failure(ctx, c.ClientIP(), u.Username); err != nil {
+			fmt.Println("Login guard error:", err)
+		}
+		h.recordAttempt(c, u.Username, false, lockout.ReasonWrongPassword)
+		problem.Respond(c, problem.Forbidden("Current password is incorrect"))
+		return
+	}
+
+	if err := h.setPassword(ctx, u, req.NewPassword); err != nil {
+		fmt.Println("Change password error:", err)
+		problem.Respond(c, problem.Internal("Could not change password"))
+		return
+	}
+
+	h.startSession(c, u, "Password changed successfully")
+}
+
+// setPassword stores a new password and ends every session and open reset link of the user
+func (h *Handler) setPassword(ctx context.Context, u User, password string) error {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := h.store.SetPassword(ctx, u.ID, hashedPassword); err != nil {
+		return err
+	}
+	if err := h.sessions.RevokeUserSessions(ctx, u.ID, time.Now().UTC()); err != nil {
+		return err
+	}
+	return h.resetTokens.DeleteUserResetTokens(ctx, u.ID)
+}
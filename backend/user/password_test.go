@@ -0,0 +1,242 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"portfolio/auth"
+	"portfolio/internal/testutil"
+	"portfolio/lockout"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var resetLinkPattern = regexp.MustCompile(`/admin/reset-password\?token=([^\s"<&]+)`)
+
+// resetLink waits for the reset mail sent in the background and returns its token
+func (env *testEnv) resetLink(t *testing.T) string {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		messages := env.mail.Messages()
+		if len(messages) == 0 {
+			continue
+		}
+		m := resetLinkPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+		if m == nil {
+			t.Fatalf("no reset link in %q", messages[len(messages)-1].Text)
+		}
+		token, err := url.QueryUnescape(m[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	t.Fatal("no reset mail was sent")
+	return ""
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+
+	known := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "admin@example.com"}, "")
+	env.resetLink(t)
+	env.mail.Reset()
+	unknown := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "nobody@example.com"}, "")
+
+	// Both answers look the same, so the endpoint does not reveal accounts
+	if known.Code != http.StatusOK || unknown.Code != http.StatusOK || known.Body.String() != unknown.Body.String() {
+		t.Errorf("known email: %d %s, unknown email: %d %s; want the same 200", known.Code, known.Body, unknown.Code, unknown.Body)
+	}
+	if messages := env.mail.Messages(); len(messages) != 0 {
+		t.Errorf("sent %d mails for an unknown email, want none", len(messages))
+	}
+}
+
+func TestForgotPasswordRateLimit(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+
+	for i := 0; i < 2; i++ {
+		env.mail.Reset()
+		if w := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "admin@example.com"}, ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d, want 200: %s", i+1, w.Code, w.Body)
+		}
+		env.resetLink(t)
+	}
+
+	// The limit of the email is reached, the address may still ask for another one
+	env.mail.Reset()
+	w := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "ADMIN@example.com"}, "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "900" {
+		t.Errorf("third request: got %d with Retry-After %q, want 429 and 900: %s", w.Code, w.Header().Get("Retry-After"), w.Body)
+	}
+	if w := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "nobody@example.com"}, ""); w.Code != http.StatusOK {
+		t.Errorf("other email: got %d, want 200: %s", w.Code, w.Body)
+	}
+
+	// Then the address is limited too, whatever email it asks for
+	if w := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "other@example.com"}, ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("address over the limit: got %d, want 429: %s", w.Code, w.Body)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if messages := env.mail.Messages(); len(messages) != 0 {
+		t.Errorf("sent %d mails after the limit, want none", len(messages))
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	login := env.login(t, "admin", "password1")
+
+	w := env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "admin@example.com"}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("forgot: got %d, want 200: %s", w.Code, w.Body)
+	}
+	token := env.resetLink(t)
+	if to := env.mail.Messages()[0].To; len(to) != 1 || to[0] != "admin@example.com" {
+		t.Errorf("reset mail sent to %v, want admin@example.com", to)
+	}
+
+	w = env.do(http.MethodPost, "/api/password/reset", ResetPasswordRequest{Token: token, Password: "password2"}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset: got %d, want 200: %s", w.Code, w.Body)
+	}
+
+	// The reset logs out every session and the link works only once
+	w = env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: login.RefreshToken}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after reset: got %d, want 401: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPost, "/api/password/reset", ResetPasswordRequest{Token: token, Password: "password3"}, "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("second reset: got %d, want 400: %s", w.Code, w.Body)
+	}
+	env.login(t, "admin", "password2")
+}
+
+func TestResetPasswordKeepsOnlyNewestLink(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+
+	env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "admin@example.com"}, "")
+	first := env.resetLink(t)
+	env.mail.Reset()
+	env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "admin@example.com"}, "")
+	second := env.resetLink(t)
+
+	w := env.do(http.MethodPost, "/api/password/reset", ResetPasswordRequest{Token: first, Password: "password2"}, "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("older link: got %d, want 400: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPost, "/api/password/reset", ResetPasswordRequest{Token: second, Password: "password2"}, "")
+	if w.Code != http.StatusOK {
+		t.Errorf("newest link: got %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+func TestResetPasswordRejectsExpiredLink(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.createUser(t, "admin", "password1")
+	now := time.Now().UTC()
+	err := env.resetTokens.SaveResetToken(context.Background(), ResetToken{
+		Hash:      auth.HashToken("expired"),
+		UserID:    u.ID,
+		ExpiresAt: now.Add(-time.Minute),
+		CreatedAt: now.Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"expired", "unknown"} {
+		w := env.do(http.MethodPost, "/api/password/reset", ResetPasswordRequest{Token: token, Password: "password2"}, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("token %q: got %d, want 400: %s", token, w.Code, w.Body)
+		}
+	}
+	env.login(t, "admin", "password1")
+}
+
+func TestResetPasswordLiftsLockout(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	guard := lockout.NewGuard(env.lockout, lockout.Policy{MaxFailures: 3, IPMaxFailures: 20, LockoutDuration: 15 * time.Minute})
+	for i := 0; i < 3; i++ {
+		if err := guard.Failure(context.Background(), "10.0.0.1", "admin"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env.do(http.MethodPost, "/api/password/forgot", ForgotPasswordRequest{Email: "admin@example.com"}, "")
+	w := env.do(http.MethodPost, "/api/password/reset", ResetPasswordRequest{Token: env.resetLink(t), Password: "password2"}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset: got %d, want 200: %s", w.Code, w.Body)
+	}
+	env.login(t, "admin", "password2")
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	login := env.login(t, "admin", "password1")
+	other := env.login(t, "admin", "password1")
+
+	w := env.do(http.MethodPut, "/api/me/password", ChangePasswordRequest{CurrentPassword: "password1", NewPassword: "password2"}, login.Token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("change: got %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp LoginResponse
+	testutil.Decode(t, w, &resp)
+
+	// Every other session ends, the response carries the tokens of a new one
+	w = env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: other.RefreshToken}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("other session after change: got %d, want 401: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: resp.RefreshToken}, "")
+	if w.Code != http.StatusOK {
+		t.Errorf("new session after change: got %d, want 200: %s", w.Code, w.Body)
+	}
+	env.login(t, "admin", "password2")
+}
+
+func TestChangePasswordRejectsWrongCurrentPassword(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	login := env.login(t, "admin", "password1")
+
+	w := env.do(http.MethodPut, "/api/me/password", ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "password2"}, login.Token)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got %d, want 403: %s", w.Code, w.Body)
+	}
+
+	// A wrong guess counts like a failed login
+	w = env.do(http.MethodPut, "/api/me/password", ChangePasswordRequest{CurrentPassword: "password1", NewPassword: "password2"}, login.Token)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("retry: got %d, want 429: %s", w.Code, w.Body)
+	}
+}
+
+func TestUpdateUserPasswordEndsSessions(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	bob := env.createUser(t, "bob", "password1")
+	admin := env.login(t, "admin", "password1")
+	session := env.login(t, "bob", "password1")
+
+	path := "/api/superadmin/users/" + strconv.Itoa(bob.ID)
+	w := env.do(http.MethodPut, path, User{Username: "bob", Email: "bob@example.com", Password: "password2"}, admin.Token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: got %d, want 200: %s", w.Code, w.Body)
+	}
+
+	w = env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: session.RefreshToken}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("bob's session after update: got %d, want 401: %s", w.Code, w.Body)
+	}
+	env.login(t, "bob", "password2")
+}
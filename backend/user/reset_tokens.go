@@ -0,0 +1,29 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrResetTokenNotFound is returned when no reset token matches a hash
+var ErrResetTokenNotFound = errors.New("password reset token not found")
+
+// ResetToken is the stored form of a password reset link; only the token hash is kept
+type ResetToken struct {
+	Hash      string
+	UserID    int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// ResetTokenStore persists password reset tokens
+type ResetTokenStore interface {
+	SaveResetToken(ctx context.Context, t ResetToken) error
+	GetResetToken(ctx context.Context, hash string) (ResetToken, error)
+	// MarkResetTokenUsed sets UsedAt if it is still empty and reports whether it did,
+	// so a link cannot be used twice even by concurrent requests
+	MarkResetTokenUsed(ctx context.Context, hash string, at time.Time) (bool, error)
+	DeleteUserResetTokens(ctx context.Context, userID int) error
+}
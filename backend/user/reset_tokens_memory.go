@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryResetTokenStore implements ResetTokenStore in process memory
+type MemoryResetTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]ResetToken // Keyed by token hash
+}
+
+// NewMemoryResetTokenStore creates an empty in-memory reset token store
+func NewMemoryResetTokenStore() *MemoryResetTokenStore {
+	return &MemoryResetTokenStore{tokens: map[string]ResetToken{}}
+}
+
+// SaveResetToken inserts a new reset token hash
+func (s *MemoryResetTokenStore) SaveResetToken(ctx context.Context, t ResetToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[t.Hash] = t
+	return nil
+}
+
+// GetResetToken returns a reset token by its hash
+func (s *MemoryResetTokenStore) GetResetToken(ctx context.Context, hash string) (ResetToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[hash]
+	if !ok {
+		return ResetToken{}, ErrResetTokenNotFound
+	}
+	return t, nil
+}
+
+// MarkResetTokenUsed sets UsedAt only if the token has not been used yet
+func (s *MemoryResetTokenStore) MarkResetTokenUsed(ctx context.Context, hash string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[hash]
+	if !ok || t.UsedAt != nil {
+		return false, nil
+	}
+	t.UsedAt = &at
+	s.tokens[hash] = t
+	return true, nil
+}
+
+// DeleteUserResetTokens removes every reset token of a user
+func (s *MemoryResetTokenStore) DeleteUserResetTokens(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, t := range s.tokens {
+		if t.UserID == userID {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"time"
+)
+
+// SQLResetTokenStore implements ResetTokenStore on PostgreSQL or SQLite
+type SQLResetTokenStore struct {
+	conn *db.DB
+}
+
+// NewSQLResetTokenStore creates a reset token store backed by an SQL database
+func NewSQLResetTokenStore(conn *db.DB) *SQLResetTokenStore {
+	return &SQLResetTokenStore{conn: conn}
+}
+
+// SaveResetToken inserts a new reset token hash
+func (s *SQLResetTokenStore) SaveResetToken(ctx context.Context, t ResetToken) error {
+	_, err := s.conn.ExecContext(ctx,
+		"INSERT INTO password_reset_tokens (token_hash, user_id, expires_at, created_at) VALUES ($1, $2, $3, $4)",
+		t.Hash, t.UserID, t.ExpiresAt, t.CreatedAt)
+	return err
+}
+
+// GetResetToken returns a reset token by its hash
+func (s *SQLResetTokenStore) GetResetToken(ctx context.Context, hash string) (ResetToken, error) {
+	var t ResetToken
+	var usedAt sql.NullTime
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT token_hash, user_id, expires_at, created_at, used_at FROM password_reset_tokens WHERE token_hash=$1", hash).
+		Scan(&t.Hash, &t.UserID, &t.ExpiresAt, &t.CreatedAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ResetToken{}, ErrResetTokenNotFound
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	return t, err
+}
+
+// MarkResetTokenUsed sets used_at only if the token has not been used yet
+func (s *SQLResetTokenStore) MarkResetTokenUsed(ctx context.Context, hash string, at time.Time) (bool, error) {
+	result, err := s.conn.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at=$1 WHERE token_hash=$2 AND used_at IS NULL", at, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// DeleteUserResetTokens removes every reset token of a user
+func (s *SQLResetTokenStore) DeleteUserResetTokens(ctx context.Context, userID int) error {
+	_, err := s.conn.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=$1", userID)
+	return err
+}
@@ -0,0 +1,132 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"portfolio/db"
+	"strings"
+)
+
+// sortColumns are the fields a user list can be sorted by
+var sortColumns = map[string]string{
+	"id":       "id",
+	"username": "LOWER(username)",
+	"email":    "LOWER(email)",
+}
+
+// SQLStore implements Store on PostgreSQL or SQLite
+type SQLStore struct {
+	conn *db.DB
+}
+
+// NewSQLStore creates a user store backed by an SQL database
+func NewSQLStore(conn *db.DB) *SQLStore {
+	return &SQLStore{conn: conn}
+}
+
+// List returns the page of users matching filter, without their passwords, and the number of matches
+func (s *SQLStore) List(ctx context.Context, filter Filter) ([]User, int, error) {
+	where := ""
+	var args []any
+	if filter.Search != "" {
+		where = ` WHERE LOWER(username) LIKE $1 ESCAPE '\' OR LOWER(COALESCE(email, '')) LIKE $1 ESCAPE '\'`
+		args = append(args, db.ContainsPattern(strings.ToLower(filter.Search)))
+	}
+
+	var total int
+	if err := s.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.conn.QueryContext(ctx, "SELECT id, username, COALESCE(email, '') FROM users"+where+filter.Clause(sortColumns), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+// Get returns a single user by ID without the password
+func (s *SQLStore) Get(ctx context.Context, id int) (User, error) {
+	var u User
+	err := s.conn.QueryRowContext(ctx, "SELECT id, username, COALESCE(email, '') FROM users WHERE id=$1", id).
+		Scan(&u.ID, &u.Username, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return u, err
+}
+
+// GetByUsername returns a user including the password hash, for login checks
+func (s *SQLStore) GetByUsername(ctx context.Context, username string) (User, error) {
+	var u User
+	err := s.conn.QueryRowContext(ctx, "SELECT id, username, password, COALESCE(email, '') FROM users WHERE username=$1", username).
+		Scan(&u.ID, &u.Username, &u.Password, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return u, err
+}
+
+// GetByEmail returns a single user by email without the password
+func (s *SQLStore) GetByEmail(ctx context.Context, email string) (User, error) {
+	var u User
+	err := s.conn.QueryRowContext(ctx, "SELECT id, username, COALESCE(email, '') FROM users WHERE LOWER(email)=LOWER($1)", email).
+		Scan(&u.ID, &u.Username, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return u, err
+}
+
+// Create inserts a new user and stores the generated ID in u
+func (s *SQLStore) Create(ctx context.Context, u *User) error {
+	err := s.conn.QueryRowContext(ctx,
+		"INSERT INTO users (username, password, email) VALUES ($1, $2, $3) RETURNING id",
+		u.Username, u.Password, u.Email).Scan(&u.ID)
+	if db.IsUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// Update saves the user with u.ID; the password only changes when u.Password is set
+func (s *SQLStore) Update(ctx context.Context, u User) error {
+	var result sql.Result
+	var err error
+	if u.Password != "" {
+		result, err = s.conn.ExecContext(ctx,
+			"UPDATE users SET username=$1, email=$2, password=$3 WHERE id=$4",
+			u.Username, u.Email, u.Password, u.ID)
+	} else {
+		result, err = s.conn.ExecContext(ctx,
+			"UPDATE users SET username=$1, email=$2 WHERE id=$3",
+			u.Username, u.Email, u.ID)
+	}
+	if db.IsUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// SetPassword replaces the password hash of a user
+func (s *SQLStore) SetPassword(ctx context.Context, id int, hash string) error {
+	result, err := s.conn.ExecContext(ctx, "UPDATE users SET password=$1 WHERE id=$2", hash, id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
+
+// Delete removes a user by ID
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, "DELETE FROM users WHERE id=$1", id)
+	return db.RequireRows(result, err, ErrNotFound)
+}
@@ -0,0 +1,33 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"portfolio/listing"
+)
+
+// Errors returned by a Store
+var (
+	ErrNotFound  = errors.New("user not found")                   // No user matches the given ID or username
+	ErrDuplicate = errors.New("username or email already exists") // Another user has the username or email
+)
+
+// Filter selects users in List; zero fields match everything
+type Filter struct {
+	Search string // Text in the username or email, ignoring case
+
+	listing.Params // Page and order; the zero value returns every user by ID
+}
+
+// Store is the persistence interface the user handlers depend on.
+// Password fields passed to and returned from a Store always hold bcrypt hashes.
+type Store interface {
+	List(ctx context.Context, filter Filter) ([]User, int, error)     // The page of matching users, without passwords, and the number of matches
+	Get(ctx context.Context, id int) (User, error)                    // Single user by ID, without password
+	GetByUsername(ctx context.Context, username string) (User, error) // Single user including the password hash
+	GetByEmail(ctx context.Context, email string) (User, error)       // Single user by email (case-insensitive), without password
+	Create(ctx context.Context, u *User) error                        // Inserts u and sets its ID, or ErrDuplicate
+	Update(ctx context.Context, u User) error                         // Updates u.ID, or ErrNotFound / ErrDuplicate; an empty Password keeps the current one
+	SetPassword(ctx context.Context, id int, hash string) error       // Replaces only the password hash
+	Delete(ctx context.Context, id int) error                         // Removes the user by ID, or ErrNotFound
+}
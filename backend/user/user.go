@@ -2,76 +2,238 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"portfolio/auth" // Auth package import
+	"portfolio/listing"
+	"portfolio/lockout"
+	"portfolio/mail"
+	"portfolio/problem"
+	"portfolio/twofactor"
+	"portfolio/validation"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool" // PostgreSQL pool library
 )
 
 // User struct represents data in the users table
 type User struct {
-	ID       int    `json:"id"`                 // ID field in JSON
-	Username string `json:"username"`           // Username field in JSON
-	Password string `json:"password,omitempty"` // Password in JSON (shown empty if not provided)
-	Email    string `json:"email"`              // Email in JSON
+	ID       int    `json:"id"`                                                  // ID field in JSON
+	Username string `json:"username" binding:"notblank,max=50"`                  // Username field in JSON
+	Password string `json:"password,omitempty" binding:"omitempty,min=8,max=72"` // Password in JSON (shown empty if not provided)
+	Email    string `json:"email" binding:"omitempty,email,max=254"`             // Email in JSON
 }
 
 // LoginRequest struct for login endpoint
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username string `json:"username" binding:"required,max=255"`
+	Password string `json:"password" binding:"required,max=1024"`
 }
 
 // LoginResponse struct for login response
 type LoginResponse struct {
-	Message string `json:"message"`
-	Token   string `json:"token"`
-	User    User   `json:"user"`
+	Message      string `json:"message"`
+	Token        string `json:"token"`         // Short-lived access token
+	RefreshToken string `json:"refresh_token"` // Opaque token for /api/refresh, rotated on every use
+	ExpiresIn    int    `json:"expires_in"`    // Access token lifetime in seconds
+	User         User   `json:"user"`
 }
 
-var Pool *pgxpool.Pool
+// ChallengeResponse struct returned by login when a second factor is required
+type ChallengeResponse struct {
+	Message           string `json:"message"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"` // Send to /api/login/2fa with the code, valid for 5 minutes
+}
+
+// TwoFactorLoginRequest struct for the second step of a two-factor login
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP code or recovery code
+}
 
-func SetDB(pool *pgxpool.Pool) {
-	Pool = pool
+// RefreshRequest struct for the refresh and logout endpoints
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Handler serves the login and user management endpoints using a Store
+type Handler struct {
+	store       Store
+	sessions    auth.SessionStore
+	twoFactor   twofactor.Store
+	resetTokens ResetTokenStore
+	guard       *lockout.Guard
+	mailer      *mail.Mailer
+}
+
+// NewHandler creates user handlers backed by the given stores
+func NewHandler(store Store, sessions auth.SessionStore, twoFactor twofactor.Store, resetTokens ResetTokenStore, guard *lockout.Guard, mailer *mail.Mailer) *Handler {
+	return &Handler{store: store, sessions: sessions, twoFactor: twoFactor, resetTokens: resetTokens, guard: guard, mailer: mailer}
 }
 
 // Login function - Authentication login function
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	var loginReq LoginRequest
 
 	// Get data from JSON
-	if err := c.ShouldBindJSON(&loginReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data format"})
+	if !validation.Bind(c, &loginReq) {
 		return
 	}
 
-	// Find user in database
-	var user User
-	err := Pool.QueryRow(context.Background(),
-		"SELECT id, username, password, email FROM users WHERE username=$1",
-		loginReq.Username).Scan(&user.ID, &user.Username, &user.Password, &user.Email)
+	// Refuse early while the account or IP is backing off or locked
+	if !h.allowAttempt(c, loginReq.Username) {
+		return
+	}
 
+	// Find user in database
+	user, err := h.store.GetByUsername(c.Request.Context(), loginReq.Username)
 	if err != nil {
-		fmt.Println("User not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		// Compare anyway so unknown usernames take as long as wrong passwords
+		auth.CheckPasswordDummy(loginReq.Password)
+		h.loginFailed(c, loginReq.Username, lockout.ReasonUnknownUser, "Invalid username or password")
 		return
 	}
 
 	// Check password
 	if err := auth.CheckPassword(user.Password, loginReq.Password); err != nil {
-		fmt.Println("Wrong password:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		h.loginFailed(c, loginReq.Username, lockout.ReasonWrongPassword, "Invalid username or password")
+		return
+	}
+
+	// With 2FA enabled the password only earns a challenge token, not a session
+	enabled, err := twofactor.Enabled(c.Request.Context(), h.twoFactor, user.ID)
+	if err != nil {
+		fmt.Println("2FA lookup error:", err)
+		problem.Respond(c, problem.Internal("Could not generate token"))
+		return
+	}
+	if enabled {
+		challenge, err := auth.GenerateChallengeToken(user.ID, user.Username)
+		if err != nil {
+			fmt.Println("Token generation error:", err)
+			problem.Respond(c, problem.Internal("Could not generate token"))
+			return
+		}
+		c.JSON(http.StatusOK, ChallengeResponse{
+			Message:           "Two-factor code required",
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		})
+		return
+	}
+
+	h.loginSucceeded(c, user)
+}
+
+// LoginTwoFactor completes a two-factor login with the challenge token and a TOTP or recovery code
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	claims, err := auth.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		problem.Respond(c, problem.Unauthorized("Invalid or expired challenge, please log in again"))
+		return
+	}
+
+	// Codes count towards the same limits as passwords, or six digits could be guessed
+	if !h.allowAttempt(c, claims.Username) {
+		return
+	}
+
+	ok, err := twofactor.Verify(c.Request.Context(), h.twoFactor, claims.UserID, req.Code)
+	if err != nil {
+		fmt.Println("2FA verification error:", err)
+		problem.Respond(c, problem.Internal("Could not generate token"))
+		return
+	}
+	if !ok {
+		h.loginFailed(c, claims.Username, lockout.ReasonInvalidCode, "Invalid two-factor code")
+		return
+	}
+
+	user, err := h.store.Get(c.Request.Context(), claims.UserID)
+	if err != nil {
+		problem.Respond(c, problem.Unauthorized("Invalid or expired challenge, please log in again"))
+		return
+	}
+
+	h.loginSucceeded(c, user)
+}
+
+// allowAttempt answers 429 and returns false while the client has to wait before logging in again
+func (h *Handler) allowAttempt(c *gin.Context, username string) bool {
+	wait, err := h.guard.Check(c.Request.Context(), c.ClientIP(), username)
+	if err != nil {
+		fmt.Println("Login guard error:", err)
+		problem.Respond(c, problem.Internal("Login is temporarily unavailable"))
+		return false
+	}
+	if wait == 0 {
+		return true
+	}
+
+	h.recordAttempt(c, username, false, lockout.ReasonThrottled)
+
+	// Round up so clients never retry a moment too early
+	seconds := int((wait + time.Second - 1) / time.Second)
+	problem.Respond(c, problem.TooManyRequests(fmt.Sprintf("Too many failed login attempts, try again in %d seconds", seconds), wait))
+	return false
+}
+
+// loginFailed counts a failed attempt and sends the same 401 whatever the cause
+func (h *Handler) loginFailed(c *gin.Context, username string, reason string, message string) {
+	if err := h.guard.Failure(c.Request.Context(), c.ClientIP(), username); err != nil {
+		fmt.Println("Login guard error:", err)
+	}
+	h.recordAttempt(c, username, false, reason)
+	problem.Respond(c, problem.Unauthorized(message))
+}
+
+// loginSucceeded clears the failures of the account and starts the session
+func (h *Handler) loginSucceeded(c *gin.Context, user User) {
+	if err := h.guard.Success(c.Request.Context(), user.Username); err != nil {
+		fmt.Println("Login guard error:", err)
+	}
+	h.recordAttempt(c, user.Username, true, lockout.ReasonSuccess)
+	h.startSession(c, user, "Login successful")
+}
+
+// recordAttempt adds the request to the login attempt log
+func (h *Handler) recordAttempt(c *gin.Context, username string, success bool, reason string) {
+	err := h.guard.Record(c.Request.Context(), lockout.Attempt{
+		Username:  username,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Success:   success,
+		Reason:    reason,
+	})
+	if err != nil {
+		fmt.Println("Login attempt log error:", err)
+	}
+}
+
+// startSession creates a session for an authenticated user and sends the login response
+func (h *Handler) startSession(c *gin.Context, user User, message string) {
+	// Start a new session with its first refresh token
+	session, refreshToken, err := auth.StartSession(c.Request.Context(), h.sessions, user.ID)
+	if err != nil {
+		fmt.Println("Session creation error:", err)
+		problem.Respond(c, problem.Internal("Could not generate token"))
 		return
 	}
 
 	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID)
 	if err != nil {
 		fmt.Println("Token generation error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		problem.Respond(c, problem.Internal("Could not generate token"))
 		return
 	}
 
@@ -80,83 +242,250 @@ func Login(c *gin.Context) {
 
 	// Successful login response
 	response := LoginResponse{
-		Message: "Login successful",
-		Token:   token,
-		User:    user,
+		Message:      message,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL().Seconds()),
+		User:         user,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Refresh exchanges a refresh token for a new access token and a new refresh token
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	session, refreshToken, err := auth.RotateRefreshToken(c.Request.Context(), h.sessions, req.RefreshToken)
+	if errors.Is(err, auth.ErrRefreshTokenReused) {
+		fmt.Println("Refresh token reuse detected, session revoked")
+		problem.Respond(c, problem.Unauthorized("Refresh token has already been used, please log in again"))
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		problem.Respond(c, problem.Unauthorized("Invalid or expired refresh token"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Refresh error:", err)
+		problem.Respond(c, problem.Internal("Could not refresh token"))
+		return
+	}
+
+	// Username may have changed since login, so read the current one
+	user, err := h.store.Get(c.Request.Context(), session.UserID)
+	if err != nil {
+		problem.Respond(c, problem.Unauthorized("Invalid or expired refresh token"))
+		return
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID)
+	if err != nil {
+		fmt.Println("Token generation error:", err)
+		problem.Respond(c, problem.Internal("Could not generate token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{
+		Message:      "Token refreshed",
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL().Seconds()),
+		User:         user,
+	})
+}
+
+// Logout revokes the session of the given refresh token
+func (h *Handler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if !validation.Bind(c, &req) {
+		return
+	}
+
+	if err := auth.RevokeRefreshToken(c.Request.Context(), h.sessions, req.RefreshToken); err != nil {
+		fmt.Println("Logout error:", err)
+		problem.Respond(c, problem.Internal("Logout failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// RevokeSessions ends every session of a user, logging them out on all devices
+func (h *Handler) RevokeSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	_, err = h.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("User not found"))
+		return
+	}
+	if err != nil {
+		problem.Respond(c, problem.Internal("Could not revoke sessions"))
+		return
+	}
+
+	if err := h.sessions.RevokeUserSessions(ctx, id, time.Now().UTC()); err != nil {
+		problem.Respond(c, problem.Internal("Could not revoke sessions"))
+		return
+	}
+
+	c.JSON(200, gin.H{"message": fmt.Sprintf("All sessions of user ID %d revoked", id)})
+}
+
+// UnlockUser clears the failed logins of a user, lifting a lockout before it expires
+func (h *Handler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	u, err := h.store.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("User not found"))
+		return
+	}
+	if err != nil {
+		problem.Respond(c, problem.Internal("Could not unlock user"))
+		return
+	}
+
+	if err := h.guard.Unlock(c.Request.Context(), u.Username); err != nil {
+		fmt.Println("Unlock error:", err)
+		problem.Respond(c, problem.Internal("Could not unlock user"))
+		return
+	}
+
+	c.JSON(200, gin.H{"message": fmt.Sprintf("User ID %d unlocked", id)})
+}
+
 // DeleteUser delete operation
-func DeleteUser(c *gin.Context) {
+func (h *Handler) DeleteUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid ID"})
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
+		return
+	}
+
+	// End the user's sessions first so their tokens stop working right away
+	if err := h.sessions.RevokeUserSessions(c.Request.Context(), id, time.Now().UTC()); err != nil {
+		problem.Respond(c, problem.Internal("Delete operation failed"))
 		return
 	}
 
-	_, err = Pool.Exec(context.Background(), "DELETE FROM users WHERE id=$1", id)
+	err = h.store.Delete(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("User not found"))
+		return
+	}
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Delete operation failed"})
+		problem.Respond(c, problem.Internal("Delete operation failed"))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": fmt.Sprintf("User ID %d deleted successfully", id)})
 }
 
-// GetUsers returns the list of users (excluding password)
-func GetUsers(c *gin.Context) {
-	rows, err := Pool.Query(context.Background(), "SELECT id, username, email FROM users")
+// GetUsers returns a page of users (excluding password); ?q= searches usernames and emails and
+// ?sort= takes id, username or email
+func (h *Handler) GetUsers(c *gin.Context) {
+	params, err := listing.Parse(c, "id", "id", "username", "email")
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Data could not be retrieved"})
+		problem.Respond(c, problem.BadRequest(err.Error()))
 		return
 	}
-	defer rows.Close()
+	filter := Filter{Search: strings.TrimSpace(c.Query("q")), Params: params}
 
-	var users []User
-	for rows.Next() {
-		var u User
-		if err := rows.Scan(&u.ID, &u.Username, &u.Email); err != nil {
-			c.JSON(500, gin.H{"error": "Row could not be read"})
-			return
-		}
-		users = append(users, u)
+	users, total, err := h.store.List(c.Request.Context(), filter)
+	if err != nil {
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
 	}
 
+	listing.SetHeaders(c, params, total)
 	c.JSON(200, users)
 }
 
-// UpdateUser user update operation
-func UpdateUser(c *gin.Context) {
-	var u User
-	if err := c.BindJSON(&u); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid data"})
+// UserExists reports whether a user with the given ID exists, for handlers of other packages
+func (h *Handler) UserExists(ctx context.Context, id int) (bool, error) {
+	_, err := h.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetUser returns a single user by ID (excluding password)
+func (h *Handler) GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, problem.BadRequest("Invalid ID"))
 		return
 	}
 
-	// If password exists, hash it
-	if u.Password != "" {
-		hashedPassword, err := auth.HashPassword(u.Password)
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Could not hash password"})
-			return
-		}
+	u, err := h.store.Get(c.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("User not found"))
+		return
+	}
+	if err != nil {
+		problem.Respond(c, problem.Internal("Data could not be retrieved"))
+		return
+	}
+
+	c.JSON(200, u)
+}
 
-		_, err = Pool.Exec(context.Background(),
-			"UPDATE users SET username=$1, email=$2, password=$3 WHERE id=$4",
-			u.Username, u.Email, hashedPassword, u.ID)
+// UpdateUser user update operation; the user is taken from the URL on PUT /users/:id and from
+// the body on PUT /users
+func (h *Handler) UpdateUser(c *gin.Context) {
+	var u User
+	if !validation.Bind(c, &u) {
+		return
+	}
+	if idStr := c.Param("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Update failed"})
+			problem.Respond(c, problem.BadRequest("Invalid ID"))
 			return
 		}
-	} else {
-		_, err := Pool.Exec(context.Background(),
-			"UPDATE users SET username=$1, email=$2 WHERE id=$3",
-			u.Username, u.Email, u.ID)
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Update failed"})
+		u.ID = id
+	}
+
+	// A new password is set separately, so it ends the user's sessions and reset links too
+	password := u.Password
+	u.Password = ""
+
+	ctx := c.Request.Context()
+	err := h.store.Update(ctx, u)
+	if errors.Is(err, ErrNotFound) {
+		problem.Respond(c, problem.NotFound("User not found"))
+		return
+	}
+	if errors.Is(err, ErrDuplicate) {
+		problem.Respond(c, problem.Conflict("Username or email is already taken"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Update user error:", err)
+		problem.Respond(c, problem.Internal("Update failed"))
+		return
+	}
+
+	if password != "" {
+		if err := h.setPassword(ctx, u, password); err != nil {
+			fmt.Println("Update password error:", err)
+			problem.Respond(c, problem.Internal("Could not change password"))
 			return
 		}
 	}
@@ -165,27 +494,37 @@ func UpdateUser(c *gin.Context) {
 }
 
 // CreateUser create new user
-func CreateUser(c *gin.Context) {
+func (h *Handler) CreateUser(c *gin.Context) {
 	var u User
-	if err := c.BindJSON(&u); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid data"})
+	if !validation.Bind(c, &u) {
+		return
+	}
+
+	if u.Password == "" {
+		validation.Fail(c, validation.FieldError{Field: "password", Reason: "is required"})
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := auth.HashPassword(u.Password)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Could not hash password"})
+		problem.Respond(c, problem.Internal("Could not hash password"))
 		return
 	}
+	u.Password = hashedPassword
 
-	_, err = Pool.Exec(context.Background(),
-		"INSERT INTO users (username, password, email) VALUES ($1, $2, $3)",
-		u.Username, hashedPassword, u.Email)
+	err = h.store.Create(c.Request.Context(), &u)
+	if errors.Is(err, ErrDuplicate) {
+		problem.Respond(c, problem.Conflict("Username or email is already taken"))
+		return
+	}
 	if err != nil {
-		c.JSON(500, gin.H{"error": "User could not be created"})
+		fmt.Println("Create user error:", err)
+		problem.Respond(c, problem.Internal("User could not be created"))
 		return
 	}
 
-	c.JSON(201, gin.H{"message": "User created successfully"})
+	u.Password = "" // Never send the hash back
+	c.Header("Location", fmt.Sprintf("/api/superadmin/users/%d", u.ID))
+	c.JSON(201, u)
 }
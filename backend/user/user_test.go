@@ -0,0 +1,427 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"portfolio/auth"
+	"portfolio/internal/testutil"
+	"portfolio/lockout"
+	"portfolio/mail"
+	"portfolio/middleware"
+	"portfolio/twofactor"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	if err := auth.LoadKeys(false); err != nil {
+		panic(err)
+	}
+	testutil.Main(m)
+}
+
+// testEnv is a user handler on memory stores, routed like routes.SetupRoutes
+type testEnv struct {
+	router      *gin.Engine
+	users       *MemoryStore
+	sessions    *auth.MemorySessionStore
+	twoFactor   *twofactor.MemoryStore
+	resetTokens *MemoryResetTokenStore
+	lockout     *lockout.MemoryStore
+	mail        *mail.MemorySender
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		router:      gin.New(),
+		users:       NewMemoryStore(),
+		sessions:    auth.NewMemorySessionStore(),
+		twoFactor:   twofactor.NewMemoryStore(),
+		resetTokens: NewMemoryResetTokenStore(),
+		lockout:     lockout.NewMemoryStore(),
+		mail:        mail.NewMemorySender(),
+	}
+	guard := lockout.NewGuard(env.lockout, lockout.Policy{MaxFailures: 3, IPMaxFailures: 20, LockoutDuration: 15 * time.Minute, MaxResetRequests: 2, IPMaxResetRequests: 3})
+	mailer := testutil.Mailer(t, env.mail)
+	h := NewHandler(env.users, env.sessions, env.twoFactor, env.resetTokens, guard, mailer)
+
+	api := env.router.Group("/api")
+	api.POST("/login", h.Login)
+	api.POST("/login/2fa", h.LoginTwoFactor)
+	api.POST("/refresh", h.Refresh)
+	api.POST("/logout", h.Logout)
+	api.POST("/password/forgot", h.ForgotPassword)
+	api.POST("/password/reset", h.ResetPassword)
+	me := api.Group("/me", middleware.AuthMiddleware(env.sessions))
+	me.PUT("/password", h.ChangePassword)
+	admin := api.Group("/superadmin", middleware.AuthMiddleware(env.sessions))
+	admin.POST("/users", h.CreateUser)
+	admin.GET("/users/:id", h.GetUser)
+	admin.PUT("/users/:id", h.UpdateUser)
+	admin.DELETE("/users/:id", h.DeleteUser)
+	admin.DELETE("/users/:id/sessions", h.RevokeSessions)
+	return env
+}
+
+// createUser adds a user with the given password
+func (env *testEnv) createUser(t *testing.T, username, password string) User {
+	t.Helper()
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := User{Username: username, Password: hash, Email: username + "@example.com"}
+	if err := env.users.Create(context.Background(), &u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// do sends a JSON request, with the access token if one is given
+func (env *testEnv) do(method, path string, body any, token string) *httptest.ResponseRecorder {
+	if token == "" {
+		return testutil.Do(env.router, method, path, body)
+	}
+	return testutil.Do(env.router, method, path, body, "Authorization", "Bearer "+token)
+}
+
+// login logs in and returns the response, failing the test unless it is a 200
+func (env *testEnv) login(t *testing.T, username, password string) LoginResponse {
+	t.Helper()
+	w := env.do(http.MethodPost, "/api/login", LoginRequest{Username: username, Password: password}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp LoginResponse
+	testutil.Decode(t, w, &resp)
+	return resp
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+
+	resp := env.login(t, "admin", "password1")
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("missing tokens: %+v", resp)
+	}
+	if resp.User.Username != "admin" || resp.User.Password != "" {
+		t.Errorf("user = %+v, want admin without a password", resp.User)
+	}
+	claims, err := auth.ValidateToken(resp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.sessions.GetSession(context.Background(), claims.SessionID); err != nil {
+		t.Errorf("session of the token: %v", err)
+	}
+}
+
+func TestLoginRejectsBadCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		body any
+		want int
+	}{
+		{"wrong password", LoginRequest{Username: "admin", Password: "wrong"}, http.StatusUnauthorized},
+		{"unknown user", LoginRequest{Username: "nobody", Password: "password1"}, http.StatusUnauthorized},
+		{"missing password", map[string]string{"username": "admin"}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.createUser(t, "admin", "password1")
+
+			w := env.do(http.MethodPost, "/api/login", tt.body, "")
+			if w.Code != tt.want {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	login := env.login(t, "admin", "password1")
+
+	w := env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: login.RefreshToken}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp LoginResponse
+	testutil.Decode(t, w, &resp)
+	if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == login.RefreshToken {
+		t.Errorf("refresh did not return a new token pair: %+v", resp)
+	}
+	if resp.User.Username != "admin" || resp.User.Password != "" {
+		t.Errorf("user = %+v, want admin without a password", resp.User)
+	}
+
+	old, err := auth.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := auth.ValidateToken(resp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.SessionID != old.SessionID {
+		t.Errorf("refresh started session %s, want to keep %s", refreshed.SessionID, old.SessionID)
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: "not-a-token"}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("got %d, want 401: %s", w.Code, w.Body)
+	}
+}
+
+func TestRefreshTokenReuseEndsSession(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	login := env.login(t, "admin", "password1")
+
+	w := env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: login.RefreshToken}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d: %s", w.Code, w.Body)
+	}
+	var refreshed LoginResponse
+	testutil.Decode(t, w, &refreshed)
+
+	// A copied token shows up again: the whole login ends
+	w = env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: login.RefreshToken}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("reused token: got %d, want 401: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: refreshed.RefreshToken}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("latest token after reuse: got %d, want 401: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPut, "/api/me/password", ChangePasswordRequest{CurrentPassword: "password1", NewPassword: "password2"}, refreshed.Token)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("access token after reuse: got %d, want 401: %s", w.Code, w.Body)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	login := env.login(t, "admin", "password1")
+
+	for i := 0; i < 2; i++ { // Logging out twice is harmless
+		w := env.do(http.MethodPost, "/api/logout", RefreshRequest{RefreshToken: login.RefreshToken}, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("logout %d: got %d, want 200: %s", i+1, w.Code, w.Body)
+		}
+	}
+	w := env.do(http.MethodPost, "/api/refresh", RefreshRequest{RefreshToken: login.RefreshToken}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout: got %d, want 401: %s", w.Code, w.Body)
+	}
+	w = env.do(http.MethodPut, "/api/me/password", ChangePasswordRequest{CurrentPassword: "password1", NewPassword: "password2"}, login.Token)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("access token after logout: got %d, want 401: %s", w.Code, w.Body)
+	}
+}
+
+// enableTwoFactor turns on 2FA for a user with one recovery code, abcde-12345
+func (env *testEnv) enableTwoFactor(t *testing.T, u User) {
+	t.Helper()
+	ctx := context.Background()
+	secret, err := twofactor.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.twoFactor.SaveTOTP(ctx, twofactor.Enrollment{UserID: u.ID, Secret: secret, CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.twoFactor.ConfirmTOTP(ctx, u.ID, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.twoFactor.ReplaceRecoveryCodes(ctx, u.ID, []string{auth.HashToken("abcde12345")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	env := newTestEnv(t)
+	env.enableTwoFactor(t, env.createUser(t, "admin", "password1"))
+
+	// The password alone only earns a challenge
+	w := env.do(http.MethodPost, "/api/login", LoginRequest{Username: "admin", Password: "password1"}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d, want 200: %s", w.Code, w.Body)
+	}
+	var challenge ChallengeResponse
+	testutil.Decode(t, w, &challenge)
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		t.Fatalf("login = %s, want a challenge", w.Body)
+	}
+	if _, err := auth.ValidateToken(challenge.ChallengeToken); err == nil {
+		t.Error("the challenge token works as an access token")
+	}
+
+	w = env.do(http.MethodPost, "/api/login/2fa", TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "ABCDE-12345"}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("recovery code: got %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp LoginResponse
+	testutil.Decode(t, w, &resp)
+	if resp.Token == "" || resp.RefreshToken == "" || resp.User.Username != "admin" {
+		t.Errorf("2FA login = %+v, want tokens for admin", resp)
+	}
+
+	// The recovery code is used up
+	w = env.do(http.MethodPost, "/api/login/2fa", TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "abcde-12345"}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("used recovery code: got %d, want 401: %s", w.Code, w.Body)
+	}
+}
+
+func TestLoginTwoFactorRejectsInvalidChallenge(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.createUser(t, "admin", "password1")
+	env.enableTwoFactor(t, u)
+
+	// An access token is not a challenge token
+	token, err := auth.GenerateToken(u.ID, u.Username, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, challenge := range []string{"not-a-token", token} {
+		w := env.do(http.MethodPost, "/api/login/2fa", TwoFactorLoginRequest{ChallengeToken: challenge, Code: "abcde-12345"}, "")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("challenge %q: got %d, want 401: %s", challenge, w.Code, w.Body)
+		}
+	}
+}
+
+func TestLoginBacksOffAfterFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+
+	w := env.do(http.MethodPost, "/api/login", LoginRequest{Username: "admin", Password: "wrong"}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: got %d, want 401: %s", w.Code, w.Body)
+	}
+
+	// Even the right password has to wait out the backoff
+	w = env.do(http.MethodPost, "/api/login", LoginRequest{Username: "admin", Password: "password1"}, "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("retry: got %d with Retry-After %q, want 429 with 1: %s", w.Code, w.Header().Get("Retry-After"), w.Body)
+	}
+
+	attempts, err := env.lockout.ListAttempts(context.Background(), lockout.AttemptFilter{Username: "admin", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0].Reason != lockout.ReasonThrottled || attempts[1].Reason != lockout.ReasonWrongPassword {
+		t.Errorf("attempts = %+v, want a wrong password then a throttled attempt", attempts)
+	}
+}
+
+func TestLoginLocksAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+
+	// Failures from other addresses count against the account
+	guard := lockout.NewGuard(env.lockout, lockout.Policy{MaxFailures: 3, IPMaxFailures: 20, LockoutDuration: 15 * time.Minute, MaxResetRequests: 2, IPMaxResetRequests: 3})
+	for i := 0; i < 3; i++ {
+		if err := guard.Failure(context.Background(), "10.0.0.1", "admin"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := env.do(http.MethodPost, "/api/login", LoginRequest{Username: "admin", Password: "password1"}, "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("locked account: got %d, want 429: %s", w.Code, w.Body)
+	}
+	if retry := w.Header().Get("Retry-After"); retry != "900" {
+		t.Errorf("Retry-After = %q, want 900", retry)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	admin := env.login(t, "admin", "password1")
+
+	w := env.do(http.MethodPost, "/api/superadmin/users", User{Username: "bob", Password: "password1", Email: "bob@example.com"}, admin.Token)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d, want 201: %s", w.Code, w.Body)
+	}
+	var created User
+	testutil.Decode(t, w, &created)
+	if created.ID == 0 || created.Password != "" {
+		t.Errorf("created %+v, want the new user without a password", created)
+	}
+
+	location := w.Header().Get("Location")
+	w = env.do(http.MethodGet, location, nil, admin.Token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get %q: got %d, want 200: %s", location, w.Code, w.Body)
+	}
+	var got User
+	testutil.Decode(t, w, &got)
+	if got != created {
+		t.Errorf("got %+v, want %+v", got, created)
+	}
+	env.login(t, "bob", "password1")
+}
+
+func TestMissingUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	admin := env.login(t, "admin", "password1")
+
+	tests := []struct {
+		method, path string
+		body         any
+		want         int
+	}{
+		{http.MethodGet, "/api/superadmin/users/99", nil, http.StatusNotFound},
+		{http.MethodGet, "/api/superadmin/users/abc", nil, http.StatusBadRequest},
+		{http.MethodPut, "/api/superadmin/users/99", User{Username: "bob"}, http.StatusNotFound},
+		{http.MethodPut, "/api/superadmin/users/99", User{Username: "bob", Password: "password2"}, http.StatusNotFound},
+		{http.MethodDelete, "/api/superadmin/users/99", nil, http.StatusNotFound},
+		{http.MethodDelete, "/api/superadmin/users/99/sessions", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := env.do(tt.method, tt.path, tt.body, admin.Token); w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestDuplicateUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "admin", "password1")
+	bob := env.createUser(t, "bob", "password1")
+	admin := env.login(t, "admin", "password1")
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         User
+	}{
+		{"create with a taken username", http.MethodPost, "/api/superadmin/users", User{Username: "admin", Password: "password1", Email: "new@example.com"}},
+		{"create with a taken email", http.MethodPost, "/api/superadmin/users", User{Username: "new", Password: "password1", Email: "bob@example.com"}},
+		{"rename to a taken username", http.MethodPut, "/api/superadmin/users/" + strconv.Itoa(bob.ID), User{Username: "admin", Email: "bob@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := env.do(tt.method, tt.path, tt.body, admin.Token); w.Code != http.StatusConflict {
+				t.Errorf("got %d, want 409: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
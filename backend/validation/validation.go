@@ -0,0 +1,146 @@
+// Package validation binds JSON request bodies and reports broken rules per field.
+//
+// Rules are declared with `binding` tags on the request structs, using the rules of
+// github.com/go-playground/validator plus "notblank" (not empty after trimming spaces).
+// A request that breaks them gets a 422 validation problem with one entry per field in "errors":
+//
+//	"errors": [{"field": "email", "reason": "must be a valid email address"}]
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"portfolio/problem"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+// FieldError is one broken rule of a request
+type FieldError = problem.FieldError
+
+// Errors are the field errors of one request
+type Errors []FieldError
+
+// Error joins the field errors into one line
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + " " + fe.Reason
+	}
+	return strings.Join(parts, "; ")
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Report fields by their JSON names, as the client sent them
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	if err := v.RegisterValidation("notblank", validators.NotBlank); err != nil {
+		panic(err)
+	}
+}
+
+// Bind decodes the JSON body into obj and checks its binding rules. On failure it writes
+// a 400 problem for malformed JSON, 413 for an oversized body or 422 with the field errors,
+// and returns false.
+func Bind(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErrs):
+		Fail(c, fieldErrors(validationErrs)...)
+	case errors.As(err, &typeErr):
+		Fail(c, FieldError{Field: typeErr.Field, Reason: "must be " + article(typeErr.Type.Kind())})
+	case errors.As(err, &tooLarge):
+		problem.Respond(c, problem.PayloadTooLarge(fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit)))
+	default:
+		problem.Respond(c, problem.BadRequest("Invalid JSON"))
+	}
+	return false
+}
+
+// Fail writes a 422 validation problem listing the given field errors
+func Fail(c *gin.Context, errs ...FieldError) {
+	problem.Respond(c, problem.Validation(errs...))
+}
+
+// fieldErrors converts the validator errors into field errors
+func fieldErrors(errs validator.ValidationErrors) Errors {
+	out := make(Errors, len(errs))
+	for i, fe := range errs {
+		// The namespace starts with the struct type, which means nothing to the client
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		out[i] = FieldError{Field: field, Reason: reason(fe)}
+	}
+	return out
+}
+
+// reason describes a broken rule
+func reason(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	} else if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "http_url":
+		return "must be an http or https URL"
+	case "max":
+		return "must be at most " + fe.Param() + unit
+	case "min":
+		return "must be at least " + fe.Param() + unit
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return fmt.Sprintf("is invalid (%s)", fe.Tag())
+	}
+}
+
+// article names a JSON type for a type mismatch
+func article(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,120 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"portfolio/internal/testutil"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+type testLink struct {
+	Label string `json:"label" binding:"notblank"`
+	URL   string `json:"url" binding:"required,http_url"`
+}
+
+type testAuthor struct {
+	Name string `json:"name" binding:"notblank,max=5"`
+	Age  int    `json:"age"`
+}
+
+type testRequest struct {
+	Title  string     `json:"title" binding:"notblank,max=10"`
+	Kind   string     `json:"kind" binding:"required,oneof=post page"`
+	Author testAuthor `json:"author"`
+	Links  []testLink `json:"links" binding:"max=2,dive"`
+}
+
+// newTestRouter binds a testRequest on POST /, answering 204 when it is valid
+func newTestRouter() *gin.Engine {
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		var req testRequest
+		if Bind(c, &req) {
+			c.Status(http.StatusNoContent)
+		}
+	})
+	return r
+}
+
+// decodeErrors returns the field errors of a 422 response
+func decodeErrors(t *testing.T, w *httptest.ResponseRecorder) []FieldError {
+	t.Helper()
+	var resp struct {
+		Errors []FieldError `json:"errors"`
+	}
+	testutil.Decode(t, w, &resp)
+	return resp.Errors
+}
+
+func TestBindFieldErrors(t *testing.T) {
+	valid := func() map[string]any {
+		return map[string]any{
+			"title":  "Hello",
+			"kind":   "post",
+			"author": map[string]any{"name": "Ann", "age": 30},
+			"links":  []map[string]any{{"label": "Home", "url": "https://example.com"}},
+		}
+	}
+	r := newTestRouter()
+
+	if w := testutil.Do(r, http.MethodPost, "/", valid()); w.Code != http.StatusNoContent {
+		t.Fatalf("valid request: got %d, want 204: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		change func(body map[string]any)
+		field  string
+		reason string
+	}{
+		{"notblank", func(b map[string]any) { b["title"] = "   " }, "title", "is required"},
+		{"max on a string", func(b map[string]any) { b["title"] = "A very long title" }, "title", "must be at most 10 characters"},
+		{"oneof", func(b map[string]any) { b["kind"] = "note" }, "kind", "must be one of: post, page"},
+		{"nested notblank", func(b map[string]any) { b["author"] = map[string]any{"name": ""} }, "author.name", "is required"},
+		{"nested max", func(b map[string]any) { b["author"] = map[string]any{"name": "Annabelle"} }, "author.name", "must be at most 5 characters"},
+		{"http_url in a list", func(b map[string]any) {
+			b["links"] = []map[string]any{{"label": "Home", "url": "https://example.com"}, {"label": "FTP", "url": "ftp://example.com"}}
+		}, "links[1].url", "must be an http or https URL"},
+		{"notblank in a list", func(b map[string]any) { b["links"] = []map[string]any{{"label": " ", "url": "https://example.com"}} }, "links[0].label", "is required"},
+		{"max on a list", func(b map[string]any) {
+			link := map[string]any{"label": "Home", "url": "https://example.com"}
+			b["links"] = []map[string]any{link, link, link}
+		}, "links", "must be at most 2 items"},
+		{"type mismatch in a nested field", func(b map[string]any) { b["author"] = map[string]any{"name": "Ann", "age": "thirty"} }, "author.age", "must be an integer"},
+	}
+	for _, tt := range tests {
+		body := valid()
+		tt.change(body)
+		w := testutil.Do(r, http.MethodPost, "/", body)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got %d, want 422: %s", tt.name, w.Code, w.Body)
+			continue
+		}
+		errs := decodeErrors(t, w)
+		if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Reason != tt.reason {
+			t.Errorf("%s: errors = %+v, want %s %q", tt.name, errs, tt.field, tt.reason)
+		}
+	}
+}
+
+func TestBindReportsEveryField(t *testing.T) {
+	r := newTestRouter()
+
+	w := testutil.Do(r, http.MethodPost, "/", map[string]any{"title": "", "kind": "", "author": map[string]any{"name": ""}})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d, want 422: %s", w.Code, w.Body)
+	}
+	var fields []string
+	for _, fe := range decodeErrors(t, w) {
+		fields = append(fields, fe.Field)
+	}
+	if len(fields) != 3 || fields[0] != "title" || fields[1] != "kind" || fields[2] != "author.name" {
+		t.Errorf("fields = %v, want title, kind and author.name", fields)
+	}
+}
@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { LogOut, MessageSquare, Home, FolderOpen, User, Edit, Trash2, Save, Plus, TrendingUp, BarChart3, Activity } from 'lucide-react';
-import { apiService, Contact as ApiContact } from '../services/api';
+import { apiService, authFetch, clearSession, Contact as ApiContact, fetchAllPages, problemMessage, readProblem } from '../services/api';
 import AdminProjects from './AdminProjects';
 import { API_BASE_URL } from '../config';
 
@@ -67,57 +67,18 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
       if (activeTab === 'contacts') {
         console.log('Loading contacts with token:', token ? 'Token exists' : 'No token');
         
-        const response = await fetch(`${API_BASE_URL}/admin/contact`, { 
-          method: 'GET',
-          headers: { 
-            'Authorization': `Bearer ${token}`,
-            'Content-Type': 'application/json'
-          }
-        });
-        
-        console.log('Contacts response status:', response.status);
-        
-        if (!response.ok) {
-          throw new Error(`HTTP error! status: ${response.status}`);
-        }
-        
-        const data = await response.json();
+        const data = await fetchAllPages<Contact>(`${API_BASE_URL}/admin/contact`);
         console.log('Contacts data received:', data);
-        
-        if (Array.isArray(data)) {
-          setContacts(data);
-          console.log('Contacts set to state:', data.length, 'items');
-        } else {
-          console.error('Contacts data is not an array:', data);
-          setContacts([]);
-        }
+        setContacts(data);
+        console.log('Contacts set to state:', data.length, 'items');
       } else if (activeTab === 'projects') {
-        console.log('Loading projects from public endpoint...');
-        
-        // Use public projects endpoint - no token needed
-        const response = await fetch(`${API_BASE_URL}/projects`, { 
-          method: 'GET',
-          headers: { 
-            'Content-Type': 'application/json'
-          }
-        });
-        
-        console.log('Projects response status:', response.status);
-        
-        if (!response.ok) {
-          throw new Error(`HTTP error! status: ${response.status}`);
-        }
-        
-        const data = await response.json();
+        console.log('Loading projects from admin endpoint...');
+
+        // The admin endpoint lists drafts and scheduled projects too, the public one only live ones
+        const data = await fetchAllPages<Project>(`${API_BASE_URL}/admin/projects`);
         console.log('Projects data received:', data);
-        
-        if (Array.isArray(data)) {
-          setProjects(data);
-          console.log('Projects set to state:', data.length, 'items');
-        } else {
-          console.error('Projects data is not an array:', data);
-          setProjects([]);
-        }
+        setProjects(data);
+        console.log('Projects set to state:', data.length, 'items');
       } else if (activeTab === 'home') {
         console.log('Loading home data...');
         const data = await apiService.getHome();
@@ -139,7 +100,7 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
 
   const handleLogout = (): void => {
     console.log('Logging out...');
-    localStorage.removeItem('authToken');
+    clearSession();
     if (onLogout) {
       onLogout();
     } else {
@@ -154,10 +115,8 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
 
     try {
       console.log('Deleting contact:', id);
-      const token = localStorage.getItem('authToken');
-      const response = await fetch(`${API_BASE_URL}/admin/contact/${id}`, { 
-        method: 'DELETE',
-        headers: { 'Authorization': `Bearer ${token}` }
+      const response = await authFetch(`${API_BASE_URL}/admin/contact/${id}`, { 
+        method: 'DELETE'
       });
       
       console.log('Delete contact response status:', response.status);
@@ -167,8 +126,8 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
         loadData();
         setTimeout(() => setMessage(''), 3000);
       } else {
-        const errorData = await response.json();
-        setMessage(`Error deleting contact: ${errorData.error || 'Unknown error'}`);
+        const problem = await readProblem(response);
+        setMessage(`Error deleting contact: ${problemMessage(problem, `Request failed (${response.status})`)}`);
       }
     } catch (error) {
       console.error('Delete contact error:', error);
@@ -179,12 +138,10 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
   const updateHome = async (formData: UpdateFormData): Promise<void> => {
     try {
       console.log('Updating home data:', formData);
-      const token = localStorage.getItem('authToken');
-      const response = await fetch(`${API_BASE_URL}/admin/home`, { 
+      const response = await authFetch(`${API_BASE_URL}/admin/home`, { 
         method: 'PUT',
         headers: {
-          'Content-Type': 'application/json',
-          'Authorization': `Bearer ${token}`
+          'Content-Type': 'application/json'
         },
         body: JSON.stringify(formData)
       });
@@ -197,8 +154,8 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
         loadData();
         setTimeout(() => setMessage(''), 3000);
       } else {
-        const errorData = await response.json();
-        setMessage(`Error updating home page: ${errorData.error || 'Unknown error'}`);
+        const problem = await readProblem(response);
+        setMessage(`Error updating home page: ${problemMessage(problem, `Request failed (${response.status})`)}`);
       }
     } catch (error) {
       console.error('Update home error:', error);
@@ -209,12 +166,10 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
   const updateAbout = async (formData: UpdateFormData): Promise<void> => {
     try {
       console.log('Updating about data:', formData);
-      const token = localStorage.getItem('authToken');
-      const response = await fetch(`${API_BASE_URL}/admin/about`, { 
+      const response = await authFetch(`${API_BASE_URL}/admin/about`, { 
         method: 'PUT',
         headers: {
-          'Content-Type': 'application/json',
-          'Authorization': `Bearer ${token}`
+          'Content-Type': 'application/json'
         },
         body: JSON.stringify(formData)
       });
@@ -227,8 +182,8 @@ const AdminDashboard = ({ onLogout }: AdminDashboardProps): JSX.Element => {
         loadData();
         setTimeout(() => setMessage(''), 3000);
       } else {
-        const errorData = await response.json();
-        setMessage(`Error updating about page: ${errorData.error || 'Unknown error'}`);
+        const problem = await readProblem(response);
+        setMessage(`Error updating about page: ${problemMessage(problem, `Request failed (${response.status})`)}`);
       }
     } catch (error) {
       console.error('Update about error:', error);
@@ -1,5 +1,5 @@
 import React, { useState } from 'react';
-import { apiService } from '../services/api';
+import { apiService, clearSession, problemMessage, saveSession } from '../services/api';
 import AdminDashboard from './AdminDashboard';
 import { LogIn, User, Lock, Eye, EyeOff, ArrowLeft } from 'lucide-react';
 
@@ -21,11 +21,11 @@ function AdminLogin() {
       const response = await apiService.login({ username, password });
       
       if (response.token) {
-        localStorage.setItem('authToken', response.token);
+        saveSession(response);
         setIsLoggedIn(true);
         setMessage(`Success: ${response.message}`);
       } else {
-        setMessage('Error: No token received');
+        setMessage(`Error: ${problemMessage(response, 'No token received')}`);
       }
     } catch (error) {
       console.error('Login error:', error);
@@ -36,7 +36,7 @@ function AdminLogin() {
   };
 
   const handleLogout = () => {
-    localStorage.removeItem('authToken');
+    clearSession();
     setIsLoggedIn(false);
     setUsername('');
     setPassword('');
@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { Github, ExternalLink, Edit, Trash2, Plus, Save, X, ImageIcon } from 'lucide-react';
 import { API_ORIGIN } from '../config';
+import { authFetch, fetchAllPages, problemMessage } from '../services/api';
 
 interface Project {
   id?: number;
@@ -41,27 +42,14 @@ const AdminProjects: React.FC = () => {
 
   const fetchProjects = async () => {
     try {
-      console.log('Fetching projects from:', `${API_ORIGIN}/api/projects`);
-      
-      const response = await fetch(`${API_ORIGIN}/api/projects`, {
-        method: 'GET',
-        headers: {
-          'Content-Type': 'application/json'
-        }
-      });
-      
-      console.log('Fetch response status:', response.status);
-      
-      if (!response.ok) {
-        const errData = await safeJson(response);
-        throw new Error(errData?.error || `Fetch failed with ${response.status}`);
-      }
-      
-      const data = await safeJson(response);
+      console.log('Fetching projects from:', `${API_ORIGIN}/api/admin/projects`);
+
+      // Every page of the admin list, drafts and scheduled projects included
+      const data = await fetchAllPages<Project>(`${API_ORIGIN}/api/admin/projects`);
       console.log('Fetched projects data:', data);
       
-      setProjects(Array.isArray(data) ? data : []);
-      setMessage(`✅ Loaded ${data?.length || 0} projects`);
+      setProjects(data);
+      setMessage(`✅ Loaded ${data.length} projects`);
       setTimeout(() => setMessage(''), 2000);
     } catch (error) {
       console.error('Failed to fetch projects:', error);
@@ -110,11 +98,10 @@ const AdminProjects: React.FC = () => {
       console.log(`Making ${method} request to:`, url);
       console.log('Project data:', currentProject);
 
-      const response = await fetch(url, {
+      const response = await authFetch(url, {
         method,
         headers: {
-          'Content-Type': 'application/json',
-          'Authorization': `Bearer ${token}`
+          'Content-Type': 'application/json'
         },
         body: JSON.stringify(currentProject)
       });
@@ -130,7 +117,7 @@ const AdminProjects: React.FC = () => {
         resetForm();
         setTimeout(() => setMessage(''), 3000);
       } else {
-        setMessage(`❌ Error: ${result?.error || `Operation failed (${response.status})`}`);
+        setMessage(`❌ Error: ${problemMessage(result, `Operation failed (${response.status})`)}`);
       }
     } catch (error) {
       console.error('Save error:', error);
@@ -161,11 +148,8 @@ const AdminProjects: React.FC = () => {
     try {
       console.log('Deleting project:', id);
       
-      const response = await fetch(`${API_ORIGIN}/api/admin/projects/${id}`, {
-        method: 'DELETE',
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
+      const response = await authFetch(`${API_ORIGIN}/api/admin/projects/${id}`, {
+        method: 'DELETE'
       });
 
       console.log('Delete response status:', response.status);
@@ -177,7 +161,7 @@ const AdminProjects: React.FC = () => {
         fetchProjects();
         setTimeout(() => setMessage(''), 3000);
       } else {
-        setMessage(`❌ Error: ${result?.error || `Delete failed (${response.status})`}`);
+        setMessage(`❌ Error: ${problemMessage(result, `Delete failed (${response.status})`)}`);
       }
     } catch (error) {
       console.error('Delete error:', error);
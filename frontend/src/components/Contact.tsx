@@ -1,6 +1,6 @@
-import React, { useState } from 'react';
+import React, { useCallback, useEffect, useState } from 'react';
 import '../assets/styles/Contact.scss';
-import { apiService } from '../services/api';
+import { apiService, problemMessage } from '../services/api';
 
 function Contact() {
   const [name, setName] = useState<string>('');
@@ -10,6 +10,22 @@ function Contact() {
   const [isLoading, setIsLoading] = useState<boolean>(false);
   const [successMessage, setSuccessMessage] = useState<string>('');
   const [errorMessage, setErrorMessage] = useState<string>('');
+  const [formToken, setFormToken] = useState<string>('');
+  const [website, setWebsite] = useState<string>('');
+
+  // The backend takes messages sent without a fresh form token for spam
+  const loadFormToken = useCallback(async () => {
+    try {
+      const data = await apiService.getContactToken();
+      setFormToken(data.token);
+    } catch (error) {
+      console.error('Contact token error:', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    loadFormToken();
+  }, [loadFormToken]);
 
   const sendEmail = async (e: any) => {
     e.preventDefault();
@@ -31,7 +47,9 @@ function Contact() {
         name: name.trim(),
         email: email.trim(),
         phone: phone.trim(),
-        message: message.trim()
+        message: message.trim(),
+        form_token: formToken,
+        website: website
       };
       
       const response = await apiService.sendContact(contactData);
@@ -43,8 +61,10 @@ function Contact() {
         setEmail('');
         setPhone('');
         setMessage('');
+        // A new token for the next message
+        loadFormToken();
       } else {
-        setErrorMessage('❌ Failed to send message. Please try again.');
+        setErrorMessage(`❌ ${problemMessage(response, 'Failed to send message. Please try again.')}`);
       }
     } catch (error) {
       console.error('Contact form error:', error);
@@ -143,6 +163,18 @@ function Contact() {
               />
             </div>
             
+            {/* Honeypot: hidden from people, filled in by bots */}
+            <input
+              type="text"
+              name="website"
+              value={website}
+              onChange={(e) => setWebsite(e.target.value)}
+              tabIndex={-1}
+              autoComplete="off"
+              aria-hidden="true"
+              style={{ position: 'absolute', left: '-10000px', width: '1px', height: '1px', overflow: 'hidden' }}
+            />
+
             <div style={{ marginBottom: '20px' }}>
               <textarea
                 placeholder="Message *"
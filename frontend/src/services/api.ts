@@ -1,4 +1,4 @@
-import { API_BASE_URL } from '../config';
+import { API_BASE_URL, API_ORIGIN } from '../config';
 
 // Interface for contact form submission (frontend to backend)
 export interface ContactForm {
@@ -6,6 +6,14 @@ export interface ContactForm {
   email: string;
   phone: string;
   message: string;
+  form_token?: string; // From getContactToken, so the message is not taken for spam
+  website?: string; // Honeypot: hidden from people, left empty
+}
+
+// Interface for the contact form token response
+export interface ContactToken {
+  token: string;
+  captcha: boolean;
 }
 
 // Interface for contact data from database (backend to frontend) 
@@ -48,7 +56,9 @@ export interface LoginRequest {
 // Interface for login response
 export interface LoginResponse {
   message: string;
-  token: string;
+  token: string; // Access token, valid for expires_in seconds
+  refresh_token: string; // Trades for new tokens at /refresh, once
+  expires_in: number;
   user: {
     id: number;
     username: string;
@@ -56,6 +66,144 @@ export interface LoginResponse {
   };
 }
 
+// Interface for error responses (application/problem+json, RFC 9457)
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string; // Path of the request
+  request_id?: string; // Quote it in bug reports
+  errors?: { field: string; reason: string }[]; // Broken rules of a request body
+  retry_after?: number; // Seconds to wait, with 429 responses
+}
+
+// problemMessage returns the text to show for an error response body, or fallback when
+// the body is not a problem
+export function problemMessage(body: unknown, fallback: string): string {
+  const problem = body as Partial<Problem> | null;
+  if (!problem || typeof problem !== 'object') {
+    return fallback;
+  }
+  const message = problem.detail ?? problem.title ?? fallback;
+  if (problem.errors && problem.errors.length > 0) {
+    return `${message}: ${problem.errors.map((e) => `${e.field} ${e.reason}`).join(', ')}`;
+  }
+  return message;
+}
+
+// readProblem returns the problem in an error response, or null when the body is not JSON
+export async function readProblem(response: Response): Promise<Problem | null> {
+  try {
+    return await response.json();
+  } catch {
+    return null;
+  }
+}
+
+// saveSession stores the tokens of a login or refresh response
+export function saveSession(session: Pick<LoginResponse, 'token' | 'refresh_token'>): void {
+  localStorage.setItem('authToken', session.token);
+  localStorage.setItem('refreshToken', session.refresh_token);
+}
+
+// clearSession forgets the stored tokens and ends the session on the server
+export function clearSession(): void {
+  const refreshToken = localStorage.getItem('refreshToken');
+  localStorage.removeItem('authToken');
+  localStorage.removeItem('refreshToken');
+  if (refreshToken) {
+    fetch(`${API_BASE_URL}/logout`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: refreshToken })
+    }).catch((error) => console.error('Logout error:', error));
+  }
+}
+
+// Refresh tokens work once, so requests that fail together share one refresh
+let refreshing: Promise<boolean> | null = null;
+
+// refreshSession trades the stored refresh token for new tokens and reports whether it worked
+export function refreshSession(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = localStorage.getItem('refreshToken');
+      if (!refreshToken) {
+        return false;
+      }
+      try {
+        const response = await fetch(`${API_BASE_URL}/refresh`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ refresh_token: refreshToken })
+        });
+        if (!response.ok) {
+          localStorage.removeItem('authToken');
+          localStorage.removeItem('refreshToken');
+          return false;
+        }
+        saveSession(await response.json());
+        return true;
+      } catch (error) {
+        console.error('Refresh error:', error);
+        return false;
+      }
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+}
+
+// authFetch sends a request with the stored access token. When the token has expired it
+// refreshes the session and sends the request again, so admins stay logged in.
+export async function authFetch(url: string, init: RequestInit = {}): Promise<Response> {
+  const send = () => {
+    const headers = new Headers(init.headers);
+    const token = localStorage.getItem('authToken');
+    if (token) {
+      headers.set('Authorization', `Bearer ${token}`);
+    }
+    return fetch(url, { ...init, headers });
+  };
+
+  const response = await send();
+  if (response.status === 401 && (await refreshSession())) {
+    return send();
+  }
+  return response;
+}
+
+// nextPageLink returns the rel="next" target of a Link header, or null on the last page
+function nextPageLink(link: string | null): string | null {
+  const match = link?.match(/<([^>]*)>;\s*rel="next"/);
+  return match ? match[1] : null;
+}
+
+// fetchAllPages reads every page of a list endpoint (X-Total-Count and Link headers), so a
+// screen sees the whole collection and not only the first page of 20
+export async function fetchAllPages<T>(url: string): Promise<T[]> {
+  const items: T[] = [];
+  let next: string | null = `${url}${url.includes('?') ? '&' : '?'}per_page=100`;
+  while (next) {
+    const response: Response = await authFetch(next, { method: 'GET', headers: { 'Content-Type': 'application/json' } });
+    if (!response.ok) {
+      throw new Error(problemMessage(await readProblem(response), `Request failed (${response.status})`));
+    }
+    const page = await response.json();
+    if (!Array.isArray(page)) {
+      throw new Error('Expected a list in the response');
+    }
+    items.push(...page);
+
+    // Links carry the path only, so they go to the same origin as the first request
+    const link = nextPageLink(response.headers.get('Link'));
+    next = link ? `${API_ORIGIN}${link}` : null;
+  }
+  return items;
+}
+
 // API service object with all endpoint methods
 export const apiService = {
   // Authentication API - user login
@@ -70,6 +218,15 @@ export const apiService = {
     return response.json();
   },
 
+  // Contact API - get a token for the contact form to send back
+  async getContactToken(): Promise<ContactToken> {
+    const response = await fetch(`${API_BASE_URL}/contact/token`, { cache: 'no-store' });
+    if (!response.ok) {
+      throw new Error(`Contact token request failed: ${response.status}`);
+    }
+    return response.json();
+  },
+
   // Contact API - send contact form data
   async sendContact(contact: ContactForm) {
     const response = await fetch(`${API_BASE_URL}/contact`, {